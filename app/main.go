@@ -15,6 +15,7 @@ import (
 	"github.com/rande/gonode/core/embed"
 	"github.com/rande/gonode/core/form"
 	"github.com/rande/gonode/core/logger"
+	"github.com/rande/gonode/core/migration"
 	"github.com/rande/gonode/core/router"
 	"github.com/rande/gonode/core/security"
 	"github.com/rande/gonode/modules/api"
@@ -33,6 +34,7 @@ import (
 	"github.com/rande/gonode/modules/setup"
 	"github.com/rande/gonode/modules/template"
 	"github.com/rande/gonode/modules/user"
+	"github.com/rande/gonode/modules/webhook"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -59,10 +61,12 @@ func Configure(configFile string) *goapp.Lifecycle {
 	bootstrap.Configure(l, conf)
 	logger.Configure(l, conf)
 	commands.Configure(l, conf)
+	migration.Configure(l, conf)
 	security.ConfigureCors(l, conf)
 	node_guard.Configure(l, conf)
 	security.ConfigureSecurity(l, conf)
 	api.Configure(l, conf)
+	webhook.Configure(l, conf)
 	setup.Configure(l, conf)
 	prism.Configure(l, conf)
 	router.Configure(l, conf)
@@ -83,6 +87,63 @@ func main() {
 				Configure: Configure,
 			}, nil
 		},
+		"migrate up": func() (cli.Command, error) {
+			return &commands.MigrateCommand{
+				Ui:        ui,
+				Action:    commands.MIGRATE_UP,
+				Configure: Configure,
+			}, nil
+		},
+		"migrate down": func() (cli.Command, error) {
+			return &commands.MigrateCommand{
+				Ui:        ui,
+				Action:    commands.MIGRATE_DOWN,
+				Configure: Configure,
+			}, nil
+		},
+		"migrate status": func() (cli.Command, error) {
+			return &commands.MigrateCommand{
+				Ui:        ui,
+				Action:    commands.MIGRATE_STATUS,
+				Configure: Configure,
+			}, nil
+		},
+		"export": func() (cli.Command, error) {
+			return &commands.ExportCommand{
+				Ui:        ui,
+				Configure: Configure,
+			}, nil
+		},
+		"import": func() (cli.Command, error) {
+			return &commands.ImportCommand{
+				Ui:        ui,
+				Configure: Configure,
+			}, nil
+		},
+		"trash purge": func() (cli.Command, error) {
+			return &commands.TrashPurgeCommand{
+				Ui:        ui,
+				Configure: Configure,
+			}, nil
+		},
+		"relations rebuild": func() (cli.Command, error) {
+			return &commands.RelationsRebuildCommand{
+				Ui:        ui,
+				Configure: Configure,
+			}, nil
+		},
+		"events redeliver": func() (cli.Command, error) {
+			return &commands.EventsRedeliverCommand{
+				Ui:        ui,
+				Configure: Configure,
+			}, nil
+		},
+		"worker": func() (cli.Command, error) {
+			return &commands.WorkerCommand{
+				Ui:        ui,
+				Configure: Configure,
+			}, nil
+		},
 	}
 
 	exitStatus, err := c.Run()
@@ -19,6 +19,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/zenazn/goji/web"
 	"github.com/zenazn/goji/web/middleware"
+	_ "modernc.org/sqlite"
 )
 
 func Configure(l *goapp.Lifecycle, conf *config.Config) {
@@ -58,15 +59,15 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 
 		app.Set("gonode.postgres.connection", func(app *goapp.App) interface{} {
 			sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
-			db, err := sql.Open("postgres", conf.Databases["master"].DSN)
-
-			db.SetMaxIdleConns(8)
-			db.SetMaxOpenConns(64)
+			db, err := sql.Open(conf.Databases["master"].Driver(), conf.Databases["master"].DSN)
 
 			if err != nil {
 				log.Fatal(err)
 			}
 
+			db.SetMaxIdleConns(8)
+			db.SetMaxOpenConns(64)
+
 			err = db.Ping()
 			if err != nil {
 				log.Fatal(err)
@@ -76,9 +77,27 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 		})
 
 		app.Set("gonode.postgres.subscriber", func(app *goapp.App) interface{} {
-			return base.NewSubscriber(
-				conf.Databases["master"].DSN,
-				app.Get("logger").(*log.Logger),
+			var sub *base.Subscriber
+
+			if conf.Databases["master"].Driver() != config.DATABASE_POSTGRES {
+				sub = base.NewLocalSubscriber(app.Get("logger").(*log.Logger))
+			} else {
+				sub = base.NewSubscriber(
+					conf.Databases["master"].DSN,
+					app.Get("logger").(*log.Logger),
+				)
+			}
+
+			// the durable listeners run in the worker command
+			sub.Passive = !conf.Listeners.InProcess
+
+			return sub
+		})
+
+		app.Set("gonode.dialect", func(app *goapp.App) interface{} {
+			return base.NewDialect(
+				conf.Databases["master"].Driver(),
+				app.Get("gonode.postgres.subscriber").(*base.Subscriber),
 			)
 		})
 
@@ -105,7 +124,10 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 		logger := app.Get("logger").(*log.Logger)
 		logger.WithFields(log.Fields{
 			"module": "commands.server",
-		}).Debug("Closing PostgreSQL connection")
+		}).Debug("Closing database connection")
+
+		// the consumers complete the current event and release their claims before the connection is closed
+		app.Get("gonode.postgres.subscriber").(*base.Subscriber).Stop()
 
 		db := app.Get("gonode.postgres.connection").(*sql.DB)
 		err := db.Close()
@@ -119,7 +141,7 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 
 		logger.WithFields(log.Fields{
 			"module": "commands.server",
-		}).Debug("End closing PostgreSQL connection")
+		}).Debug("End closing database connection")
 
 		return err
 	})
@@ -0,0 +1,110 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+)
+
+type EventsRedeliverCommand struct {
+	Ui         cli.Ui
+	ConfigFile string
+	From       string
+	To         string
+	Channels   string
+	Configure  func(configFile string) *goapp.Lifecycle
+}
+
+func (c *EventsRedeliverCommand) Help() string {
+	return `Send again the events stored in the outbox between two dates, the consumers handle them again
+
+Options:
+  -config=server.toml.dist     The configuration file
+  -from=2023-01-02T15:04:05Z   The events created at or after this date (RFC 3339), required
+  -to=2023-01-03T15:04:05Z     The events created before this date (RFC 3339), default: now
+  -channels=media_file_download  Comma separated list of channels, default: all
+`
+}
+
+func (c *EventsRedeliverCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("events redeliver", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	cmdFlags.StringVar(&c.ConfigFile, "config", "server.toml.dist", "")
+	cmdFlags.StringVar(&c.From, "from", "", "")
+	cmdFlags.StringVar(&c.To, "to", "", "")
+	cmdFlags.StringVar(&c.Channels, "channels", "", "")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	if c.From == "" {
+		c.Ui.Error("The from option is required")
+
+		return 1
+	}
+
+	from, err := time.Parse(time.RFC3339, c.From)
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Invalid from date: %s", err))
+
+		return 1
+	}
+
+	to := time.Now()
+
+	if c.To != "" {
+		if to, err = time.Parse(time.RFC3339, c.To); err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid to date: %s", err))
+
+			return 1
+		}
+	}
+
+	if !from.Before(to) {
+		c.Ui.Error("The from date must be before the to date")
+
+		return 1
+	}
+
+	channels := make([]string, 0)
+	for _, channel := range strings.Split(c.Channels, ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			channels = append(channels, channel)
+		}
+	}
+
+	l := c.Configure(c.ConfigFile)
+
+	l.Run(func(app *goapp.App, state *goapp.GoroutineState) error {
+		count, err := app.Get("gonode.outbox").(*base.Outbox).Redeliver(channels, from, to)
+
+		c.Ui.Output(fmt.Sprintf("Redelivered events: %d", count))
+
+		if err != nil {
+			c.Ui.Error(err.Error())
+		}
+
+		return err
+	})
+
+	return l.Go(goapp.NewApp())
+}
+
+func (c *EventsRedeliverCommand) Synopsis() string {
+	return "send again the stored events"
+}
@@ -0,0 +1,115 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+)
+
+type ExportCommand struct {
+	Ui         cli.Ui
+	ConfigFile string
+	Output     string
+	Types      string
+	Revisions  bool
+	Binaries   string
+	Configure  func(configFile string) *goapp.Lifecycle
+}
+
+func (c *ExportCommand) Help() string {
+	return `Export the nodes as newline delimited JSON, one node per line
+
+Options:
+  -config=server.toml.dist  The configuration file
+  -output=-                 The export file, - is the standard output
+  -types=                   Comma separated list of node types to export, all types if empty
+  -revisions                Export the node's revisions
+  -binaries=                Directory used to store the vault's binaries, binaries are not exported if empty
+`
+}
+
+func (c *ExportCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("export", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	cmdFlags.StringVar(&c.ConfigFile, "config", "server.toml.dist", "")
+	cmdFlags.StringVar(&c.Output, "output", "-", "")
+	cmdFlags.StringVar(&c.Types, "types", "", "")
+	cmdFlags.BoolVar(&c.Revisions, "revisions", false, "")
+	cmdFlags.StringVar(&c.Binaries, "binaries", "", "")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	l := c.Configure(c.ConfigFile)
+
+	l.Run(func(app *goapp.App, state *goapp.GoroutineState) error {
+		options := &base.ExportOptions{
+			Revisions: c.Revisions,
+			Binaries:  c.Binaries,
+		}
+
+		if c.Types != "" {
+			options.Types = strings.Split(c.Types, ",")
+		}
+
+		if c.Binaries != "" {
+			if err := os.MkdirAll(c.Binaries, 0755); err != nil {
+				c.Ui.Error(err.Error())
+
+				return err
+			}
+		}
+
+		var w io.Writer = os.Stdout
+
+		if c.Output != "-" {
+			file, err := os.Create(c.Output)
+
+			if err != nil {
+				c.Ui.Error(err.Error())
+
+				return err
+			}
+
+			defer file.Close()
+
+			w = file
+		}
+
+		count, err := app.Get("gonode.exporter").(*base.Exporter).Export(w, options)
+
+		if err != nil {
+			c.Ui.Error(err.Error())
+
+			return err
+		}
+
+		// the standard output only contains the export
+		if c.Output != "-" {
+			c.Ui.Output(fmt.Sprintf("Exported nodes: %d", count))
+		}
+
+		return nil
+	})
+
+	return l.Go(goapp.NewApp())
+}
+
+func (c *ExportCommand) Synopsis() string {
+	return "export the nodes as newline delimited JSON"
+}
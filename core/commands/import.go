@@ -0,0 +1,108 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+)
+
+type ImportCommand struct {
+	Ui         cli.Ui
+	ConfigFile string
+	Input      string
+	Upsert     bool
+	DryRun     bool
+	Hooks      bool
+	Binaries   string
+	Configure  func(configFile string) *goapp.Lifecycle
+}
+
+func (c *ImportCommand) Help() string {
+	return `Import nodes from a newline delimited JSON export, the uuids, parents and paths are preserved
+
+Options:
+  -config=server.toml.dist  The configuration file
+  -input=-                  The export file, - is the standard input
+  -upsert                   Update the existing nodes, the existing nodes are skipped otherwise
+  -dry-run                  Validate the import, nothing is stored
+  -hooks                    Run the handler's PreInsert/PostInsert and PreUpdate/PostUpdate hooks
+  -binaries=                Directory containing the vault's binaries, binaries are not imported if empty
+`
+}
+
+func (c *ImportCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("import", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	cmdFlags.StringVar(&c.ConfigFile, "config", "server.toml.dist", "")
+	cmdFlags.StringVar(&c.Input, "input", "-", "")
+	cmdFlags.BoolVar(&c.Upsert, "upsert", false, "")
+	cmdFlags.BoolVar(&c.DryRun, "dry-run", false, "")
+	cmdFlags.BoolVar(&c.Hooks, "hooks", false, "")
+	cmdFlags.StringVar(&c.Binaries, "binaries", "", "")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	l := c.Configure(c.ConfigFile)
+
+	l.Run(func(app *goapp.App, state *goapp.GoroutineState) error {
+		var r io.Reader = os.Stdin
+
+		if c.Input != "-" {
+			file, err := os.Open(c.Input)
+
+			if err != nil {
+				c.Ui.Error(err.Error())
+
+				return err
+			}
+
+			defer file.Close()
+
+			r = file
+		}
+
+		result, err := app.Get("gonode.importer").(*base.Importer).Import(r, &base.ImportOptions{
+			Upsert:   c.Upsert,
+			DryRun:   c.DryRun,
+			Hooks:    c.Hooks,
+			Binaries: c.Binaries,
+		})
+
+		if err != nil {
+			c.Ui.Error(err.Error())
+
+			return err
+		}
+
+		if c.DryRun {
+			c.Ui.Output("Dry run, nothing has been stored")
+		}
+
+		c.Ui.Output(fmt.Sprintf("Created nodes: %d", result.Created))
+		c.Ui.Output(fmt.Sprintf("Updated nodes: %d", result.Updated))
+		c.Ui.Output(fmt.Sprintf("Skipped nodes: %d", result.Skipped))
+
+		return nil
+	})
+
+	return l.Go(goapp.NewApp())
+}
+
+func (c *ImportCommand) Synopsis() string {
+	return "import nodes from a newline delimited JSON export"
+}
@@ -0,0 +1,133 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/core/migration"
+)
+
+const (
+	MIGRATE_UP     = "up"
+	MIGRATE_DOWN   = "down"
+	MIGRATE_STATUS = "status"
+)
+
+type MigrateCommand struct {
+	Ui         cli.Ui
+	Action     string
+	ConfigFile string
+	Steps      int
+	Configure  func(configFile string) *goapp.Lifecycle
+}
+
+func (c *MigrateCommand) Help() string {
+	switch c.Action {
+	case MIGRATE_UP:
+		return `Apply all the pending database migrations
+
+Options:
+  -config=server.toml.dist  The configuration file
+`
+	case MIGRATE_DOWN:
+		return `Revert the last applied database migrations
+
+Options:
+  -config=server.toml.dist  The configuration file
+  -steps=1                  The number of migrations to revert
+`
+	default:
+		return `Display the status of the database migrations
+
+Options:
+  -config=server.toml.dist  The configuration file
+`
+	}
+}
+
+func (c *MigrateCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("migrate "+c.Action, flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	cmdFlags.StringVar(&c.ConfigFile, "config", "server.toml.dist", "")
+	cmdFlags.IntVar(&c.Steps, "steps", 1, "")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	l := c.Configure(c.ConfigFile)
+
+	l.Run(func(app *goapp.App, state *goapp.GoroutineState) error {
+		migrator := app.Get("gonode.migrator").(*migration.Migrator)
+
+		var done []*migration.Migration
+		var err error
+
+		switch c.Action {
+		case MIGRATE_UP:
+			done, err = migrator.Up()
+		case MIGRATE_DOWN:
+			done, err = migrator.Down(c.Steps)
+		default:
+			return c.status(migrator)
+		}
+
+		for _, m := range done {
+			c.Ui.Output(fmt.Sprintf("%s %s:%d - %s", c.Action, m.Module, m.Version, m.Name))
+		}
+
+		if err != nil {
+			c.Ui.Error(err.Error())
+
+			return err
+		}
+
+		c.Ui.Output(fmt.Sprintf("Migrations executed: %d", len(done)))
+
+		return nil
+	})
+
+	return l.Go(goapp.NewApp())
+}
+
+func (c *MigrateCommand) status(migrator *migration.Migrator) error {
+	list, err := migrator.Status()
+
+	if err != nil {
+		c.Ui.Error(err.Error())
+
+		return err
+	}
+
+	for _, s := range list {
+		appliedAt := "pending"
+		if s.Applied {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+
+		c.Ui.Output(fmt.Sprintf("%-20s %-20s %4d - %s", appliedAt, s.Module, s.Version, s.Name))
+	}
+
+	return nil
+}
+
+func (c *MigrateCommand) Synopsis() string {
+	switch c.Action {
+	case MIGRATE_UP:
+		return "apply the pending database migrations"
+	case MIGRATE_DOWN:
+		return "revert the last database migrations"
+	default:
+		return "display the database migrations status"
+	}
+}
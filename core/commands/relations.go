@@ -0,0 +1,65 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+)
+
+type RelationsRebuildCommand struct {
+	Ui         cli.Ui
+	ConfigFile string
+	Configure  func(configFile string) *goapp.Lifecycle
+}
+
+func (c *RelationsRebuildCommand) Help() string {
+	return `Rebuild the relations table from the nodes' reference fields
+
+The command must be run once after upgrading, so the nodes created before the relations
+table existed are checked by the integrity option and returned by the relations endpoints.
+
+Options:
+  -config=server.toml.dist  The configuration file
+`
+}
+
+func (c *RelationsRebuildCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("relations rebuild", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	cmdFlags.StringVar(&c.ConfigFile, "config", "server.toml.dist", "")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	l := c.Configure(c.ConfigFile)
+
+	l.Run(func(app *goapp.App, state *goapp.GoroutineState) error {
+		count, err := app.Get("gonode.manager").(*base.SqlNodeManager).RebuildRelations()
+
+		c.Ui.Output(fmt.Sprintf("Processed nodes: %d", count))
+
+		if err != nil {
+			c.Ui.Error(err.Error())
+		}
+
+		return err
+	})
+
+	return l.Go(goapp.NewApp())
+}
+
+func (c *RelationsRebuildCommand) Synopsis() string {
+	return "rebuild the nodes' relations"
+}
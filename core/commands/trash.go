@@ -0,0 +1,82 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/core/config"
+	"github.com/rande/gonode/modules/base"
+)
+
+type TrashPurgeCommand struct {
+	Ui         cli.Ui
+	ConfigFile string
+	Retention  string
+	Configure  func(configFile string) *goapp.Lifecycle
+}
+
+func (c *TrashPurgeCommand) Help() string {
+	return `Remove the nodes deleted before the retention period (rows, revisions and binaries)
+
+Options:
+  -config=server.toml.dist  The configuration file
+  -retention=720h           Override the trash.retention setting
+`
+}
+
+func (c *TrashPurgeCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("trash purge", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	cmdFlags.StringVar(&c.ConfigFile, "config", "server.toml.dist", "")
+	cmdFlags.StringVar(&c.Retention, "retention", "", "")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	l := c.Configure(c.ConfigFile)
+
+	l.Run(func(app *goapp.App, state *goapp.GoroutineState) error {
+		conf := app.Get("gonode.configuration").(*config.Config)
+
+		value := conf.Trash.Retention
+		if c.Retention != "" {
+			value = c.Retention
+		}
+
+		retention, err := time.ParseDuration(value)
+
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid retention: %s", err))
+
+			return err
+		}
+
+		count, err := app.Get("gonode.trash").(*base.Trash).Purge(retention)
+
+		c.Ui.Output(fmt.Sprintf("Purged nodes: %d", count))
+
+		if err != nil {
+			c.Ui.Error(err.Error())
+		}
+
+		return err
+	})
+
+	return l.Go(goapp.NewApp())
+}
+
+func (c *TrashPurgeCommand) Synopsis() string {
+	return "purge the deleted nodes"
+}
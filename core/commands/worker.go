@@ -0,0 +1,85 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package commands
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mitchellh/cli"
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/core/config"
+	log "github.com/sirupsen/logrus"
+)
+
+type WorkerCommand struct {
+	Ui         cli.Ui
+	ConfigFile string
+	Configure  func(configFile string) *goapp.Lifecycle
+}
+
+func (c *WorkerCommand) Help() string {
+	return `Run the pub/sub listeners and the job workers without the http server
+
+The server should run with the listeners.in_process option set to false.
+
+Options:
+  -config=server.toml.dist     The configuration file
+`
+}
+
+func (c *WorkerCommand) Run(args []string) int {
+	cmdFlags := flag.NewFlagSet("worker", flag.ContinueOnError)
+	cmdFlags.Usage = func() {
+		c.Ui.Output(c.Help())
+	}
+
+	cmdFlags.StringVar(&c.ConfigFile, "config", "server.toml.dist", "")
+
+	if err := cmdFlags.Parse(args); err != nil {
+		return 1
+	}
+
+	l := c.Configure(c.ConfigFile)
+
+	l.Config(func(app *goapp.App) error {
+		// the worker always runs the listeners, whatever the server's configuration
+		app.Get("gonode.configuration").(*config.Config).Listeners.InProcess = true
+
+		return nil
+	})
+
+	l.Run(func(app *goapp.App, state *goapp.GoroutineState) error {
+		logger := app.Get("logger").(*log.Logger)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+
+		logger.WithFields(log.Fields{
+			"module": "command.worker",
+		}).Info("Worker started")
+
+		select {
+		case <-signals:
+		case <-state.In:
+		}
+
+		logger.WithFields(log.Fields{
+			"module": "command.worker",
+		}).Info("Worker received signal, stopping")
+
+		return nil
+	})
+
+	return l.Go(goapp.NewApp())
+}
+
+func (c *WorkerCommand) Synopsis() string {
+	return "run the listeners and the job workers"
+}
@@ -54,6 +54,11 @@ type Media struct {
 	Image *MediaImage `toml:"image"`
 }
 
+const (
+	DATABASE_POSTGRES = "postgres"
+	DATABASE_SQLITE   = "sqlite"
+)
+
 type Database struct {
 	Name    string `toml:"name"`
 	DSN     string `toml:"dsn"`
@@ -62,6 +67,15 @@ type Database struct {
 	Enabled bool   `toml:"enabled"`
 }
 
+// Driver returns the database/sql driver's name, PostgreSQL is used unless the type is sqlite
+func (d *Database) Driver() string {
+	if d.Type == DATABASE_SQLITE {
+		return DATABASE_SQLITE
+	}
+
+	return DATABASE_POSTGRES
+}
+
 type Filesystem struct {
 	Type string `toml:"type"`
 	Path string `toml:"path"`
@@ -82,23 +96,102 @@ type Logger struct {
 	Hooks  map[string]map[string]interface{} `toml:"hooks"`
 }
 
+type Trash struct {
+	Retention string `toml:"retention"` // duration before a deleted node is purged, ie: 720h
+	Interval  string `toml:"interval"`  // interval of the purge job, the job is disabled if empty
+}
+
+type Publication struct {
+	Interval string `toml:"interval"` // interval of the publish job, the job is disabled if empty
+}
+
+type WorkflowTransition struct {
+	Name  string   `toml:"name"`
+	From  []int    `toml:"from"`
+	To    int      `toml:"to"`
+	Roles []string `toml:"roles"`
+}
+
+type Workflow struct {
+	Initial     int                   `toml:"initial"`
+	Transitions []*WorkflowTransition `toml:"transitions"`
+}
+
+type Relations struct {
+	Integrity bool `toml:"integrity"` // prevent the removal of a node referenced by other nodes
+}
+
+type Slugs struct {
+	Freeze bool `toml:"freeze"` // prevent the slug of a published node to be changed
+}
+
+type Locks struct {
+	TTL      string `toml:"ttl"`      // default lifetime of an edit lock, ie: 15m
+	Interval string `toml:"interval"` // interval of the expired locks purge, the job is disabled if empty
+}
+
+type Events struct {
+	Retention string `toml:"retention"` // duration before a stored event is removed from the outbox, ie: 168h
+	Interval  string `toml:"interval"`  // interval of the events purge, the job is disabled if empty
+}
+
+type JobQueue struct {
+	Workers     int    `toml:"workers"`      // number of jobs handled at the same time by an instance
+	MaxAttempts int    `toml:"max_attempts"` // a job is dead-lettered after this number of attempts
+	Backoff     string `toml:"backoff"`      // delay before the first retry, doubled on each retry, ie: 10s
+	Timeout     string `toml:"timeout"`      // duration of a job's claim, ie: 5m
+}
+
+type Jobs struct {
+	Poll      string               `toml:"poll"`      // interval used by the workers to check the queues, ie: 1s
+	Retention string               `toml:"retention"` // duration before a done job is removed, ie: 168h
+	Interval  string               `toml:"interval"`  // interval of the done jobs purge, the job is disabled if empty
+	Queues    map[string]*JobQueue `toml:"queues"`
+}
+
+type Listeners struct {
+	InProcess bool   `toml:"in_process"` // run the durable listeners and the job workers in the server, disable it to run them with the worker command
+	ClaimTTL  string `toml:"claim_ttl"`  // the claim of an exclusive listener held by a crashed instance expires after this duration, ie: 1m
+}
+
+type Webhooks struct {
+	MaxAttempts int    `toml:"max_attempts"` // a delivery fails after this number of attempts
+	Backoff     string `toml:"backoff"`      // delay before the first retry, doubled on each retry, ie: 30s
+	Timeout     string `toml:"timeout"`      // timeout of a delivery request, ie: 10s
+}
+
+type Cache struct {
+	Size int `toml:"size"` // number of nodes kept in memory, the nodes are not cached if 0
+}
+
 type Dashboard struct {
 	Prefix string `toml:"prefix"`
 }
 
 type Config struct {
-	Name       string               `toml:"name"`
-	Databases  map[string]*Database `toml:"databases"`
-	Filesystem Filesystem           `toml:"filesystem"`
-	Test       bool                 `toml:"test"`
-	Bind       string               `toml:"bind"`
-	Guard      *Guard               `toml:"guard"`
-	Security   *Security            `toml:"security"`
-	Search     *Search              `toml:"search"`
-	Media      *Media               `toml:"media"`
-	Logger     *Logger              `toml:"logger"`
-	Api        *Api                 `toml:"api"`
-	Dashboard  *Dashboard           `toml:"dashboard"`
+	Name        string               `toml:"name"`
+	Databases   map[string]*Database `toml:"databases"`
+	Filesystem  Filesystem           `toml:"filesystem"`
+	Test        bool                 `toml:"test"`
+	Bind        string               `toml:"bind"`
+	Guard       *Guard               `toml:"guard"`
+	Security    *Security            `toml:"security"`
+	Search      *Search              `toml:"search"`
+	Media       *Media               `toml:"media"`
+	Logger      *Logger              `toml:"logger"`
+	Api         *Api                 `toml:"api"`
+	Dashboard   *Dashboard           `toml:"dashboard"`
+	Trash       *Trash               `toml:"trash"`
+	Relations   *Relations           `toml:"relations"`
+	Slugs       *Slugs               `toml:"slugs"`
+	Locks       *Locks               `toml:"locks"`
+	Events      *Events              `toml:"events"`
+	Webhooks    *Webhooks            `toml:"webhooks"`
+	Jobs        *Jobs                `toml:"jobs"`
+	Listeners   *Listeners           `toml:"listeners"`
+	Publication *Publication         `toml:"publication"`
+	Workflows   map[string]*Workflow `toml:"workflows"`
+	Cache       *Cache               `toml:"cache"`
 }
 
 func NewConfig() *Config {
@@ -123,5 +216,39 @@ func NewConfig() *Config {
 		Dashboard: &Dashboard{
 			Prefix: "/dashboard",
 		},
+		Trash: &Trash{
+			Retention: "720h",
+		},
+		Relations: &Relations{
+			Integrity: false,
+		},
+		Slugs: &Slugs{
+			Freeze: false,
+		},
+		Locks: &Locks{
+			TTL: "15m",
+		},
+		Events: &Events{
+			Retention: "168h",
+		},
+		Webhooks: &Webhooks{
+			MaxAttempts: 5,
+			Backoff:     "30s",
+			Timeout:     "10s",
+		},
+		Jobs: &Jobs{
+			Poll:      "1s",
+			Retention: "168h",
+			Queues:    make(map[string]*JobQueue),
+		},
+		Listeners: &Listeners{
+			InProcess: true,
+			ClaimTTL:  "1m",
+		},
+		Publication: &Publication{},
+		Workflows:   make(map[string]*Workflow),
+		Cache: &Cache{
+			Size: 1024,
+		},
 	}
 }
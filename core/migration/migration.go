@@ -0,0 +1,335 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package migration
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rande/gonode/core/config"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrMigrationExists = errors.New("migration already registered")
+)
+
+// Migration is a numbered schema change shipped by a module. The Up and Down statements
+// can contain the {prefix} placeholder, it is replaced by the configured database prefix.
+// A migration with a Driver is only registered for this database driver, so a module can
+// ship the same version for each driver.
+type Migration struct {
+	Module  string
+	Version int
+	Name    string
+	Driver  string
+	Up      string
+	Down    string
+}
+
+func (m *Migration) Key() string {
+	return fmt.Sprintf("%s:%d", m.Module, m.Version)
+}
+
+type MigrationStatus struct {
+	Module    string     `json:"module"`
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+func NewMigrator(db *sql.DB, prefix string, logger *log.Logger) *Migrator {
+	return &Migrator{
+		Db:         db,
+		Prefix:     prefix,
+		Logger:     logger,
+		modules:    make([]string, 0),
+		migrations: make(map[string][]*Migration),
+	}
+}
+
+type Migrator struct {
+	Db         *sql.DB
+	Prefix     string
+	Driver     string
+	Logger     *log.Logger
+	modules    []string
+	migrations map[string][]*Migration
+}
+
+// Add registers a migration, the migrations are applied in the modules' registration order
+// and then by version. The migrations of another driver are ignored.
+func (m *Migrator) Add(migration *Migration) error {
+	if migration.Driver != "" && migration.Driver != m.Driver {
+		return nil
+	}
+
+	if _, ok := m.migrations[migration.Module]; !ok {
+		m.modules = append(m.modules, migration.Module)
+		m.migrations[migration.Module] = make([]*Migration, 0)
+	}
+
+	for _, v := range m.migrations[migration.Module] {
+		if v.Version == migration.Version {
+			return ErrMigrationExists
+		}
+	}
+
+	m.migrations[migration.Module] = append(m.migrations[migration.Module], migration)
+
+	sort.Slice(m.migrations[migration.Module], func(i, j int) bool {
+		return m.migrations[migration.Module][i].Version < m.migrations[migration.Module][j].Version
+	})
+
+	return nil
+}
+
+// Migrations returns the registered migrations in the order they are applied
+func (m *Migrator) Migrations() []*Migration {
+	list := make([]*Migration, 0)
+
+	for _, module := range m.modules {
+		list = append(list, m.migrations[module]...)
+	}
+
+	return list
+}
+
+func (m *Migrator) table() string {
+	return fmt.Sprintf(`"%s_migrations"`, m.Prefix)
+}
+
+func (m *Migrator) statement(sql string) string {
+	return strings.Replace(sql, "{prefix}", m.Prefix, -1)
+}
+
+func (m *Migrator) init() error {
+	// SQLite only generates the id of an INTEGER PRIMARY KEY column, and only reads the dates
+	// of the TIMESTAMP columns
+	id := `"id" SERIAL NOT NULL`
+	timestamp := `TIMESTAMP WITHOUT TIME ZONE`
+	if m.Driver == config.DATABASE_SQLITE {
+		id = `"id" INTEGER NOT NULL`
+		timestamp = `TIMESTAMP`
+	}
+
+	_, err := m.Db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		%s,
+		"module" CHARACTER VARYING( 64 ) NOT NULL,
+		"version" INTEGER NOT NULL,
+		"name" CHARACTER VARYING( 256 ) DEFAULT '' NOT NULL,
+		"applied_at" %s NOT NULL,
+		PRIMARY KEY ( "id" ),
+		CONSTRAINT "%s_migrations_version" UNIQUE( "module", "version" )
+	)`, m.table(), id, timestamp, m.Prefix))
+
+	return err
+}
+
+func (m *Migrator) applied() (map[string]time.Time, error) {
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.Db.Query(fmt.Sprintf(`SELECT module, version, applied_at FROM %s`, m.table()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := make(map[string]time.Time)
+
+	for rows.Next() {
+		migration := &Migration{}
+		var appliedAt time.Time
+
+		if err := rows.Scan(&migration.Module, &migration.Version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[migration.Key()] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// Status returns the state of every registered migration
+func (m *Migrator) Status() ([]*MigrationStatus, error) {
+	applied, err := m.applied()
+
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*MigrationStatus, 0)
+
+	for _, migration := range m.Migrations() {
+		status := &MigrationStatus{
+			Module:  migration.Module,
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+
+		if at, ok := applied[migration.Key()]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+
+		list = append(list, status)
+	}
+
+	return list, nil
+}
+
+// Up applies all the pending migrations, each migration runs in its own transaction
+func (m *Migrator) Up() ([]*Migration, error) {
+	applied, err := m.applied()
+
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]*Migration, 0)
+
+	for _, migration := range m.Migrations() {
+		if _, ok := applied[migration.Key()]; ok {
+			continue
+		}
+
+		err := m.run(migration, migration.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(fmt.Sprintf(`INSERT INTO %s (module, version, name, applied_at) VALUES ($1, $2, $3, $4)`, m.table()),
+				migration.Module, migration.Version, migration.Name, time.Now())
+
+			return err
+		})
+
+		if err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the last applied migrations, steps is the number of migrations to revert
+func (m *Migrator) Down(steps int) ([]*Migration, error) {
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.Db.Query(fmt.Sprintf(`SELECT module, version FROM %s ORDER BY id DESC LIMIT $1`, m.table()), steps)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0)
+
+	for rows.Next() {
+		migration := &Migration{}
+
+		if err := rows.Scan(&migration.Module, &migration.Version); err != nil {
+			rows.Close()
+
+			return nil, err
+		}
+
+		keys = append(keys, migration.Key())
+	}
+
+	rows.Close()
+
+	registered := make(map[string]*Migration)
+	for _, migration := range m.Migrations() {
+		registered[migration.Key()] = migration
+	}
+
+	done := make([]*Migration, 0)
+
+	for _, key := range keys {
+		migration, ok := registered[key]
+
+		if !ok {
+			return done, fmt.Errorf("the applied migration %s is not registered", key)
+		}
+
+		err := m.run(migration, migration.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE module = $1 AND version = $2`, m.table()), migration.Module, migration.Version)
+
+			return err
+		})
+
+		if err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Reset runs every down migration in the reverse order, whatever the bookkeeping state is, and
+// drops the bookkeeping table. This is used to reset a test database.
+func (m *Migrator) Reset() error {
+	migrations := m.Migrations()
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if migrations[i].Down == "" {
+			continue
+		}
+
+		if _, err := m.Db.Exec(m.statement(migrations[i].Down)); err != nil {
+			return err
+		}
+	}
+
+	_, err := m.Db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s`, m.table()))
+
+	return err
+}
+
+func (m *Migrator) run(migration *Migration, statement string, bookkeeping func(tx *sql.Tx) error) error {
+	if m.Logger != nil {
+		m.Logger.WithFields(log.Fields{
+			"module":            "core.migration",
+			"migration_module":  migration.Module,
+			"migration_version": migration.Version,
+			"migration_name":    migration.Name,
+		}).Info("running migration")
+	}
+
+	tx, err := m.Db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if statement != "" {
+		if _, err = tx.Exec(m.statement(statement)); err != nil {
+			tx.Rollback()
+
+			return fmt.Errorf("migration %s (%s): %w", migration.Key(), migration.Name, err)
+		}
+	}
+
+	if err = bookkeeping(tx); err != nil {
+		tx.Rollback()
+
+		return err
+	}
+
+	return tx.Commit()
+}
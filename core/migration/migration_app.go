@@ -0,0 +1,32 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/core/config"
+	log "github.com/sirupsen/logrus"
+)
+
+func Configure(l *goapp.Lifecycle, conf *config.Config) {
+	l.Register(func(app *goapp.App) error {
+		app.Set("gonode.migrator", func(app *goapp.App) interface{} {
+			migrator := NewMigrator(
+				app.Get("gonode.postgres.connection").(*sql.DB),
+				conf.Databases["master"].Prefix,
+				app.Get("logger").(*log.Logger),
+			)
+
+			migrator.Driver = conf.Databases["master"].Driver()
+
+			return migrator
+		})
+
+		return nil
+	})
+}
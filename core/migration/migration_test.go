@@ -0,0 +1,51 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Migrator_Order(t *testing.T) {
+	m := NewMigrator(nil, "test", nil)
+
+	assert.NoError(t, m.Add(&Migration{Module: "base", Version: 2, Name: "base 2"}))
+	assert.NoError(t, m.Add(&Migration{Module: "search", Version: 1, Name: "search 1"}))
+	assert.NoError(t, m.Add(&Migration{Module: "base", Version: 1, Name: "base 1"}))
+
+	assert.Equal(t, ErrMigrationExists, m.Add(&Migration{Module: "base", Version: 1}))
+
+	names := []string{}
+	for _, migration := range m.Migrations() {
+		names = append(names, migration.Name)
+	}
+
+	assert.Equal(t, []string{"base 1", "base 2", "search 1"}, names)
+}
+
+func Test_Migrator_Statement(t *testing.T) {
+	m := NewMigrator(nil, "prod", nil)
+
+	assert.Equal(t, `CREATE TABLE "prod_nodes" (id INTEGER); DROP INDEX "prod_idx"`, m.statement(`CREATE TABLE "{prefix}_nodes" (id INTEGER); DROP INDEX "{prefix}_idx"`))
+}
+
+func Test_Migrator_Driver(t *testing.T) {
+	m := NewMigrator(nil, "test", nil)
+	m.Driver = "sqlite"
+
+	assert.NoError(t, m.Add(&Migration{Module: "base", Version: 1, Name: "postgres 1", Driver: "postgres"}))
+	assert.NoError(t, m.Add(&Migration{Module: "base", Version: 1, Name: "sqlite 1", Driver: "sqlite"}))
+	assert.NoError(t, m.Add(&Migration{Module: "base", Version: 2, Name: "any 2"}))
+
+	names := []string{}
+	for _, migration := range m.Migrations() {
+		names = append(names, migration.Name)
+	}
+
+	assert.Equal(t, []string{"sqlite 1", "any 2"}, names)
+}
@@ -10,6 +10,7 @@ package squirrel
 import (
 	"database/sql/driver"
 	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"regexp"
 	"strings"
@@ -20,16 +21,28 @@ var quoteEscapeRegex = regexp.MustCompile(`([^\\]([\\]{2})*)\\"`)
 type StringSlice []string
 
 func (s *StringSlice) Scan(src interface{}) error {
-	asBytes, ok := src.([]byte)
-	if !ok {
+	var str string
+
+	switch v := src.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	case nil:
+		return nil
+	default:
 		return error(errors.New("Scan source was not []bytes"))
 	}
-	str := string(asBytes)
 
-	if str == "{}" {
+	if str == "{}" || str == "[]" {
 		return nil
 	}
 
+	// the databases without array type store a JSON array
+	if str[0] == '[' {
+		return json.Unmarshal([]byte(str), (*[]string)(s))
+	}
+
 	// change quote escapes for csv parser
 	str = quoteEscapeRegex.ReplaceAllString(str, `$1""`)
 	str = strings.Replace(str, `\\`, `\`, -1)
@@ -40,3 +40,18 @@ func TestStringSliceDbValue(t *testing.T) {
 		t.Errorf("Could not convert %v to string for comparison", val)
 	}
 }
+
+func TestStringSliceScanJson(t *testing.T) {
+	var slice StringSlice
+
+	err := slice.Scan(`["12","abc,\"d"]`)
+
+	if err != nil {
+		t.Errorf("Could not scan array, %v", err)
+		return
+	}
+
+	if len(slice) != 2 || slice[0] != "12" || slice[1] != `abc,"d` {
+		t.Errorf("Did not get expected slice contents")
+	}
+}
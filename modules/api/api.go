@@ -19,6 +19,9 @@ import (
 const (
 	OPERATION_OK = "OK"
 	OPERATION_KO = "KO"
+
+	RELATION_REFERENCES    = "references"    // the nodes referenced by the node
+	RELATION_REFERENCED_BY = "referenced-by" // the nodes referencing the node
 )
 
 type ApiPager struct {
@@ -32,9 +35,19 @@ type ApiPager struct {
 type Api struct {
 	Version    string
 	Manager    base.NodeManager
+	Handlers   base.Handlers
 	BaseUrl    string
 	Logger     *log.Logger
 	Authorizer security.AuthorizationChecker
+	Serializer *base.Serializer
+	Trash      *base.Trash
+	Copier     *base.Copier
+	Audit      *base.Audit
+	Locker     *base.Locker
+	Jobs       *base.JobQueue
+	Relations  base.RelationManager
+	Workflows  *base.Workflows
+	Dialect    base.Dialect
 }
 
 type ApiOperation struct {
@@ -46,13 +59,20 @@ func (a *Api) SelectBuilder(options *base.SelectOptions) sq.SelectBuilder {
 	return a.Manager.SelectBuilder(options)
 }
 
-func (a *Api) Find(query sq.SelectBuilder, page uint64, perPage uint64, options *base.AccessOptions) (*ApiPager, error) {
+// accessQuery restricts the query to the nodes granted to one of the options' roles
+func (a *Api) accessQuery(query sq.SelectBuilder, options *base.AccessOptions) sq.SelectBuilder {
 	if options != nil && len(options.Roles) > 0 {
 		value, _ := options.Roles.ToStringSlice()
 
-		query = query.Where(squirrel.NewExprSlice(fmt.Sprintf("\"%s\" && ARRAY["+sq.Placeholders(len(options.Roles))+"]", "access"), value))
+		query = query.Where(squirrel.NewExprSlice(a.Dialect.ArrayOverlap("\"access\"", len(value)), value))
 	}
 
+	return query
+}
+
+func (a *Api) Find(query sq.SelectBuilder, page uint64, perPage uint64, options *base.AccessOptions) (*ApiPager, error) {
+	query = a.accessQuery(query, options)
+
 	list := a.Manager.FindBy(query, (page-1)*perPage, perPage+1)
 
 	pager := &ApiPager{
@@ -82,6 +102,13 @@ func (a *Api) Find(query sq.SelectBuilder, page uint64, perPage uint64, options
 }
 
 func (a *Api) Save(node *base.Node, options *base.AccessOptions) (*base.Node, base.Errors, error) {
+	return a.save(node, options, nil)
+}
+
+// save runs the access, revision, lock and validation checks before saving the node. The
+// transition's status is applied once the fields the user cannot write are restored, as the
+// transition is granted by its own roles.
+func (a *Api) save(node *base.Node, options *base.AccessOptions, transition *base.Transition) (*base.Node, base.Errors, error) {
 	if a.Logger != nil {
 		a.Logger.Printf("trying to save node.uuid=%s, node.type=%s", node.Uuid, node.Type)
 	}
@@ -109,6 +136,10 @@ func (a *Api) Save(node *base.Node, options *base.AccessOptions) (*base.Node, ba
 			return nil, nil, base.ErrRevision
 		}
 
+		if err := a.checkLock(node.Uuid, options); err != nil {
+			return nil, nil, err
+		}
+
 		node.Id = saved.Id
 
 		// we cannot overwrite the Parents, Or the ParentUuid, need to use the http API
@@ -119,6 +150,20 @@ func (a *Api) Save(node *base.Node, options *base.AccessOptions) (*base.Node, ba
 		a.Logger.Printf("cannot find uuid: %s, create a new one", node.Uuid)
 	}
 
+	if a.Handlers != nil && options != nil {
+		// the fields the user cannot write keep their saved, or default, values
+		source := saved
+		if source == nil {
+			source = a.Handlers.NewNode(node.Type)
+		}
+
+		base.CopyFields(node, source, base.UnwritableFields(a.Handlers.Get(node), options.Token))
+	}
+
+	if transition != nil {
+		node.Status = transition.To
+	}
+
 	if a.Logger != nil {
 		a.Logger.Printf("saving node.id=%d, node.uuid=%s", node.Id, node.Uuid)
 	}
@@ -127,11 +172,105 @@ func (a *Api) Save(node *base.Node, options *base.AccessOptions) (*base.Node, ba
 		return nil, errors, base.ErrValidation
 	}
 
+	// the status change is validated by the manager, only the transition's roles are checked
+	if a.Workflows != nil && options != nil && saved != nil && saved.Status != node.Status {
+		if workflow := a.Workflows.Get(node); workflow != nil {
+			if t := workflow.Find(saved.Status, node.Status); t != nil && !a.isTransitionGranted(t, options) {
+				return nil, nil, base.ErrAccessForbidden
+			}
+		}
+	}
+
 	node, err := a.Manager.Save(node, true)
 
+	if errors, ok := err.(base.Errors); ok {
+		return nil, errors, base.ErrValidation
+	}
+
+	// the editor's lock is released once the node is saved
+	if err == nil && a.Locker != nil && options != nil && options.Token != nil {
+		_, err = a.Locker.Release(node.Uuid, options.Token.GetUsername(), false)
+
+		if err == base.ErrLocked {
+			err = nil
+		}
+	}
+
 	return node, nil, err
 }
 
+// checkLock returns ErrLocked if the node is locked by another user, and if the user cannot
+// override the lock
+func (a *Api) checkLock(uuid base.Reference, options *base.AccessOptions) error {
+	if a.Locker == nil || options == nil || options.Token == nil {
+		return nil
+	}
+
+	lock, err := a.Locker.Find(uuid)
+
+	if err != nil || lock == nil {
+		return err
+	}
+
+	if lock.Owner != options.Token.GetUsername() && !base.CanOverrideLock(options.Token.GetRoles()) {
+		return base.ErrLocked
+	}
+
+	return nil
+}
+
+func (a *Api) isTransitionGranted(transition *base.Transition, options *base.AccessOptions) bool {
+	if len(transition.Roles) == 0 {
+		return true
+	}
+
+	result, _ := a.Authorizer.IsGranted(options.Token, security.AttributesFromString(transition.Roles), nil)
+
+	return result
+}
+
+// Transition applies the workflow's transition to the node, the node is saved with the same
+// checks as Save and a new revision is created
+func (a *Api) Transition(uuid, name string, options *base.AccessOptions) (*base.Node, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if node.Deleted {
+		return nil, base.ErrAlreadyDeleted
+	}
+
+	workflow := a.Workflows.Get(node)
+
+	if workflow == nil {
+		return nil, base.ErrInvalidTransition
+	}
+
+	transition := workflow.Get(name)
+
+	if transition == nil {
+		return nil, base.ErrNotFound
+	}
+
+	if !transition.Support(node.Status) {
+		return nil, base.ErrInvalidTransition
+	}
+
+	if !a.isTransitionGranted(transition, options) {
+		return nil, base.ErrAccessForbidden
+	}
+
+	node, errors, err := a.save(node, options, transition)
+
+	if errors != nil && errors.HasErrors() {
+		return nil, errors
+	}
+
+	return node, err
+}
+
 func (a *Api) Move(nodeUuid, parentUuid string, options *base.AccessOptions) (*ApiOperation, error) {
 	// handle node
 	nodeReference, err := base.GetReferenceFromString(nodeUuid)
@@ -146,6 +285,10 @@ func (a *Api) Move(nodeUuid, parentUuid string, options *base.AccessOptions) (*A
 		return nil, base.ErrAccessForbidden
 	}
 
+	if err := a.checkLock(nodeReference, options); err != nil {
+		return nil, err
+	}
+
 	// parent node
 	parentReference, err := base.GetReferenceFromString(parentUuid)
 
@@ -170,6 +313,72 @@ func (a *Api) Move(nodeUuid, parentUuid string, options *base.AccessOptions) (*A
 	}
 }
 
+// Reorder sets the weight of the node's children from their position in uuids, all the nodes
+// must be granted. The children are saved with a new revision if revision is true, so they
+// must not be locked by another user.
+func (a *Api) Reorder(uuid string, uuids []string, revision bool, options *base.AccessOptions) (*ApiOperation, error) {
+	parent, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	references := make([]base.Reference, 0)
+
+	for _, u := range uuids {
+		node, err := a.FindOne(u, options)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if revision {
+			if err := a.checkLock(node.Uuid, options); err != nil {
+				return nil, err
+			}
+		}
+
+		references = append(references, node.Uuid)
+	}
+
+	affectedNodes, err := a.Manager.Reorder(parent.Uuid, references, revision)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ApiOperation{
+		Status:  OPERATION_OK,
+		Message: fmt.Sprintf("Node altered: %d", affectedNodes),
+	}, nil
+}
+
+// Copy clones the node under the parent, the node is copied next to the original node if
+// parentUuid is empty. If recursive is true, the node's subtree is copied too.
+func (a *Api) Copy(uuid, parentUuid string, recursive bool, options *base.AccessOptions) (*base.Node, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if parentUuid == "" && node.ParentUuid != base.GetEmptyReference() {
+		parentUuid = node.ParentUuid.CleanString()
+	}
+
+	var parent *base.Node
+
+	if parentUuid != "" {
+		if parent, err = a.FindOne(parentUuid, options); err != nil {
+			return nil, err
+		}
+	}
+
+	clone, _, err := a.Copier.Copy(node, parent, recursive)
+
+	return clone, err
+}
+
 func (a *Api) FindOne(uuid string, options *base.AccessOptions) (*base.Node, error) {
 	reference, err := base.GetReferenceFromString(uuid)
 
@@ -200,6 +409,86 @@ func (a *Api) FindOneBy(query sq.SelectBuilder, options *base.AccessOptions) (*b
 	return node, nil
 }
 
+func (a *Api) FindRevision(uuid string, revision int, options *base.AccessOptions) (*base.Node, error) {
+	reference, err := base.GetReferenceFromString(uuid)
+
+	if err != nil {
+		return nil, base.ErrNotFound
+	}
+
+	selectOptions := base.NewSelectOptions()
+	selectOptions.TableSuffix = "nodes_audit"
+
+	query := a.Manager.SelectBuilder(selectOptions).
+		Where(sq.Eq{"uuid": reference.String()}).
+		Where(sq.Eq{"revision": revision})
+
+	return a.FindOneBy(query, options)
+}
+
+func (a *Api) Diff(uuid string, from, to int, options *base.AccessOptions) (*NodeDiff, error) {
+	fromNode, err := a.FindRevision(uuid, from, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	toNode, err := a.FindRevision(uuid, to, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffNodes(fromNode, toNode, a.serializer(options))
+}
+
+// serializer returns the serializer filtering the nodes' fields with the user's roles
+func (a *Api) serializer(options *base.AccessOptions) *base.Serializer {
+	if a.Serializer == nil || options == nil {
+		return a.Serializer
+	}
+
+	return a.Serializer.WithToken(options.Token)
+}
+
+// Restore creates a new revision of the node with the content of a previous revision, the
+// restored revision number is stored in the node's modules under the "restored_from" key.
+func (a *Api) Restore(uuid string, revision int, options *base.AccessOptions) (*base.Node, base.Errors, error) {
+	current, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	old, err := a.FindRevision(uuid, revision, options)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	node := old
+	node.Id = current.Id
+	node.Revision = current.Revision
+	node.Deleted = current.Deleted
+
+	// the content is restored, the node keeps its position in the tree so the slug matches
+	// the path and the children's paths
+	node.Slug = current.Slug
+	node.Path = current.Path
+	node.ParentUuid = current.ParentUuid
+	node.Parents = current.Parents
+	node.CreatedAt = current.CreatedAt
+	node.UpdatedAt = current.UpdatedAt
+
+	if node.Modules == nil {
+		node.Modules = base.Modules{}
+	}
+
+	node.Modules.Set("restored_from", revision)
+
+	return a.Save(node, options)
+}
+
 func (a *Api) RemoveOne(uuid string, options *base.AccessOptions) (*base.Node, error) {
 	reference, err := base.GetReferenceFromString(uuid)
 
@@ -225,17 +514,65 @@ func (a *Api) RemoveOne(uuid string, options *base.AccessOptions) (*base.Node, e
 		return nil, base.ErrAlreadyDeleted
 	}
 
+	if err := a.checkLock(node.Uuid, options); err != nil {
+		return nil, err
+	}
+
 	return a.Manager.RemoveOne(node)
 }
 
-func (a *Api) Remove(query sq.SelectBuilder, options *base.AccessOptions) (*ApiPager, error) {
-	if options != nil && len(options.Roles) > 0 {
-		value, _ := options.Roles.ToStringSlice()
+func (a *Api) FindDeleted(query sq.SelectBuilder, page uint64, perPage uint64, options *base.AccessOptions) (*ApiPager, error) {
+	return a.Find(query.Where("deleted = ?", true), page, perPage, options)
+}
+
+// Undelete restores a soft deleted node, and its deleted children if recursive is true
+func (a *Api) Undelete(uuid string, recursive bool, options *base.AccessOptions) (*ApiOperation, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !node.Deleted {
+		return nil, base.ErrNotDeleted
+	}
+
+	count, err := a.Trash.Undelete(node, recursive)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ApiOperation{
+		Status:  OPERATION_OK,
+		Message: fmt.Sprintf("Node restored: %d", count),
+	}, nil
+}
+
+// FindRelations returns the nodes linked to the node, the direction is RELATION_REFERENCES or
+// RELATION_REFERENCED_BY and field is optional
+func (a *Api) FindRelations(direction, uuid, field string, query sq.SelectBuilder, page uint64, perPage uint64, options *base.AccessOptions) (*ApiPager, error) {
+	node, err := a.FindOne(uuid, options)
 
-		query = query.Where(squirrel.NewExprSlice(fmt.Sprintf("\"%s\" && ARRAY["+sq.Placeholders(len(options.Roles))+"]", "access"), value))
+	if err != nil {
+		return nil, err
+	}
+
+	if direction == RELATION_REFERENCED_BY {
+		query = a.Relations.ReferencedBy(query, node.Uuid, field)
+	} else {
+		query = a.Relations.References(query, node.Uuid, field)
 	}
 
-	a.Manager.Remove(query)
+	return a.Find(query, page, perPage, options)
+}
+
+func (a *Api) Remove(query sq.SelectBuilder, options *base.AccessOptions) (*ApiPager, error) {
+	query = a.accessQuery(query, options)
+
+	if err := a.Manager.Remove(query); err != nil {
+		return nil, err
+	}
 
 	return a.Find(query, 0, 0, options)
 }
@@ -24,10 +24,20 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 	l.Prepare(func(app *goapp.App) error {
 		app.Set("gonode.api", func(app *goapp.App) interface{} {
 			return &Api{
-				Manager:    app.Get("gonode.manager").(*base.PgNodeManager),
+				Manager:    app.Get("gonode.manager").(*base.SqlNodeManager),
+				Handlers:   app.Get("gonode.handler_collection").(base.Handlers),
 				Version:    "1.0.0",
 				Logger:     app.Get("logger").(*log.Logger),
 				Authorizer: app.Get("security.authorizer").(security.AuthorizationChecker),
+				Serializer: app.Get("gonode.node.serializer").(*base.Serializer),
+				Trash:      app.Get("gonode.trash").(*base.Trash),
+				Copier:     app.Get("gonode.copier").(*base.Copier),
+				Audit:      app.Get("gonode.audit").(*base.Audit),
+				Locker:     app.Get("gonode.locker").(*base.Locker),
+				Jobs:       app.Get("gonode.jobs").(*base.JobQueue),
+				Relations:  app.Get("gonode.manager").(*base.SqlNodeManager),
+				Workflows:  app.Get("gonode.workflows").(*base.Workflows),
+				Dialect:    app.Get("gonode.dialect").(base.Dialect),
 			}
 		})
 
@@ -77,14 +87,36 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid", Api_GET_Node(app))
 		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/revisions", Api_GET_Node_Revisions(app))
 		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/revisions/:rev", Api_GET_Node_Revision(app))
+		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/revisions/:from/diff/:to", Api_GET_Node_Revisions_Diff(app))
+		mux.Put(conf.Api.Prefix+"/:version/nodes/:uuid/revisions/:rev/restore", Api_PUT_Node_Revision_Restore(app))
+		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/references", Api_GET_Node_Relations(app, RELATION_REFERENCES))
+		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/referenced-by", Api_GET_Node_Relations(app, RELATION_REFERENCED_BY))
+		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/children", Api_GET_Node_Children(app))
+		mux.Put(conf.Api.Prefix+"/:version/nodes/:uuid/children/order", Api_PUT_Node_Children_Order(app))
+		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/ancestors", Api_GET_Node_Ancestors(app))
+		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/tree", Api_GET_Node_Tree(app))
+		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/breadcrumbs", Api_GET_Node_Breadcrumbs(app))
+		mux.Post(conf.Api.Prefix+"/:version/nodes/:uuid/transitions/:name", Api_POST_Node_Transition(app))
 		mux.Post(conf.Api.Prefix+"/:version/nodes", Api_POST_Nodes(app))
 		mux.Put(conf.Api.Prefix+"/:version/nodes/:uuid", Api_PUT_Nodes(app))
 		mux.Put(conf.Api.Prefix+"/:version/nodes/move/:uuid/:parentUuid", Api_PUT_Nodes_Move(app))
+		mux.Post(conf.Api.Prefix+"/:version/nodes/:uuid/copy", Api_POST_Node_Copy(app))
+		mux.Get(conf.Api.Prefix+"/:version/nodes/:uuid/lock", Api_GET_Node_Lock(app))
+		mux.Put(conf.Api.Prefix+"/:version/nodes/:uuid/lock", Api_PUT_Node_Lock(app))
+		mux.Delete(conf.Api.Prefix+"/:version/nodes/:uuid/lock", Api_DELETE_Node_Lock(app))
 		mux.Delete(conf.Api.Prefix+"/:version/nodes/:uuid", Api_DELETE_Nodes(app))
 		mux.Get(conf.Api.Prefix+"/:version/nodes", Api_GET_Nodes(app))
+		mux.Get(conf.Api.Prefix+"/:version/trash", Api_GET_Trash(app))
+		mux.Get(conf.Api.Prefix+"/:version/audit", Api_GET_Audit(app))
+		mux.Put(conf.Api.Prefix+"/:version/trash/:uuid/undelete", Api_PUT_Trash_Undelete(app))
+		mux.Get(conf.Api.Prefix+"/:version/jobs", Api_GET_Jobs(app))
+		mux.Get(conf.Api.Prefix+"/:version/jobs/stats", Api_GET_Jobs_Stats(app))
+		mux.Get(conf.Api.Prefix+"/:version/jobs/:id", Api_GET_Job(app))
+		mux.Put(conf.Api.Prefix+"/:version/jobs/:id/retry", Api_PUT_Job_Retry(app))
 		mux.Get(conf.Api.Prefix+"/:version/hello", Api_GET_Hello(app))
 		mux.Put(conf.Api.Prefix+"/:version/notify/:name", Api_PUT_Notify(app))
 		mux.Get(conf.Api.Prefix+"/:version/handlers/node", Api_GET_Handlers_Node(app))
+		mux.Get(conf.Api.Prefix+"/:version/handlers/node/:type/schema", Api_GET_Handlers_Node_Schema(app))
 		mux.Get(conf.Api.Prefix+"/:version/handlers/view", Api_GET_Handlers_View(app))
 		mux.Get(conf.Api.Prefix+"/:version/services", Api_GET_Services(app))
 
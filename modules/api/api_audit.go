@@ -0,0 +1,133 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/rande/gonode/modules/base"
+)
+
+var ErrInvalidAuditQuery = errors.New("invalid audit query")
+
+// the fields describing the revision, they are not reported as changed fields
+var auditIgnoredFields = map[string]bool{
+	"revision":   true,
+	"updated_at": true,
+	"updated_by": true,
+}
+
+// AuditEntry is one revision of a node, Fields lists the fields changed since the previous
+// revision.
+type AuditEntry struct {
+	Uuid      base.Reference `json:"uuid"`
+	Type      string         `json:"type"`
+	Name      string         `json:"name"`
+	Revision  int            `json:"revision"`
+	Action    string         `json:"action"`
+	UpdatedBy base.Reference `json:"updated_by"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Fields    []string       `json:"fields"`
+}
+
+// NewAuditQuery creates the audit query from the request's parameters: updated_by, type and
+// action can be repeated, from and to are RFC 3339 dates.
+func NewAuditQuery(values url.Values) (*base.AuditQuery, error) {
+	query := &base.AuditQuery{
+		UpdatedBy: make([]base.Reference, 0),
+		Types:     values["type"],
+		Actions:   values["action"],
+	}
+
+	for _, v := range values["updated_by"] {
+		reference, err := base.GetReferenceFromString(v)
+
+		if err != nil {
+			return nil, ErrInvalidAuditQuery
+		}
+
+		query.UpdatedBy = append(query.UpdatedBy, reference)
+	}
+
+	for _, action := range query.Actions {
+		valid := false
+		for _, a := range base.AuditActions {
+			valid = valid || a == action
+		}
+
+		if !valid {
+			return nil, ErrInvalidAuditQuery
+		}
+	}
+
+	for name, date := range map[string]**time.Time{"from": &query.From, "to": &query.To} {
+		if values.Get(name) == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, values.Get(name))
+
+		if err != nil {
+			return nil, ErrInvalidAuditQuery
+		}
+
+		*date = &t
+	}
+
+	return query, nil
+}
+
+// FindAudit returns the revisions matching the query, the most recent first. The elements
+// are AuditEntry values, only the revisions granted by the access options are returned.
+func (a *Api) FindAudit(query *base.AuditQuery, page uint64, perPage uint64, options *base.AccessOptions) (*ApiPager, error) {
+	pager, err := a.Find(a.Audit.SelectBuilder(query), page, perPage, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*base.Node, 0)
+	for _, e := range pager.Elements {
+		nodes = append(nodes, e.(*base.Node))
+	}
+
+	previous := a.Audit.Previous(nodes)
+
+	for k, node := range nodes {
+		prev := previous[base.AuditKey(node.Uuid, node.Revision)]
+
+		entry := &AuditEntry{
+			Uuid:      node.Uuid,
+			Type:      node.Type,
+			Name:      node.Name,
+			Revision:  node.Revision,
+			Action:    base.AuditAction(node, prev),
+			UpdatedBy: node.UpdatedBy,
+			UpdatedAt: node.UpdatedAt,
+			Fields:    make([]string, 0),
+		}
+
+		if prev != nil {
+			diff, err := DiffNodes(prev, node, a.serializer(options))
+
+			if err != nil {
+				return nil, err
+			}
+
+			for _, field := range diff.Fields() {
+				if !auditIgnoredFields[field] {
+					entry.Fields = append(entry.Fields, field)
+				}
+			}
+		}
+
+		pager.Elements[k] = entry
+	}
+
+	return pager, nil
+}
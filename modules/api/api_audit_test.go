@@ -0,0 +1,42 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/rande/gonode/modules/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewAuditQuery(t *testing.T) {
+	values := url.Values{
+		"updated_by": []string{"11111111-1111-1111-1111-111111111111"},
+		"type":       []string{"core.user", "blog.post"},
+		"action":     []string{base.AUDIT_UPDATE},
+		"from":       []string{"2023-01-01T00:00:00Z"},
+	}
+
+	query, err := NewAuditQuery(values)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []base.Reference{base.GetEmptyReference()}, query.UpdatedBy)
+	assert.Equal(t, []string{"core.user", "blog.post"}, query.Types)
+	assert.Equal(t, []string{base.AUDIT_UPDATE}, query.Actions)
+	assert.Equal(t, 2023, query.From.Year())
+	assert.Nil(t, query.To)
+
+	for _, values := range []url.Values{
+		{"updated_by": []string{"thomas"}},
+		{"action": []string{"publish"}},
+		{"to": []string{"yesterday"}},
+	} {
+		_, err := NewAuditQuery(values)
+
+		assert.Equal(t, ErrInvalidAuditQuery, err)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rande/gonode/modules/base"
+)
+
+const (
+	PATCH_ADD     = "add"
+	PATCH_REMOVE  = "remove"
+	PATCH_REPLACE = "replace"
+)
+
+// PatchOperation is a RFC 6902 JSON Patch operation
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+func (o *PatchOperation) MarshalJSON() ([]byte, error) {
+	if o.Op == PATCH_REMOVE {
+		return json.Marshal(map[string]interface{}{
+			"op":   o.Op,
+			"path": o.Path,
+		})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"op":    o.Op,
+		"path":  o.Path,
+		"value": o.Value,
+	})
+}
+
+type NodeDiff struct {
+	Uuid    string            `json:"uuid"`
+	From    int               `json:"from"`
+	To      int               `json:"to"`
+	Patch   []*PatchOperation `json:"patch"`
+	Summary []string          `json:"summary"`
+}
+
+// DiffNodes computes the changes required to go from one node to the other one. The nodes
+// are compared using their serialized representation, so private fields hidden by a custom
+// serializer are not exposed in the diff.
+func DiffNodes(from, to *base.Node, serializer *base.Serializer) (*NodeDiff, error) {
+	fromDoc, err := toDocument(from, serializer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	toDoc, err := toDocument(to, serializer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &NodeDiff{
+		Uuid:    to.Uuid.CleanString(),
+		From:    from.Revision,
+		To:      to.Revision,
+		Patch:   make([]*PatchOperation, 0),
+		Summary: make([]string, 0),
+	}
+
+	diffDocument(diff, []string{}, fromDoc, toDoc)
+
+	return diff, nil
+}
+
+// Fields returns the dotted names of the changed fields, ie: data.title
+func (d *NodeDiff) Fields() []string {
+	r := strings.NewReplacer("~1", "/", "~0", "~")
+
+	fields := make([]string, 0)
+	for _, op := range d.Patch {
+		path := strings.Split(strings.TrimPrefix(op.Path, "/"), "/")
+
+		for i, s := range path {
+			path[i] = r.Replace(s)
+		}
+
+		fields = append(fields, fieldName(path))
+	}
+
+	return fields
+}
+
+// DiffDocuments returns the JSON Patch operations between two decoded JSON documents
+func DiffDocuments(from, to interface{}) []*PatchOperation {
+	diff := &NodeDiff{
+		Patch:   make([]*PatchOperation, 0),
+		Summary: make([]string, 0),
+	}
+
+	diffDocument(diff, []string{}, from, to)
+
+	return diff.Patch
+}
+
+func toDocument(node *base.Node, serializer *base.Serializer) (interface{}, error) {
+	b := bytes.NewBuffer([]byte{})
+
+	var err error
+	if serializer != nil {
+		err = serializer.Serialize(b, node)
+	} else {
+		err = base.Serialize(b, node)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+
+	err = json.Unmarshal(b.Bytes(), &doc)
+
+	return doc, err
+}
+
+func diffDocument(diff *NodeDiff, path []string, from, to interface{}) {
+	fromMap, fromIsMap := from.(map[string]interface{})
+	toMap, toIsMap := to.(map[string]interface{})
+
+	if !fromIsMap || !toIsMap {
+		// scalar values or arrays are replaced as a whole
+		if !reflect.DeepEqual(from, to) {
+			diff.Patch = append(diff.Patch, &PatchOperation{Op: PATCH_REPLACE, Path: jsonPointer(path), Value: to})
+			diff.Summary = append(diff.Summary, fmt.Sprintf("%s changed from %s to %s", fieldName(path), summaryValue(from), summaryValue(to)))
+		}
+
+		return
+	}
+
+	for _, key := range sortedKeys(fromMap) {
+		if _, ok := toMap[key]; !ok {
+			p := append(append([]string{}, path...), key)
+
+			diff.Patch = append(diff.Patch, &PatchOperation{Op: PATCH_REMOVE, Path: jsonPointer(p)})
+			diff.Summary = append(diff.Summary, fmt.Sprintf("%s removed", fieldName(p)))
+		}
+	}
+
+	for _, key := range sortedKeys(toMap) {
+		p := append(append([]string{}, path...), key)
+
+		if _, ok := fromMap[key]; !ok {
+			diff.Patch = append(diff.Patch, &PatchOperation{Op: PATCH_ADD, Path: jsonPointer(p), Value: toMap[key]})
+			diff.Summary = append(diff.Summary, fmt.Sprintf("%s added with %s", fieldName(p), summaryValue(toMap[key])))
+
+			continue
+		}
+
+		diffDocument(diff, p, fromMap[key], toMap[key])
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func jsonPointer(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	r := strings.NewReplacer("~", "~0", "/", "~1")
+
+	p := ""
+	for _, s := range path {
+		p += "/" + r.Replace(s)
+	}
+
+	return p
+}
+
+func fieldName(path []string) string {
+	if len(path) == 0 {
+		return "node"
+	}
+
+	return strings.Join(path, ".")
+}
+
+func summaryValue(v interface{}) string {
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,74 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rande/gonode/modules/base"
+	"github.com/stretchr/testify/assert"
+)
+
+type diffData struct {
+	Title string   `json:"title"`
+	Tags  []string `json:"tags"`
+}
+
+func Test_DiffNodes(t *testing.T) {
+	from := base.NewNode()
+	from.Type = "blog.post"
+	from.Name = "Post"
+	from.Revision = 1
+	from.Data = &diffData{Title: "Hello", Tags: []string{"go"}}
+	from.Meta = map[string]interface{}{"format": "markdown"}
+
+	to := base.NewNode()
+	to.Uuid = from.Uuid
+	to.Type = "blog.post"
+	to.Name = "Post"
+	to.Revision = 2
+	to.CreatedAt = from.CreatedAt
+	to.UpdatedAt = from.UpdatedAt
+	to.Data = &diffData{Title: "Hello World", Tags: []string{"go"}}
+	to.Meta = map[string]interface{}{"source": "import"}
+
+	diff, err := DiffNodes(from, to, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, diff.From)
+	assert.Equal(t, 2, diff.To)
+
+	assert.Equal(t, []*PatchOperation{
+		{Op: PATCH_REPLACE, Path: "/data/title", Value: "Hello World"},
+		{Op: PATCH_REMOVE, Path: "/meta/format"},
+		{Op: PATCH_ADD, Path: "/meta/source", Value: "import"},
+		{Op: PATCH_REPLACE, Path: "/revision", Value: float64(2)},
+	}, diff.Patch)
+
+	assert.Equal(t, []string{
+		`data.title changed from "Hello" to "Hello World"`,
+		`meta.format removed`,
+		`meta.source added with "import"`,
+		`revision changed from 1 to 2`,
+	}, diff.Summary)
+
+	assert.Equal(t, []string{"data.title", "meta.format", "meta.source", "revision"}, diff.Fields())
+}
+
+func Test_DiffDocuments_Escape_Pointer(t *testing.T) {
+	from := map[string]interface{}{"a/b": 1, "c~d": nil}
+	to := map[string]interface{}{"a/b": 2}
+
+	patch := DiffDocuments(from, to)
+
+	assert.Equal(t, "/c~0d", patch[0].Path)
+	assert.Equal(t, "/a~1b", patch[1].Path)
+
+	data, _ := json.Marshal(patch)
+
+	assert.Equal(t, `[{"op":"remove","path":"/c~0d"},{"op":"replace","path":"/a~1b","value":2}]`, string(data))
+}
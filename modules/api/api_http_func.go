@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -145,7 +146,7 @@ func Api_GET_Stream(app *goapp.App) func(c web.C, res http.ResponseWriter, req *
 }
 
 func Api_GET_Node(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 	apiHandler := app.Get("gonode.api").(*Api)
 	handler_collection := app.Get("gonode.handler_collection").(base.Handlers)
 	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
@@ -153,6 +154,7 @@ func Api_GET_Node(app *goapp.App) func(c web.C, res http.ResponseWriter, req *ht
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) {
 		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
 		attrs := security.Attributes{"node:api:master", "node:api:read"}
 
 		if !Check(c, res, req, attrs, authorizer) {
@@ -226,6 +228,7 @@ func Api_GET_Node_Revisions(app *goapp.App) func(c web.C, res http.ResponseWrite
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) {
 		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
 		attrs := security.Attributes{"node:api:master", "node:api:revisions"}
 
 		if !Check(c, res, req, attrs, authorizer) {
@@ -269,6 +272,7 @@ func Api_GET_Node_Revision(app *goapp.App) func(c web.C, res http.ResponseWriter
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) {
 		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
 		attrs := security.Attributes{"node:api:master", "node:api:revision"}
 
 		if !Check(c, res, req, attrs, authorizer) {
@@ -294,13 +298,362 @@ func Api_GET_Node_Revision(app *goapp.App) func(c web.C, res http.ResponseWriter
 	}
 }
 
+func Api_GET_Node_Revisions_Diff(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		attrs := security.Attributes{"node:api:master", "node:api:revision"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		from, err := strconv.Atoi(c.URLParams["from"])
+
+		if err != nil {
+			base.HandleError(req, res, base.ErrNotFound)
+			return
+		}
+
+		to, err := strconv.Atoi(c.URLParams["to"])
+
+		if err != nil {
+			base.HandleError(req, res, base.ErrNotFound)
+			return
+		}
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		if diff, err := apiHandler.Diff(c.URLParams["uuid"], from, to, options); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			base.Serialize(res, diff)
+		}
+	}
+}
+
+func Api_PUT_Node_Revision_Restore(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:restore"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		revision, err := strconv.Atoi(c.URLParams["rev"])
+
+		if err != nil {
+			base.HandleError(req, res, base.ErrNotFound)
+			return
+		}
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		if node, errors, err := apiHandler.Restore(c.URLParams["uuid"], revision, options); err != nil && err != base.ErrValidation {
+			base.HandleError(req, res, err)
+		} else if errors != nil {
+			res.WriteHeader(http.StatusPreconditionFailed)
+			base.Serialize(res, errors)
+		} else {
+			serializer.Serialize(res, node)
+		}
+	}
+}
+
+// Api_GET_Node_Relations lists the nodes linked to the node, the direction is RELATION_REFERENCES
+// or RELATION_REFERENCED_BY
+func Api_GET_Node_Relations(app *goapp.App, direction string) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+	apiHandler := app.Get("gonode.api").(*Api)
+	searchBuilder := app.Get("gonode.search.pgsql").(*search.SearchPGSQL)
+	searchParser := app.Get("gonode.search.parser.http").(*search.HttpSearchParser)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:references"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		searchForm := searchParser.HandleSearch(res, req)
+
+		if searchForm == nil {
+			return
+		}
+
+		query := searchBuilder.BuildQuery(searchForm, manager.SelectBuilder(base.NewSelectOptions()))
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		pager, err := apiHandler.FindRelations(direction, c.URLParams["uuid"], req.URL.Query().Get("field"), query, searchForm.Page, searchForm.PerPage, options)
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		for k, v := range pager.Elements {
+			b := bytes.NewBuffer([]byte{})
+			serializer.Serialize(b, v)
+			message := json.RawMessage(b.Bytes())
+
+			pager.Elements[k] = &message
+		}
+
+		base.Serialize(res, pager)
+	}
+}
+
+func Api_GET_Node_Children(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+	apiHandler := app.Get("gonode.api").(*Api)
+	searchBuilder := app.Get("gonode.search.pgsql").(*search.SearchPGSQL)
+	searchParser := app.Get("gonode.search.parser.http").(*search.HttpSearchParser)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:tree"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		searchForm := searchParser.HandleSearch(res, req)
+
+		if searchForm == nil {
+			return
+		}
+
+		// the children are ordered by weight, unless the client provides its own order
+		if _, ok := req.URL.Query()["order_by"]; !ok {
+			searchForm.OrderBy = make([]*search.Param, 0)
+		}
+
+		query := searchBuilder.BuildQuery(searchForm, manager.SelectBuilder(base.NewSelectOptions()))
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		pager, err := apiHandler.FindChildren(c.URLParams["uuid"], query, searchForm.Page, searchForm.PerPage, options)
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		for k, v := range pager.Elements {
+			b := bytes.NewBuffer([]byte{})
+			serializer.Serialize(b, v)
+			message := json.RawMessage(b.Bytes())
+
+			pager.Elements[k] = &message
+		}
+
+		base.Serialize(res, pager)
+	}
+}
+
+func Api_GET_Node_Ancestors(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:tree"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		nodes, err := apiHandler.FindAncestors(c.URLParams["uuid"], options)
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		elements := make([]*json.RawMessage, 0)
+
+		for _, node := range nodes {
+			b := bytes.NewBuffer([]byte{})
+			serializer.Serialize(b, node)
+			message := json.RawMessage(b.Bytes())
+
+			elements = append(elements, &message)
+		}
+
+		base.Serialize(res, elements)
+	}
+}
+
+func Api_GET_Node_Tree(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	var serializeTree func(tree *NodeTree, serializer *base.Serializer)
+	serializeTree = func(tree *NodeTree, serializer *base.Serializer) {
+		b := bytes.NewBuffer([]byte{})
+		serializer.Serialize(b, tree.Node)
+		message := json.RawMessage(b.Bytes())
+
+		tree.Node = &message
+
+		for _, child := range tree.Children {
+			serializeTree(child, serializer)
+		}
+	}
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		attrs := security.Attributes{"node:api:master", "node:api:tree"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		depth, err := strconv.Atoi(req.URL.Query().Get("depth"))
+
+		if err != nil || depth < 1 {
+			depth = 1
+		}
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		tree, err := apiHandler.FindTree(c.URLParams["uuid"], depth, options)
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		serializeTree(tree, serializer.WithToken(token))
+
+		base.Serialize(res, tree)
+	}
+}
+
+func Api_GET_Node_Breadcrumbs(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		attrs := security.Attributes{"node:api:master", "node:api:tree"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		if breadcrumbs, err := apiHandler.FindBreadcrumbs(c.URLParams["uuid"], options); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			base.Serialize(res, breadcrumbs)
+		}
+	}
+}
+
+func Api_POST_Node_Transition(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:transition"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		if node, err := apiHandler.Transition(c.URLParams["uuid"], c.URLParams["name"], options); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			serializer.Serialize(res, node)
+		}
+	}
+}
+
+// deserializeNode validates the request's body against the node type's schema before
+// loading the node, the validation errors are returned if the body is not valid.
+func deserializeNode(req *http.Request, serializer *base.Serializer, schemas *base.Schemas) (*base.Node, base.Errors) {
+	data, err := ioutil.ReadAll(req.Body)
+
+	if err != nil {
+		errors := base.NewErrors()
+		errors.AddError("document", err.Error())
+
+		return nil, errors
+	}
+
+	if errors := schemas.Validate(data); errors.HasErrors() {
+		return nil, errors
+	}
+
+	node := base.NewNode()
+
+	if err := serializer.Deserialize(bytes.NewReader(data), node); err != nil {
+		errors := base.NewErrors()
+		errors.AddError("document", err.Error())
+
+		return nil, errors
+	}
+
+	return node, nil
+}
+
 func Api_POST_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
 	apiHandler := app.Get("gonode.api").(*Api)
 	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
 	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+	schemas := app.Get("gonode.node.schemas").(*base.Schemas)
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) {
 		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
 		attrs := security.Attributes{"node:api:master", "node:api:create"}
 
 		if !Check(c, res, req, attrs, authorizer) {
@@ -309,9 +662,11 @@ func Api_POST_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req *
 
 		res.Header().Set("Content-Type", "application/json")
 
-		node := base.NewNode()
-		if err := serializer.Deserialize(req.Body, node); err != nil {
-			base.HandleError(req, res, err)
+		node, errors := deserializeNode(req, serializer, schemas)
+
+		if errors != nil {
+			res.WriteHeader(http.StatusPreconditionFailed)
+			base.Serialize(res, errors)
 
 			return
 		}
@@ -324,22 +679,233 @@ func Api_POST_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req *
 			res.WriteHeader(http.StatusPreconditionFailed)
 			base.Serialize(res, errors)
 		} else {
-			res.WriteHeader(http.StatusCreated)
-			serializer.Serialize(res, node)
+			res.WriteHeader(http.StatusCreated)
+			serializer.Serialize(res, node)
+		}
+	}
+}
+
+func Api_PUT_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+	apiHandler := app.Get("gonode.api").(*Api)
+	handler_collection := app.Get("gonode.handler_collection").(base.Handlers)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	schemas := app.Get("gonode.node.schemas").(*base.Schemas)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:update"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		values := req.URL.Query()
+
+		if _, raw := values["raw"]; raw {
+			// send binary data
+			reference, err := base.GetReferenceFromString(c.URLParams["uuid"])
+
+			if err != nil {
+				base.HandleError(req, res, err)
+				return
+			}
+
+			node := manager.Find(reference)
+
+			if node == nil {
+				base.HandleError(req, res, base.ErrNotFound)
+				return
+			}
+
+			options := base.NewAccessOptionsFromToken(token)
+
+			if result, _ := authorizer.IsGranted(options.Token, nil, node); !result {
+				base.HandleError(req, res, base.ErrAccessForbidden)
+				return
+			}
+
+			if err := apiHandler.checkLock(node.Uuid, options); err != nil {
+				base.HandleError(req, res, err)
+				return
+			}
+
+			handler := handler_collection.Get(node)
+
+			if h, ok := handler.(base.StoreStreamNodeHandler); ok {
+				_, err = h.StoreStream(node, req.Body)
+			} else {
+				_, err = base.DefaultHandlerStoreStream(node, req.Body)
+			}
+
+			if err != nil {
+				base.HandleError(req, res, err)
+				return
+			}
+
+			// we don't save a new revision as we just need to attach binary to current node
+			manager.Save(node, false)
+
+			if err != nil {
+				base.HandleError(req, res, err)
+			} else {
+				manager.Save(node, false)
+
+				helper.SendWithHttpCode(res, http.StatusOK, "binary stored")
+			}
+
+		} else {
+			options := base.NewAccessOptionsFromToken(token)
+
+			node, errors := deserializeNode(req, serializer, schemas)
+
+			if errors != nil {
+				res.WriteHeader(http.StatusPreconditionFailed)
+				base.Serialize(res, errors)
+
+				return
+			}
+
+			if node, errors, err := apiHandler.Save(node, options); err != nil && err != base.ErrValidation {
+				base.HandleError(req, res, err)
+			} else if errors != nil {
+				res.WriteHeader(http.StatusPreconditionFailed)
+				base.Serialize(res, errors)
+			} else {
+				res.WriteHeader(http.StatusCreated)
+				serializer.Serialize(res, node)
+			}
+		}
+	}
+}
+
+func Api_PUT_Nodes_Move(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:move"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		if result, err := apiHandler.Move(c.URLParams["uuid"], c.URLParams["parentUuid"], options); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			serializer.Serialize(res, result)
+		}
+	}
+}
+
+func Api_PUT_Node_Children_Order(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:move"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		uuids := make([]string, 0)
+
+		if err := json.NewDecoder(req.Body).Decode(&uuids); err != nil {
+			helper.SendWithHttpCode(res, http.StatusBadRequest, "the body must be a JSON list of uuids")
+
+			return
+		}
+
+		_, revision := req.URL.Query()["revision"]
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		if result, err := apiHandler.Reorder(c.URLParams["uuid"], uuids, revision, options); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			serializer.Serialize(res, result)
+		}
+	}
+}
+
+func Api_POST_Node_Copy(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:copy"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		values := req.URL.Query()
+		_, recursive := values["recursive"]
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		if node, err := apiHandler.Copy(c.URLParams["uuid"], values.Get("parent"), recursive, options); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			res.WriteHeader(http.StatusCreated)
+			serializer.Serialize(res, node)
+		}
+	}
+}
+
+func Api_GET_Node_Lock(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		attrs := security.Attributes{"node:api:master", "node:api:lock"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		if lock, err := apiHandler.FindLock(c.URLParams["uuid"], options); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			base.Serialize(res, lock)
 		}
 	}
 }
 
-func Api_PUT_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+func Api_PUT_Node_Lock(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
 	apiHandler := app.Get("gonode.api").(*Api)
-	handler_collection := app.Get("gonode.handler_collection").(base.Handlers)
 	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
-	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) {
 		token := security.GetTokenFromContext(c)
-		attrs := security.Attributes{"node:api:master", "node:api:update"}
+		attrs := security.Attributes{"node:api:master", "node:api:lock"}
 
 		if !Check(c, res, req, attrs, authorizer) {
 			return
@@ -347,79 +913,35 @@ func Api_PUT_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req *h
 
 		res.Header().Set("Content-Type", "application/json")
 
-		values := req.URL.Query()
-
-		if _, raw := values["raw"]; raw {
-			// send binary data
-			reference, err := base.GetReferenceFromString(c.URLParams["uuid"])
-
-			if err != nil {
-				base.HandleError(req, res, err)
-				return
-			}
-
-			node := manager.Find(reference)
-
-			if node == nil {
-				base.HandleError(req, res, base.ErrNotFound)
-				return
-			}
+		ttl := time.Duration(0)
 
-			handler := handler_collection.Get(node)
+		if v := req.URL.Query().Get("ttl"); v != "" {
+			var err error
 
-			if h, ok := handler.(base.StoreStreamNodeHandler); ok {
-				_, err = h.StoreStream(node, req.Body)
-			} else {
-				_, err = base.DefaultHandlerStoreStream(node, req.Body)
-			}
+			if ttl, err = time.ParseDuration(v); err != nil || ttl <= 0 {
+				helper.SendWithHttpCode(res, http.StatusBadRequest, "the ttl must be a positive duration, ie: 15m")
 
-			if err != nil {
-				base.HandleError(req, res, err)
 				return
 			}
+		}
 
-			// we don't save a new revision as we just need to attach binary to current node
-			manager.Save(node, false)
-
-			if err != nil {
-				base.HandleError(req, res, err)
-			} else {
-				manager.Save(node, false)
-
-				helper.SendWithHttpCode(res, http.StatusOK, "binary stored")
-			}
+		options := base.NewAccessOptionsFromToken(token)
 
+		if lock, err := apiHandler.Lock(c.URLParams["uuid"], ttl, options); err != nil {
+			base.HandleError(req, res, err)
 		} else {
-			options := base.NewAccessOptionsFromToken(token)
-
-			node := base.NewNode()
-			if err := serializer.Deserialize(req.Body, node); err != nil {
-				base.HandleError(req, res, err)
-
-				return
-			}
-
-			if node, errors, err := apiHandler.Save(node, options); err != nil && err != base.ErrValidation {
-				base.HandleError(req, res, err)
-			} else if errors != nil {
-				res.WriteHeader(http.StatusPreconditionFailed)
-				base.Serialize(res, errors)
-			} else {
-				res.WriteHeader(http.StatusCreated)
-				serializer.Serialize(res, node)
-			}
+			base.Serialize(res, lock)
 		}
 	}
 }
 
-func Api_PUT_Nodes_Move(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+func Api_DELETE_Node_Lock(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
 	apiHandler := app.Get("gonode.api").(*Api)
 	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
-	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) {
 		token := security.GetTokenFromContext(c)
-		attrs := security.Attributes{"node:api:master", "node:api:move"}
+		attrs := security.Attributes{"node:api:master", "node:api:lock"}
 
 		if !Check(c, res, req, attrs, authorizer) {
 			return
@@ -429,10 +951,10 @@ func Api_PUT_Nodes_Move(app *goapp.App) func(c web.C, res http.ResponseWriter, r
 
 		options := base.NewAccessOptionsFromToken(token)
 
-		if result, err := apiHandler.Move(c.URLParams["uuid"], c.URLParams["parentUuid"], options); err != nil {
+		if operation, err := apiHandler.Unlock(c.URLParams["uuid"], options); err != nil {
 			base.HandleError(req, res, err)
 		} else {
-			serializer.Serialize(res, result)
+			base.Serialize(res, operation)
 		}
 	}
 }
@@ -444,6 +966,7 @@ func Api_DELETE_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) {
 		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
 		attrs := security.Attributes{"node:api:master", "node:api:delete"}
 
 		if !Check(c, res, req, attrs, authorizer) {
@@ -461,7 +984,7 @@ func Api_DELETE_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req
 }
 
 func Api_PUT_Notify(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) {
@@ -478,7 +1001,7 @@ func Api_PUT_Notify(app *goapp.App) func(c web.C, res http.ResponseWriter, req *
 }
 
 func Api_GET_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 	apiHandler := app.Get("gonode.api").(*Api)
 	searchBuilder := app.Get("gonode.search.pgsql").(*search.SearchPGSQL)
 	searchParser := app.Get("gonode.search.parser.http").(*search.HttpSearchParser)
@@ -489,6 +1012,7 @@ func Api_GET_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req *h
 		var logger *log.Entry
 
 		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
 		attrs := security.Attributes{"node:api:master", "node:api:list"}
 
 		if !Check(c, res, req, attrs, authorizer) {
@@ -536,3 +1060,231 @@ func Api_GET_Nodes(app *goapp.App) func(c web.C, res http.ResponseWriter, req *h
 		base.Serialize(res, pager)
 	}
 }
+
+func Api_GET_Audit(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	searchParser := app.Get("gonode.search.parser.http").(*search.HttpSearchParser)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		attrs := security.Attributes{"node:api:master", "node:api:audit"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		// only the pagination is used from the search form
+		searchForm := searchParser.HandleSearch(res, req)
+
+		if searchForm == nil {
+			return
+		}
+
+		query, err := NewAuditQuery(req.Form)
+
+		if err != nil {
+			helper.SendWithHttpCode(res, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		pager, err := apiHandler.FindAudit(query, searchForm.Page, searchForm.PerPage, options)
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		base.Serialize(res, pager)
+	}
+}
+
+func Api_GET_Jobs(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	searchParser := app.Get("gonode.search.parser.http").(*search.HttpSearchParser)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		attrs := security.Attributes{"node:api:master", "node:api:jobs"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		// only the pagination is used from the search form
+		searchForm := searchParser.HandleSearch(res, req)
+
+		if searchForm == nil {
+			return
+		}
+
+		query, err := NewJobQuery(req.Form)
+
+		if err != nil {
+			helper.SendWithHttpCode(res, http.StatusBadRequest, err.Error())
+
+			return
+		}
+
+		if pager, err := apiHandler.FindJobs(query, searchForm.Page, searchForm.PerPage); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			base.Serialize(res, pager)
+		}
+	}
+}
+
+func Api_GET_Jobs_Stats(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		attrs := security.Attributes{"node:api:master", "node:api:jobs"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		stats, err := apiHandler.Jobs.Stats()
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		base.Serialize(res, stats)
+	}
+}
+
+func Api_GET_Job(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		attrs := security.Attributes{"node:api:master", "node:api:jobs"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		if job, err := apiHandler.FindJob(c.URLParams["id"]); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			base.Serialize(res, job)
+		}
+	}
+}
+
+func Api_PUT_Job_Retry(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		attrs := security.Attributes{"node:api:master", "node:api:jobs"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		if job, err := apiHandler.RetryJob(c.URLParams["id"]); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			base.Serialize(res, job)
+		}
+	}
+}
+
+func Api_GET_Trash(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+	apiHandler := app.Get("gonode.api").(*Api)
+	searchBuilder := app.Get("gonode.search.pgsql").(*search.SearchPGSQL)
+	searchParser := app.Get("gonode.search.parser.http").(*search.HttpSearchParser)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:trash"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		searchForm := searchParser.HandleSearch(res, req)
+
+		if searchForm == nil {
+			return
+		}
+
+		// the deleted flag is handled by the FindDeleted method
+		searchForm.Deleted = nil
+
+		query := searchBuilder.BuildQuery(searchForm, manager.SelectBuilder(base.NewSelectOptions()))
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		pager, err := apiHandler.FindDeleted(query, searchForm.Page, searchForm.PerPage, options)
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		for k, v := range pager.Elements {
+			b := bytes.NewBuffer([]byte{})
+			serializer.Serialize(b, v)
+			message := json.RawMessage(b.Bytes())
+
+			pager.Elements[k] = &message
+		}
+
+		base.Serialize(res, pager)
+	}
+}
+
+func Api_PUT_Trash_Undelete(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*Api)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		serializer := serializer.WithToken(token)
+		attrs := security.Attributes{"node:api:master", "node:api:trash"}
+
+		if !Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		_, recursive := req.URL.Query()["recursive"]
+
+		options := base.NewAccessOptionsFromToken(token)
+
+		if result, err := apiHandler.Undelete(c.URLParams["uuid"], recursive, options); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			serializer.Serialize(res, result)
+		}
+	}
+}
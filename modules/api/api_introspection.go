@@ -14,9 +14,15 @@ import (
 	"github.com/zenazn/goji/web"
 )
 
+// StatsService is implemented by the services exposing statistics in the introspection
+type StatsService interface {
+	Stats() interface{}
+}
+
 type Service struct {
-	Name string `json:"name"`
-	Type string `json:"type"`
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Stats interface{} `json:"stats,omitempty"`
 }
 
 func Api_GET_Handlers_Node(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
@@ -47,6 +53,23 @@ func Api_GET_Handlers_Node(app *goapp.App) func(c web.C, res http.ResponseWriter
 	}
 }
 
+func Api_GET_Handlers_Node_Schema(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	collections := app.Get("gonode.handler_collection").(base.HandlerCollection)
+	schemas := app.Get("gonode.node.schemas").(*base.Schemas)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		if !collections.HasType(c.URLParams["type"]) {
+			base.HandleError(req, res, base.ErrNotFound)
+
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/schema+json")
+
+		base.Serialize(res, schemas.Get(c.URLParams["type"]))
+	}
+}
+
 func Api_GET_Handlers_View(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
 	collections := app.Get("gonode.view_handler_collection").(base.ViewHandlerCollection)
 	serializer := app.Get("gonode.node.serializer").(*base.Serializer)
@@ -84,10 +107,16 @@ func Api_GET_Services(app *goapp.App) func(c web.C, res http.ResponseWriter, req
 		ch := make([]*Service, 0)
 
 		for _, key := range app.GetKeys() {
-			ch = append(ch, &Service{
+			service := &Service{
 				Name: key,
 				Type: fmt.Sprintf("%T", app.Get(key)),
-			})
+			}
+
+			if s, ok := app.Get(key).(StatsService); ok {
+				service.Stats = s.Stats()
+			}
+
+			ch = append(ch, service)
 		}
 
 		serializer.Serialize(res, ch)
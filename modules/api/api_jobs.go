@@ -0,0 +1,97 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/rande/gonode/modules/base"
+)
+
+var ErrInvalidJobQuery = errors.New("invalid job query")
+
+// the names of the job's statuses used by the status parameter
+var JobStatuses = map[string]int{
+	"ready":   base.ProcessStatusReady,
+	"running": base.ProcessStatusUpdate,
+	"done":    base.ProcessStatusDone,
+	"dead":    base.ProcessStatusError,
+}
+
+// NewJobQuery creates the job query from the request's parameters: queue and status (ready,
+// running, done or dead)
+func NewJobQuery(values url.Values) (*base.JobQuery, error) {
+	query := &base.JobQuery{
+		Queue: values.Get("queue"),
+	}
+
+	if name := values.Get("status"); name != "" {
+		status, ok := JobStatuses[name]
+
+		if !ok {
+			return nil, ErrInvalidJobQuery
+		}
+
+		query.Status = &status
+	}
+
+	return query, nil
+}
+
+// FindJobs returns the jobs matching the query, the most recent first
+func (a *Api) FindJobs(query *base.JobQuery, page uint64, perPage uint64) (*ApiPager, error) {
+	jobs, err := a.Jobs.FindBy(a.Jobs.SelectBuilder(query), (page-1)*perPage, perPage+1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pager := &ApiPager{
+		Elements: make([]interface{}, 0),
+		Page:     page,
+		PerPage:  perPage,
+	}
+
+	if page > 1 {
+		pager.Previous = page - 1
+	}
+
+	for k, job := range jobs {
+		if uint64(k) == perPage {
+			pager.Next = page + 1
+
+			break
+		}
+
+		pager.Elements = append(pager.Elements, job)
+	}
+
+	return pager, nil
+}
+
+// FindJob returns the job, ErrNotFound is returned if the id is not valid
+func (a *Api) FindJob(id string) (*base.Job, error) {
+	jobId, err := strconv.ParseInt(id, 10, 64)
+
+	if err != nil {
+		return nil, base.ErrNotFound
+	}
+
+	return a.Jobs.Find(jobId)
+}
+
+// RetryJob sends a dead-lettered job back to its queue
+func (a *Api) RetryJob(id string) (*base.Job, error) {
+	job, err := a.FindJob(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Jobs.Retry(job.Id)
+}
@@ -0,0 +1,78 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rande/gonode/core/security"
+	"github.com/rande/gonode/modules/base"
+)
+
+// FindLock returns the node's active edit lock, ErrNotFound is returned if the node is not locked
+func (a *Api) FindLock(uuid string, options *base.AccessOptions) (*base.Lock, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lock, err := a.Locker.Find(node.Uuid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lock == nil {
+		return nil, base.ErrNotFound
+	}
+
+	return lock, nil
+}
+
+// Lock acquires, or extends, the user's edit lock on the node, the locker's default ttl is used
+// if ttl is 0
+func (a *Api) Lock(uuid string, ttl time.Duration, options *base.AccessOptions) (*base.Lock, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Locker.Acquire(node.Uuid, lockOwner(options.Token), ttl)
+}
+
+// Unlock releases the user's edit lock, the users with a LockOverrideRoles role can release
+// the lock of another user.
+func (a *Api) Unlock(uuid string, options *base.AccessOptions) (*ApiOperation, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	force := options.Token != nil && base.CanOverrideLock(options.Token.GetRoles())
+
+	released, err := a.Locker.Release(node.Uuid, lockOwner(options.Token), force)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ApiOperation{
+		Status:  OPERATION_OK,
+		Message: fmt.Sprintf("Node unlocked: %t", released),
+	}, nil
+}
+
+func lockOwner(token security.SecurityToken) string {
+	if token == nil {
+		return ""
+	}
+
+	return token.GetUsername()
+}
@@ -0,0 +1,154 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rande/gonode/modules/base"
+)
+
+const (
+	TREE_MAX_DEPTH = 10
+	TREE_MAX_NODES = 1000
+)
+
+// NodeTree is a node with its children, Node contains the serialized node once the tree is
+// sent by the http handler.
+type NodeTree struct {
+	Node     interface{} `json:"node"`
+	Children []*NodeTree `json:"children"`
+}
+
+type Breadcrumb struct {
+	Uuid base.Reference `json:"uuid"`
+	Type string         `json:"type"`
+	Name string         `json:"name"`
+	Slug string         `json:"slug"`
+	Path string         `json:"path"`
+}
+
+// treeQuery filters out the deleted nodes and the nodes not granted by the access options,
+// the nodes are ordered by weight
+func (a *Api) treeQuery(query sq.SelectBuilder, options *base.AccessOptions) sq.SelectBuilder {
+	return a.accessQuery(query.Where(sq.Eq{"deleted": false}), options).OrderBy("weight ASC", "id ASC")
+}
+
+// FindChildren returns the direct children of the node ordered by weight
+func (a *Api) FindChildren(uuid string, query sq.SelectBuilder, page uint64, perPage uint64, options *base.AccessOptions) (*ApiPager, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Find(query.Where(sq.Eq{"parent_uuid": node.Uuid.CleanString()}).OrderBy("weight ASC", "id ASC"), page, perPage, options)
+}
+
+// FindAncestors returns the node's ancestors from the root to the direct parent
+func (a *Api) FindAncestors(uuid string, options *base.AccessOptions) ([]*base.Node, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.findAncestors(node, options), nil
+}
+
+func (a *Api) findAncestors(node *base.Node, options *base.AccessOptions) []*base.Node {
+	ancestors := make([]*base.Node, 0)
+
+	if len(node.Parents) == 0 {
+		return ancestors
+	}
+
+	uuids := make([]string, 0)
+	for _, p := range node.Parents {
+		uuids = append(uuids, p.CleanString())
+	}
+
+	query := a.treeQuery(a.Manager.SelectBuilder(base.NewSelectOptions()).Where(sq.Eq{"uuid": uuids}), options)
+
+	nodes := make(map[string]*base.Node)
+	for e := a.Manager.FindBy(query, 0, uint64(len(uuids))).Front(); e != nil; e = e.Next() {
+		n := e.Value.(*base.Node)
+		nodes[n.Uuid.CleanString()] = n
+	}
+
+	for _, uuid := range uuids {
+		if n, ok := nodes[uuid]; ok {
+			ancestors = append(ancestors, n)
+		}
+	}
+
+	return ancestors
+}
+
+// FindBreadcrumbs returns the ancestors and the node, the nodes not granted are skipped
+func (a *Api) FindBreadcrumbs(uuid string, options *base.AccessOptions) ([]*Breadcrumb, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	breadcrumbs := make([]*Breadcrumb, 0)
+
+	for _, n := range append(a.findAncestors(node, options), node) {
+		breadcrumbs = append(breadcrumbs, &Breadcrumb{
+			Uuid: n.Uuid,
+			Type: n.Type,
+			Name: n.Name,
+			Slug: n.Slug,
+			Path: n.Path,
+		})
+	}
+
+	return breadcrumbs, nil
+}
+
+// FindTree returns the node and its descendants up to depth levels, one query is used per
+// level. A node not granted is skipped with its descendants.
+func (a *Api) FindTree(uuid string, depth int, options *base.AccessOptions) (*NodeTree, error) {
+	node, err := a.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if depth > TREE_MAX_DEPTH {
+		depth = TREE_MAX_DEPTH
+	}
+
+	tree := &NodeTree{Node: node, Children: make([]*NodeTree, 0)}
+
+	level := map[string]*NodeTree{node.Uuid.CleanString(): tree}
+	remaining := uint64(TREE_MAX_NODES)
+
+	for d := 0; d < depth && len(level) > 0 && remaining > 0; d++ {
+		parents := make([]string, 0)
+		for uuid := range level {
+			parents = append(parents, uuid)
+		}
+
+		query := a.treeQuery(a.Manager.SelectBuilder(base.NewSelectOptions()).Where(sq.Eq{"parent_uuid": parents}), options)
+
+		next := make(map[string]*NodeTree)
+
+		for e := a.Manager.FindBy(query, 0, remaining).Front(); e != nil; e = e.Next() {
+			child := e.Value.(*base.Node)
+			t := &NodeTree{Node: child, Children: make([]*NodeTree, 0)}
+
+			level[child.ParentUuid.CleanString()].Children = append(level[child.ParentUuid.CleanString()].Children, t)
+			next[child.Uuid.CleanString()] = t
+			remaining--
+		}
+
+		level = next
+	}
+
+	return tree, nil
+}
@@ -0,0 +1,147 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// the actions which created a revision
+const (
+	AUDIT_CREATE   = "create"
+	AUDIT_UPDATE   = "update"
+	AUDIT_DELETE   = "delete"
+	AUDIT_UNDELETE = "undelete"
+)
+
+var AuditActions = []string{AUDIT_CREATE, AUDIT_UPDATE, AUDIT_DELETE, AUDIT_UNDELETE}
+
+// AuditQuery filters the revisions stored in the nodes_audit table, the empty fields are ignored
+type AuditQuery struct {
+	UpdatedBy []Reference
+	Types     []string
+	Actions   []string
+	From      *time.Time // the revisions updated at or after this date
+	To        *time.Time // the revisions updated before this date
+}
+
+// Audit reads the revisions of all the nodes, each revision is compared with the previous one
+// to find the action which created it.
+type Audit struct {
+	Manager *SqlNodeManager
+}
+
+func (a *Audit) table() string {
+	return a.Manager.Prefix + "_nodes_audit"
+}
+
+// SelectBuilder returns the query of the revisions matching the audit query, the most recent
+// revisions first
+func (a *Audit) SelectBuilder(q *AuditQuery) sq.SelectBuilder {
+	options := NewSelectOptions()
+	options.TableSuffix = "nodes_audit"
+
+	query := a.Manager.SelectBuilder(options).OrderBy("updated_at DESC", "id DESC")
+
+	if len(q.UpdatedBy) > 0 {
+		uuids := make([]string, 0)
+		for _, u := range q.UpdatedBy {
+			uuids = append(uuids, u.CleanString())
+		}
+
+		query = query.Where(sq.Eq{"updated_by": uuids})
+	}
+
+	if len(q.Types) > 0 {
+		query = query.Where(sq.Eq{"type": q.Types})
+	}
+
+	if q.From != nil {
+		query = query.Where(sq.GtOrEq{"updated_at": *q.From})
+	}
+
+	if q.To != nil {
+		query = query.Where(sq.Lt{"updated_at": *q.To})
+	}
+
+	if len(q.Actions) > 0 {
+		or := sq.Or{}
+		for _, action := range q.Actions {
+			or = append(or, a.actionPredicate(action))
+		}
+
+		query = query.Where(or)
+	}
+
+	return query
+}
+
+// actionPredicate compares the revision with the previous one, an unknown action matches nothing
+func (a *Audit) actionPredicate(action string) sq.Sqlizer {
+	previous := fmt.Sprintf(`SELECT 1 FROM %s p WHERE p.uuid = %s.uuid AND p.revision = %s.revision - 1`, a.table(), a.table(), a.table())
+
+	switch action {
+	case AUDIT_CREATE:
+		return sq.Expr("revision = 1")
+	case AUDIT_UPDATE:
+		return sq.Expr(fmt.Sprintf("revision > 1 AND NOT EXISTS(%s AND p.deleted <> %s.deleted)", previous, a.table()))
+	case AUDIT_DELETE:
+		return sq.Expr(fmt.Sprintf("revision > 1 AND deleted = ? AND EXISTS(%s AND p.deleted = ?)", previous), true, false)
+	case AUDIT_UNDELETE:
+		return sq.Expr(fmt.Sprintf("revision > 1 AND deleted = ? AND EXISTS(%s AND p.deleted = ?)", previous), false, true)
+	}
+
+	return sq.Expr("1 = 0")
+}
+
+// Previous returns the revisions preceding the nodes' revisions, indexed by AuditKey
+func (a *Audit) Previous(nodes []*Node) map[string]*Node {
+	previous := make(map[string]*Node)
+
+	or := sq.Or{}
+	for _, node := range nodes {
+		if node.Revision > 1 {
+			or = append(or, sq.Eq{"uuid": node.Uuid.CleanString(), "revision": node.Revision - 1})
+		}
+	}
+
+	if len(or) == 0 {
+		return previous
+	}
+
+	options := NewSelectOptions()
+	options.TableSuffix = "nodes_audit"
+
+	for e := a.Manager.FindBy(a.Manager.SelectBuilder(options).Where(or), 0, uint64(len(or))).Front(); e != nil; e = e.Next() {
+		node := e.Value.(*Node)
+
+		previous[AuditKey(node.Uuid, node.Revision+1)] = node
+	}
+
+	return previous
+}
+
+// AuditKey returns the key used by Audit.Previous for the node's revision
+func AuditKey(uuid Reference, revision int) string {
+	return fmt.Sprintf("%s:%d", uuid.CleanString(), revision)
+}
+
+// AuditAction returns the action which created the revision, previous is nil for the first revision
+func AuditAction(node, previous *Node) string {
+	switch {
+	case node.Revision == 1:
+		return AUDIT_CREATE
+	case previous != nil && node.Deleted && !previous.Deleted:
+		return AUDIT_DELETE
+	case previous != nil && !node.Deleted && previous.Deleted:
+		return AUDIT_UNDELETE
+	}
+
+	return AUDIT_UPDATE
+}
@@ -0,0 +1,80 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func findAudit(a *Audit, q *AuditQuery) []*Node {
+	nodes := make([]*Node, 0)
+
+	for e := a.Manager.FindBy(a.SelectBuilder(q), 0, 100).Front(); e != nil; e = e.Next() {
+		nodes = append(nodes, e.Value.(*Node))
+	}
+
+	return nodes
+}
+
+func Test_Audit(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	a := &Audit{Manager: m}
+	editor := GetReference(uuid.New())
+	start := time.Now()
+
+	node := newCachedUser(m, "thomas")
+	other := newCachedUser(m, "other")
+
+	node.Name = "Thomas Rabaix"
+	node.UpdatedBy = editor
+	m.Save(node, true) // revision 2
+
+	m.RemoveOne(node) // revision 3
+
+	node.Deleted = false
+	m.Save(node, true) // revision 4
+
+	assert.Equal(t, 5, len(findAudit(a, &AuditQuery{})))
+	assert.Equal(t, 0, len(findAudit(a, &AuditQuery{Types: []string{"core.user"}})))
+
+	nodes := findAudit(a, &AuditQuery{Actions: []string{AUDIT_CREATE}})
+	assert.Equal(t, 2, len(nodes))
+	assert.Equal(t, other.Uuid, nodes[0].Uuid)
+
+	cases := map[string]int{AUDIT_UPDATE: 2, AUDIT_DELETE: 3, AUDIT_UNDELETE: 4}
+	for action, revision := range cases {
+		nodes = findAudit(a, &AuditQuery{Actions: []string{action}})
+
+		assert.Equal(t, 1, len(nodes), action)
+		assert.Equal(t, revision, nodes[0].Revision, action)
+	}
+
+	assert.Equal(t, 0, len(findAudit(a, &AuditQuery{Actions: []string{"unknown"}})))
+
+	// the editor has created the revisions 2, 3 and 4
+	nodes = findAudit(a, &AuditQuery{UpdatedBy: []Reference{editor}, Actions: []string{AUDIT_UPDATE, AUDIT_DELETE}})
+	assert.Equal(t, 2, len(nodes))
+	assert.Equal(t, 3, nodes[0].Revision)
+
+	from := start.Add(-time.Hour)
+	assert.Equal(t, 5, len(findAudit(a, &AuditQuery{From: &from})))
+	assert.Equal(t, 0, len(findAudit(a, &AuditQuery{To: &from})))
+
+	// the previous revisions
+	nodes = findAudit(a, &AuditQuery{UpdatedBy: []Reference{editor}})
+	previous := a.Previous(nodes)
+
+	assert.Equal(t, 3, len(previous))
+	assert.Equal(t, "thomas", previous[AuditKey(node.Uuid, 2)].Name)
+	assert.Equal(t, AUDIT_UNDELETE, AuditAction(nodes[0], previous[AuditKey(node.Uuid, 4)]))
+	assert.Equal(t, AUDIT_CREATE, AuditAction(&Node{Revision: 1}, nil))
+}
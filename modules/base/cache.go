@@ -0,0 +1,312 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"container/list"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/rande/gonode/core/helper"
+	log "github.com/sirupsen/logrus"
+)
+
+type NodeCacheStats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Evictions     uint64 `json:"evictions"`
+	Invalidations uint64 `json:"invalidations"`
+	Size          int    `json:"size"`
+	Capacity      int    `json:"capacity"`
+}
+
+type nodeCacheEntry struct {
+	key  string
+	node *Node
+}
+
+func NewNodeCache(manager NodeManager, capacity int, logger *log.Logger) *NodeCache {
+	return &NodeCache{
+		Manager:  manager,
+		Logger:   logger,
+		capacity: capacity,
+		entries:  list.New(),
+		keys:     make(map[string]*list.Element),
+	}
+}
+
+// NodeCache is a read-through cache wrapping a NodeManager, the nodes loaded with Find and
+// FindByPath are kept in a bounded LRU list. The entries are invalidated by the
+// _manager_action events, so all the instances sharing the database stay coherent.
+//
+// Find and FindByPath return a copy of the cached node, so a caller altering the node does not
+// alter the cache.
+type NodeCache struct {
+	Manager NodeManager
+	Logger  *log.Logger
+
+	capacity   int
+	entries    *list.List
+	keys       map[string]*list.Element
+	generation uint64
+	stats      NodeCacheStats
+	lock       sync.Mutex
+}
+
+func (c *NodeCache) SelectBuilder(options *SelectOptions) sq.SelectBuilder {
+	return c.Manager.SelectBuilder(options)
+}
+
+func (c *NodeCache) FindBy(query sq.SelectBuilder, offset uint64, limit uint64) *list.List {
+	return c.Manager.FindBy(query, offset, limit)
+}
+
+func (c *NodeCache) FindOneBy(query sq.SelectBuilder) *Node {
+	return c.Manager.FindOneBy(query)
+}
+
+func (c *NodeCache) Find(uuid Reference) *Node {
+	return copyNode(c.load("uuid:"+uuid.CleanString(), func() *Node {
+		return c.Manager.Find(uuid)
+	}))
+}
+
+// FindByPath returns the node stored at the path, the active node is used if a deleted node
+// shares the same path.
+func (c *NodeCache) FindByPath(path string) *Node {
+	return copyNode(c.load("path:"+path, func() *Node {
+		query := c.Manager.SelectBuilder(NewSelectOptions()).
+			Where(sq.Eq{"path": path}).
+			OrderBy("deleted ASC", "id DESC")
+
+		return c.Manager.FindOneBy(query)
+	}))
+}
+
+func (c *NodeCache) Remove(query sq.SelectBuilder) error {
+	defer c.Clear()
+
+	return c.Manager.Remove(query)
+}
+
+func (c *NodeCache) RemoveOne(node *Node) (*Node, error) {
+	defer c.Invalidate(node.Uuid)
+
+	return c.Manager.RemoveOne(node)
+}
+
+func (c *NodeCache) Save(node *Node, revision bool) (*Node, error) {
+	defer c.Invalidate(node.Uuid)
+
+	return c.Manager.Save(node, revision)
+}
+
+func (c *NodeCache) Notify(channel string, payload string) {
+	c.Manager.Notify(channel, payload)
+}
+
+func (c *NodeCache) NewNode(t string) *Node {
+	return c.Manager.NewNode(t)
+}
+
+func (c *NodeCache) Validate(node *Node) (bool, Errors) {
+	return c.Manager.Validate(node)
+}
+
+func (c *NodeCache) Move(uuid, parent Reference) (int64, error) {
+	// the parents and the path of the descendants are updated too
+	defer c.Clear()
+
+	return c.Manager.Move(uuid, parent)
+}
+
+func (c *NodeCache) Reorder(parent Reference, uuids []Reference, revision bool) (int64, error) {
+	defer c.Clear()
+
+	return c.Manager.Reorder(parent, uuids, revision)
+}
+
+// FindRedirect does not use the cache, it is only used once a path is not found
+func (c *NodeCache) FindRedirect(path string) *Node {
+	return c.Manager.FindRedirect(path)
+}
+
+// Transaction does not use the cache, the nodes read inside a transaction can be uncommitted
+func (c *NodeCache) Transaction(f func(tx NodeManager) error) error {
+	return c.Manager.Transaction(f)
+}
+
+// Invalidate removes the node's entries, as a path can be taken by another node all the
+// path entries are removed too.
+func (c *NodeCache) Invalidate(uuid Reference) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+	c.stats.Invalidations++
+
+	c.remove("uuid:" + uuid.CleanString())
+
+	for key, el := range c.keys {
+		if strings.HasPrefix(key, "path:") {
+			c.entries.Remove(el)
+			delete(c.keys, key)
+		}
+	}
+}
+
+func (c *NodeCache) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+	c.stats.Invalidations++
+
+	c.entries.Init()
+	c.keys = make(map[string]*list.Element)
+}
+
+// Stats returns a NodeCacheStats copy, the value is exposed in the services introspection
+func (c *NodeCache) Stats() interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stats := c.stats
+	stats.Size = c.entries.Len()
+	stats.Capacity = c.capacity
+
+	return &stats
+}
+
+// Handle invalidates the entries altered by a _manager_action notification
+func (c *NodeCache) Handle(notification *pq.Notification) (int, error) {
+	event := CreateModelEvent(notification)
+
+	// the edit locks do not alter the node
+	if event.Action == "Lock" || event.Action == "Unlock" {
+		return PubSubListenContinue, nil
+	}
+
+	if c.Logger != nil {
+		c.Logger.WithFields(log.Fields{
+			"uuid":   event.Subject,
+			"action": event.Action,
+			"module": "node.cache",
+		}).Debug("invalidate node")
+	}
+
+	reference, err := GetReferenceFromString(event.Subject)
+
+	// a move alters the descendants, and a reorder alters the children
+	if err != nil || event.Action == "Move" || event.Action == "Reorder" {
+		c.Clear()
+	} else {
+		c.Invalidate(reference)
+	}
+
+	return PubSubListenContinue, nil
+}
+
+func (c *NodeCache) load(key string, loader func() *Node) *Node {
+	c.lock.Lock()
+
+	if el, ok := c.keys[key]; ok {
+		c.entries.MoveToFront(el)
+		c.stats.Hits++
+		c.lock.Unlock()
+
+		return el.Value.(*nodeCacheEntry).node
+	}
+
+	c.stats.Misses++
+	generation := c.generation
+	c.lock.Unlock()
+
+	node := loader()
+
+	if node == nil {
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// the node might have been altered while it was loaded
+	if generation != c.generation || c.capacity <= 0 {
+		return node
+	}
+
+	if _, ok := c.keys[key]; !ok {
+		c.keys[key] = c.entries.PushFront(&nodeCacheEntry{key: key, node: node})
+	}
+
+	for c.entries.Len() > c.capacity {
+		c.remove(c.entries.Back().Value.(*nodeCacheEntry).key)
+		c.stats.Evictions++
+	}
+
+	return node
+}
+
+func (c *NodeCache) remove(key string) {
+	if el, ok := c.keys[key]; ok {
+		c.entries.Remove(el)
+		delete(c.keys, key)
+	}
+}
+
+// copyNode returns a deep copy of the node, the data, meta and modules are copied through their
+// JSON form like the manager stores them
+func copyNode(node *Node) *Node {
+	if node == nil {
+		return nil
+	}
+
+	clone := *node
+	clone.Data = copyValue(node.Data)
+	clone.Meta = copyValue(node.Meta)
+	clone.Parents = append([]Reference(nil), node.Parents...)
+	clone.Access = append([]string(nil), node.Access...)
+
+	if node.Modules != nil {
+		clone.Modules = make(Modules)
+		data, err := json.Marshal(node.Modules)
+		helper.PanicOnError(err)
+		helper.PanicOnError(json.Unmarshal(data, &clone.Modules))
+	}
+
+	if node.PublishAt != nil {
+		date := *node.PublishAt
+		clone.PublishAt = &date
+	}
+
+	if node.UnpublishAt != nil {
+		date := *node.UnpublishAt
+		clone.UnpublishAt = &date
+	}
+
+	return &clone
+}
+
+func copyValue(value interface{}) interface{} {
+	t := reflect.TypeOf(value)
+
+	if t == nil || t.Kind() != reflect.Ptr {
+		return value
+	}
+
+	clone := reflect.New(t.Elem()).Interface()
+
+	data, err := json.Marshal(value)
+	helper.PanicOnError(err)
+	helper.PanicOnError(json.Unmarshal(data, clone))
+
+	return clone
+}
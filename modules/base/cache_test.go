@@ -0,0 +1,145 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCachedUser(m *SqlNodeManager, slug string) *Node {
+	node := m.NewNode("node.user")
+	node.Name = slug
+	node.Slug = slug
+	node.Path = "/" + slug
+
+	m.Save(node, false)
+
+	return node
+}
+
+func Test_NodeCache_Find(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	cache := NewNodeCache(m, 10, nil)
+	node := newCachedUser(m, "thomas")
+
+	assert.Equal(t, node.Uuid, cache.Find(node.Uuid).Uuid)
+	assert.Equal(t, cache.Find(node.Uuid), cache.Find(node.Uuid))
+	assert.Equal(t, node.Uuid, cache.FindByPath("/thomas").Uuid)
+	assert.Nil(t, cache.FindByPath("/missing"))
+
+	stats := cache.Stats().(*NodeCacheStats)
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(3), stats.Misses)
+	assert.Equal(t, 2, stats.Size)
+	assert.Equal(t, 10, stats.Capacity)
+
+	// the callers get a copy of the cached node
+	found := cache.Find(node.Uuid)
+	found.Name = "altered"
+	found.Data.(*User).Name = "altered"
+	found.Access[0] = "altered"
+
+	found = cache.Find(node.Uuid)
+	assert.Equal(t, "thomas", found.Name)
+	assert.Equal(t, "", found.Data.(*User).Name)
+	assert.NotEqual(t, "altered", found.Access[0])
+}
+
+func Test_NodeCache_Eviction(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	cache := NewNodeCache(m, 2, nil)
+
+	n1 := newCachedUser(m, "n1")
+	n2 := newCachedUser(m, "n2")
+	n3 := newCachedUser(m, "n3")
+
+	cache.Find(n1.Uuid)
+	cache.Find(n2.Uuid)
+	cache.Find(n1.Uuid) // n2 is now the least recently used node
+	cache.Find(n3.Uuid)
+
+	stats := cache.Stats().(*NodeCacheStats)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Size)
+
+	cache.Find(n1.Uuid)
+	assert.Equal(t, uint64(2), cache.Stats().(*NodeCacheStats).Hits)
+
+	cache.Find(n2.Uuid)
+	assert.Equal(t, uint64(4), cache.Stats().(*NodeCacheStats).Misses)
+}
+
+func Test_NodeCache_Handle(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	cache := NewNodeCache(m, 10, nil)
+	node := newCachedUser(m, "thomas")
+	other := newCachedUser(m, "other")
+
+	cache.Find(node.Uuid)
+	cache.Find(other.Uuid)
+	cache.FindByPath("/thomas")
+
+	// the node is altered by another instance
+	node.Name = "Thomas Rabaix"
+	m.Save(node, false)
+
+	assert.Equal(t, "thomas", cache.Find(node.Uuid).Name)
+
+	data, _ := json.Marshal(&ModelEvent{Action: "Update", Subject: node.Uuid.CleanString()})
+	cache.Handle(&pq.Notification{Extra: string(data)})
+
+	assert.Equal(t, 1, cache.Stats().(*NodeCacheStats).Size)
+	assert.Equal(t, "Thomas Rabaix", cache.Find(node.Uuid).Name)
+	assert.Equal(t, "Thomas Rabaix", cache.FindByPath("/thomas").Name)
+
+	data, _ = json.Marshal(&ModelEvent{Action: "Move", Subject: node.Uuid.CleanString()})
+	cache.Handle(&pq.Notification{Extra: string(data)})
+
+	assert.Equal(t, 0, cache.Stats().(*NodeCacheStats).Size)
+}
+
+func Test_NodeCache_Save(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	cache := NewNodeCache(m, 10, nil)
+	node := newCachedUser(m, "thomas")
+
+	cache.Find(node.Uuid)
+
+	node = m.Find(node.Uuid)
+	node.Name = "Thomas Rabaix"
+
+	_, err := cache.Save(node, false)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Thomas Rabaix", cache.Find(node.Uuid).Name)
+}
+
+func Test_NodeCache_Disabled(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	cache := NewNodeCache(m, 0, nil)
+	node := newCachedUser(m, "thomas")
+
+	assert.Equal(t, node.Uuid, cache.Find(node.Uuid).Uuid)
+	assert.Equal(t, node.Uuid, cache.Find(node.Uuid).Uuid)
+
+	stats := cache.Stats().(*NodeCacheStats)
+	assert.Equal(t, uint64(0), stats.Hits)
+	assert.Equal(t, 0, stats.Size)
+}
@@ -0,0 +1,124 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Claims coordinates the instances sharing the database: a claim is held by a single instance,
+// the owner, until it is released or until it expires. So the claim of a crashed instance is
+// taken over by another instance once the TTL is reached.
+type Claims struct {
+	Manager *SqlNodeManager
+	Owner   string        // identifier of the instance
+	TTL     time.Duration // the claim expires if it is not extended within this duration
+	Logger  *log.Logger
+}
+
+// NewClaims returns the claims of the current instance, the owner is built from the hostname,
+// the process id and a random suffix
+func NewClaims(m *SqlNodeManager) *Claims {
+	hostname, _ := os.Hostname()
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	return &Claims{
+		Manager: m,
+		Owner:   fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), hex.EncodeToString(suffix)),
+		TTL:     time.Minute,
+	}
+}
+
+func (c *Claims) table() string {
+	return c.Manager.Prefix + "_nodes_claims"
+}
+
+// Claim acquires the claim, or extends it if the instance already holds it. The function returns
+// false if another instance holds an active claim.
+func (c *Claims) Claim(name string) (bool, error) {
+	return c.ClaimFor(name, c.TTL)
+}
+
+// ClaimFor acquires or extends the claim like Claim does, the claim expires after the ttl
+func (c *Claims) ClaimFor(name string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	// the statement is atomic: an expired claim, or the instance's claim, is replaced
+	r, err := c.Manager.runner().Exec(fmt.Sprintf(`INSERT INTO %s (name, owner, expires_at, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at, updated_at = excluded.updated_at
+		WHERE %s.owner = excluded.owner OR %s.expires_at <= $4`, c.table(), c.table(), c.table()),
+		name, c.Owner, now.Add(ttl), now)
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := r.RowsAffected()
+
+	return affected > 0, err
+}
+
+// Release removes the instance's claim, so another instance can acquire it without waiting for
+// the expiration
+func (c *Claims) Release(name string) error {
+	_, err := c.Manager.runner().Exec(fmt.Sprintf(`DELETE FROM %s WHERE name = $1 AND owner = $2`, c.table()), name, c.Owner)
+
+	return err
+}
+
+// Keep extends the claim every third of the ttl until the returned function is called, so the
+// claim does not expire while a long task runs. The renewal stops if the claim is lost.
+func (c *Claims) Keep(name string, ttl time.Duration) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		if ttl <= 0 {
+			<-done
+
+			return
+		}
+
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if claimed, err := c.ClaimFor(name, ttl); err != nil || !claimed {
+					if c.Logger != nil {
+						c.Logger.WithFields(log.Fields{
+							"module": "node.claims",
+							"claim":  name,
+							"error":  err,
+						}).Warn("the claim cannot be extended")
+					}
+
+					<-done
+
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
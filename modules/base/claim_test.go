@@ -0,0 +1,52 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Claims(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	first := NewClaims(m)
+	second := NewClaims(m)
+
+	assert.NotEqual(t, first.Owner, second.Owner)
+
+	claimed, err := first.Claim("consumer.media")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	// the claim is extended by its owner
+	claimed, _ = first.Claim("consumer.media")
+	assert.True(t, claimed)
+
+	claimed, err = second.Claim("consumer.media")
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+
+	claimed, _ = second.Claim("consumer.webhook")
+	assert.True(t, claimed)
+
+	// the claim is released
+	assert.NoError(t, second.Release("consumer.media"))
+	assert.NoError(t, first.Release("consumer.media"))
+
+	claimed, _ = second.Claim("consumer.media")
+	assert.True(t, claimed)
+
+	// the claim of a crashed instance expires
+	second.TTL = -time.Second
+	second.Claim("consumer.media")
+
+	claimed, _ = first.Claim("consumer.media")
+	assert.True(t, claimed)
+}
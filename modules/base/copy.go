@@ -0,0 +1,178 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"io"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rande/gonode/core/vault"
+	log "github.com/sirupsen/logrus"
+)
+
+// Copier duplicates nodes, a clone gets a new uuid and its Source is the original node
+type Copier struct {
+	Manager *SqlNodeManager
+	Vault   *vault.Vault
+	Logger  *log.Logger
+}
+
+// Copy clones the node under the parent, the node is copied at the root level if the parent is
+// nil. If recursive is true, the non deleted descendants are cloned too. The clones keep the
+// access of the original nodes. The function returns the node's clone and the number of
+// created nodes.
+func (c *Copier) Copy(node, parent *Node, recursive bool) (*Node, int, error) {
+	var clone *Node
+
+	count := 0
+	binaries := make(map[string]*Node)
+
+	err := c.Manager.Transaction(func(tx NodeManager) error {
+		m := tx.(*SqlNodeManager)
+
+		// the descendants are loaded first, so a node can be copied inside its own subtree
+		levels := make([][]*Node, 0)
+		if recursive {
+			levels = c.descendants(m, node)
+		}
+
+		var err error
+
+		if clone, err = c.copy(m, node, parent, binaries); err != nil {
+			return err
+		}
+
+		count++
+
+		clones := map[string]*Node{node.Uuid.CleanString(): clone}
+
+		for _, level := range levels {
+			for _, n := range level {
+				cloned, err := c.copy(m, n, clones[n.ParentUuid.CleanString()], binaries)
+
+				if err != nil {
+					return err
+				}
+
+				clones[n.Uuid.CleanString()] = cloned
+				count++
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// the vault is not transactional, the binaries are copied once the clones are committed: a
+	// failed copy leaves a clone without its binary
+	for name, cloned := range binaries {
+		if err := c.copyBinary(name, cloned); err != nil {
+			return clone, count, err
+		}
+	}
+
+	return clone, count, nil
+}
+
+// descendants returns the non deleted descendants, level by level
+func (c *Copier) descendants(m *SqlNodeManager, node *Node) [][]*Node {
+	levels := make([][]*Node, 0)
+	parents := []string{node.Uuid.CleanString()}
+
+	for len(parents) > 0 {
+		query := m.SelectBuilder(NewSelectOptions()).
+			Where(sq.Eq{"parent_uuid": parents}).
+			Where("deleted = ?", false).
+			OrderBy("weight ASC", "id ASC")
+
+		level := make([]*Node, 0)
+		parents = make([]string, 0)
+
+		for e := m.FindBy(query, 0, 1<<20).Front(); e != nil; e = e.Next() {
+			n := e.Value.(*Node)
+
+			level = append(level, n)
+			parents = append(parents, n.Uuid.CleanString())
+		}
+
+		if len(level) > 0 {
+			levels = append(levels, level)
+		}
+	}
+
+	return levels
+}
+
+func (c *Copier) copy(m *SqlNodeManager, source, parent *Node, binaries map[string]*Node) (*Node, error) {
+	clone := *source
+
+	now := time.Now()
+
+	clone.Id = 0
+	clone.Uuid = GetEmptyReference()
+	clone.Source = source.Uuid
+	clone.Revision = 1
+	clone.Deleted = false
+	clone.CreatedAt = now
+	clone.UpdatedAt = now
+	clone.ParentUuid = GetEmptyReference()
+	clone.Parents = make([]Reference, 0)
+
+	path := ""
+	if parent != nil {
+		clone.ParentUuid = parent.Uuid
+		clone.Parents = append(append(clone.Parents, parent.Parents...), parent.Uuid)
+		path = parent.Path
+	}
+
+	slug, err := m.UniqueSlug(clone.ParentUuid, clone.Uuid, source.Slug)
+
+	if err != nil {
+		return nil, err
+	}
+
+	clone.Slug = slug
+	clone.Path = path + "/" + slug
+
+	if _, err := m.Save(&clone, false); err != nil {
+		return nil, err
+	}
+
+	if _, ok := m.Handlers.Get(source).(StoreStreamNodeHandler); ok && c.Vault != nil && c.Vault.Has(source.UniqueId()) {
+		binaries[source.UniqueId()] = &clone
+	}
+
+	if c.Logger != nil {
+		c.Logger.WithFields(log.Fields{
+			"type":   source.Type,
+			"source": source.Uuid,
+			"uuid":   clone.Uuid,
+			"module": "node.copy",
+		}).Debug("copy node")
+	}
+
+	return &clone, nil
+}
+
+func (c *Copier) copyBinary(name string, clone *Node) error {
+	r, w := io.Pipe()
+
+	go func() {
+		_, err := c.Vault.Get(name, w)
+
+		w.CloseWithError(err)
+	}()
+
+	defer r.Close()
+
+	_, err := c.Vault.Put(clone.UniqueId(), GetVaultMetadata(clone), r)
+
+	return err
+}
@@ -0,0 +1,81 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rande/gonode/core/vault"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Copier_Copy(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	v := &vault.Vault{
+		Algo:   "no_op",
+		Driver: &vault.DriverFs{Root: t.TempDir()},
+	}
+
+	copier := &Copier{Manager: m, Vault: v}
+
+	nodes := make(map[string]*Node)
+	for _, slug := range []string{"site", "page", "child", "target"} {
+		nodes[slug] = newCachedUser(m, slug)
+	}
+
+	m.Move(nodes["page"].Uuid, nodes["site"].Uuid)
+	m.Move(nodes["child"].Uuid, nodes["page"].Uuid)
+
+	for slug, node := range nodes {
+		nodes[slug] = m.Find(node.Uuid)
+	}
+
+	// the user handler implements StoreStreamNodeHandler
+	_, err := v.Put(nodes["child"].UniqueId(), GetVaultMetadata(nodes["child"]), strings.NewReader("binary"))
+	assert.NoError(t, err)
+
+	// copy next to the original, the slug is already used
+	clone, count, err := copier.Copy(nodes["page"], nodes["site"], true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.NotEqual(t, nodes["page"].Uuid, clone.Uuid)
+	assert.Equal(t, nodes["page"].Uuid, clone.Source)
+	assert.Equal(t, "page-1", clone.Slug)
+	assert.Equal(t, "/site/page-1", clone.Path)
+	assert.Equal(t, []Reference{nodes["site"].Uuid}, clone.Parents)
+
+	query := m.SelectBuilder(NewSelectOptions()).Where("parent_uuid = ?", clone.Uuid.CleanString())
+	child := m.FindOneBy(query)
+
+	assert.Equal(t, nodes["child"].Uuid, child.Source)
+	assert.Equal(t, "/site/page-1/child", child.Path)
+	assert.Equal(t, []Reference{nodes["site"].Uuid, clone.Uuid}, child.Parents)
+
+	b := bytes.NewBuffer([]byte{})
+	_, err = v.Get(child.UniqueId(), b)
+	assert.NoError(t, err)
+	assert.Equal(t, "binary", b.String())
+
+	// copy the page inside its own subtree
+	clone, count, err = copier.Copy(nodes["page"], nodes["child"], true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "/site/page/child/page/child", m.FindOneBy(m.SelectBuilder(NewSelectOptions()).Where("parent_uuid = ?", clone.Uuid.CleanString())).Path)
+
+	// copy at the root level without the children
+	clone, count, err = copier.Copy(nodes["page"], nil, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "/page", clone.Path)
+	assert.Equal(t, 0, len(clone.Parents))
+}
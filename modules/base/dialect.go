@@ -0,0 +1,248 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/rande/gonode/core/config"
+	"github.com/rande/gonode/core/squirrel"
+)
+
+// Dialect builds the SQL fragments which are specific to a database, the fragments take
+// SQL expressions (column, placeholder, ...) as arguments.
+type Dialect interface {
+	// Name returns the database/sql driver's name
+	Name() string
+
+	// ArrayValue converts the values into the value stored in an array column
+	ArrayValue(values []string) interface{}
+
+	// ArrayContains returns a predicate true if the array contains the value
+	ArrayContains(array, value string) string
+
+	// ArrayOverlap returns a predicate true if the array contains one of the count placeholders
+	ArrayOverlap(array string, count int) string
+
+	// JsonField returns the expression to access a JSON field, the path is the list of keys
+	JsonField(column string, path []string, text bool) string
+
+	// JsonContainsAny returns a predicate true if the JSON array contains one of the count
+	// placeholders
+	JsonContainsAny(column string, path []string, count int) string
+
+	// Notify sends the payload to the channel's subscribers
+	Notify(db *sql.DB, channel, payload string) error
+
+	// SkipLocked returns the clause of a SELECT locking the selected rows, the rows locked by
+	// another transaction are skipped
+	SkipLocked() string
+
+	// LockTable returns the statement locking the table against the other writers until the
+	// end of the transaction, the readers are not blocked. An empty statement is returned if
+	// the database already serializes the writes.
+	LockTable(table string) string
+
+	// TreeQuery returns the statement computing the parents and the path of the parent ($1),
+	// of the moved node ($2) and of its descendants, the parent's other children are not updated
+	TreeQuery(table string) string
+
+	// UniqueViolation returns true if the error is raised by the unique constraint, the column
+	// (table.column) identifies the constraint if the database does not report its name
+	UniqueViolation(err error, constraint, column string) bool
+}
+
+// NewDialect returns the dialect of the driver, the subscriber is used by the databases
+// without LISTEN/NOTIFY support.
+func NewDialect(driver string, subscriber *Subscriber) Dialect {
+	if driver == config.DATABASE_SQLITE {
+		return &SqliteDialect{
+			Subscriber: subscriber,
+		}
+	}
+
+	return &PgDialect{}
+}
+
+type PgDialect struct{}
+
+func (d *PgDialect) Name() string {
+	return config.DATABASE_POSTGRES
+}
+
+func (d *PgDialect) ArrayValue(values []string) interface{} {
+	return squirrel.StringSlice(values)
+}
+
+func (d *PgDialect) ArrayContains(array, value string) string {
+	return fmt.Sprintf("%s = ANY(%s)", value, array)
+}
+
+func (d *PgDialect) ArrayOverlap(array string, count int) string {
+	return fmt.Sprintf("%s && ARRAY[%s]", array, sq.Placeholders(count))
+}
+
+func (d *PgDialect) JsonField(column string, path []string, text bool) string {
+	c := column
+	for i, key := range path {
+		if text && i == len(path)-1 {
+			c += fmt.Sprintf("->>'%s'", key)
+		} else {
+			c += fmt.Sprintf("->'%s'", key)
+		}
+	}
+
+	return c
+}
+
+func (d *PgDialect) JsonContainsAny(column string, path []string, count int) string {
+	return fmt.Sprintf("%s ??| ARRAY[%s]", d.JsonField(column, path, false), sq.Placeholders(count))
+}
+
+func (d *PgDialect) Notify(db *sql.DB, channel, payload string) error {
+	_, err := db.Exec(fmt.Sprintf("NOTIFY %s, '%s'", channel, strings.Replace(payload, "'", "''", -1)))
+
+	return err
+}
+
+func (d *PgDialect) SkipLocked() string {
+	return "FOR UPDATE SKIP LOCKED"
+}
+
+func (d *PgDialect) LockTable(table string) string {
+	return fmt.Sprintf(`LOCK TABLE "%s" IN EXCLUSIVE MODE`, table)
+}
+
+func (d *PgDialect) UniqueViolation(err error, constraint, column string) bool {
+	e, ok := err.(*pq.Error)
+
+	return ok && e.Code == "23505" && e.Constraint == constraint
+}
+
+func (d *PgDialect) TreeQuery(table string) string {
+	return fmt.Sprintf(`WITH RECURSIVE  r AS (
+				SELECT uuid, parent_uuid, parents,
+					CASE	WHEN type = 'core.root' THEN ''
+						WHEN array_length(parents, 1)>0 THEN path
+						ELSE '/' || slug::varchar(2000)
+					END as path
+				FROM %s r
+				WHERE uuid = $1::uuid
+			UNION ALL
+				SELECT c.uuid, c.parent_uuid, array_append(r.parents, c.parent_uuid) AS parents, r.path || '/' || c.slug as path
+				FROM %s c
+				JOIN r ON c.parent_uuid = r.uuid
+				WHERE r.uuid <> $1::uuid OR c.uuid = $2::uuid
+		)
+		UPDATE %s n SET parents = r.parents, path = r.path FROM r WHERE r.uuid = n.uuid`, table, table, table)
+}
+
+// SqliteDialect stores the arrays as JSON arrays, and dispatches the notifications to the
+// in-process subscriber.
+type SqliteDialect struct {
+	Subscriber *Subscriber
+}
+
+func (d *SqliteDialect) Name() string {
+	return config.DATABASE_SQLITE
+}
+
+func (d *SqliteDialect) ArrayValue(values []string) interface{} {
+	if values == nil {
+		values = []string{}
+	}
+
+	data, _ := json.Marshal(values)
+
+	return string(data)
+}
+
+func (d *SqliteDialect) ArrayContains(array, value string) string {
+	return fmt.Sprintf("EXISTS(SELECT 1 FROM json_each(%s) WHERE json_each.value = %s)", array, value)
+}
+
+func (d *SqliteDialect) ArrayOverlap(array string, count int) string {
+	return fmt.Sprintf("EXISTS(SELECT 1 FROM json_each(%s) WHERE json_each.value IN (%s))", array, sq.Placeholders(count))
+}
+
+func (d *SqliteDialect) JsonField(column string, path []string, text bool) string {
+	if len(path) == 0 {
+		return column
+	}
+
+	if text {
+		return fmt.Sprintf("CAST(%s->>'%s' AS TEXT)", column, d.path(path))
+	}
+
+	return fmt.Sprintf("json_extract(%s, '%s')", column, d.path(path))
+}
+
+func (d *SqliteDialect) JsonContainsAny(column string, path []string, count int) string {
+	return fmt.Sprintf("EXISTS(SELECT 1 FROM json_each(%s, '%s') WHERE json_each.value IN (%s))", column, d.path(path), sq.Placeholders(count))
+}
+
+func (d *SqliteDialect) path(path []string) string {
+	p := "$"
+	for _, key := range path {
+		p += fmt.Sprintf(".%s", strings.Replace(key, "'", "''", -1))
+	}
+
+	return p
+}
+
+func (d *SqliteDialect) Notify(db *sql.DB, channel, payload string) error {
+	if d.Subscriber != nil {
+		d.Subscriber.Publish(channel, payload)
+	}
+
+	return nil
+}
+
+// SkipLocked returns an empty clause, SQLite has a single writer so an UPDATE ... WHERE id = (SELECT ...)
+// statement is already atomic
+func (d *SqliteDialect) SkipLocked() string {
+	return ""
+}
+
+// LockTable returns an empty statement, SQLite has a single writer
+func (d *SqliteDialect) LockTable(table string) string {
+	return ""
+}
+
+// UniqueViolation checks the error's message, SQLite reports the columns of the constraint:
+// "UNIQUE constraint failed: table.column, ..."
+func (d *SqliteDialect) UniqueViolation(err error, constraint, column string) bool {
+	if err == nil {
+		return false
+	}
+
+	message := err.Error()
+
+	return strings.Contains(message, "UNIQUE constraint failed:") && strings.Contains(message, column)
+}
+
+func (d *SqliteDialect) TreeQuery(table string) string {
+	return fmt.Sprintf(`WITH RECURSIVE  r AS (
+				SELECT uuid, parent_uuid, parents,
+					CASE	WHEN type = 'core.root' THEN ''
+						WHEN json_array_length(parents)>0 THEN path
+						ELSE '/' || slug
+					END as path
+				FROM %s
+				WHERE uuid = $1
+			UNION ALL
+				SELECT c.uuid, c.parent_uuid, json_insert(r.parents, '$[#]', c.parent_uuid) AS parents, r.path || '/' || c.slug as path
+				FROM %s c
+				JOIN r ON c.parent_uuid = r.uuid
+				WHERE r.uuid <> $1 OR c.uuid = $2
+		)
+		UPDATE %s SET parents = r.parents, path = r.path FROM r WHERE r.uuid = %s.uuid`, table, table, table, table)
+}
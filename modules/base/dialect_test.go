@@ -0,0 +1,160 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/rande/gonode/core/config"
+	"github.com/rande/gonode/core/migration"
+	"github.com/rande/gonode/core/squirrel"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func Test_NewDialect(t *testing.T) {
+	assert.IsType(t, &PgDialect{}, NewDialect(config.DATABASE_POSTGRES, nil))
+	assert.IsType(t, &SqliteDialect{}, NewDialect(config.DATABASE_SQLITE, nil))
+}
+
+func Test_PgDialect_Fragments(t *testing.T) {
+	d := &PgDialect{}
+
+	assert.Equal(t, squirrel.StringSlice{"a", "b"}, d.ArrayValue([]string{"a", "b"}))
+	assert.Equal(t, "? = ANY(parents)", d.ArrayContains("parents", "?"))
+	assert.Equal(t, `"access" && ARRAY[?,?]`, d.ArrayOverlap(`"access"`, 2))
+	assert.Equal(t, "data->'tags'->>'name'", d.JsonField("data", []string{"tags", "name"}, true))
+	assert.Equal(t, "data->'name'", d.JsonField("data", []string{"name"}, false))
+	assert.Equal(t, "data->'tags' ??| ARRAY[?,?]", d.JsonContainsAny("data", []string{"tags"}, 2))
+	assert.Equal(t, "FOR UPDATE SKIP LOCKED", d.SkipLocked())
+}
+
+func Test_SqliteDialect_Fragments(t *testing.T) {
+	d := &SqliteDialect{}
+
+	assert.Equal(t, `["a","b"]`, d.ArrayValue([]string{"a", "b"}))
+	assert.Equal(t, `[]`, d.ArrayValue(nil))
+	assert.Equal(t, "EXISTS(SELECT 1 FROM json_each(parents) WHERE json_each.value = ?)", d.ArrayContains("parents", "?"))
+	assert.Equal(t, `EXISTS(SELECT 1 FROM json_each("access") WHERE json_each.value IN (?,?))`, d.ArrayOverlap(`"access"`, 2))
+	assert.Equal(t, "CAST(data->>'$.tags.name' AS TEXT)", d.JsonField("data", []string{"tags", "name"}, true))
+	assert.Equal(t, "json_extract(data, '$.name')", d.JsonField("data", []string{"name"}, false))
+	assert.Equal(t, "created_at", d.JsonField("created_at", []string{}, false))
+	assert.Equal(t, "EXISTS(SELECT 1 FROM json_each(data, '$.tags') WHERE json_each.value IN (?,?))", d.JsonContainsAny("data", []string{"tags"}, 2))
+	assert.Equal(t, "", d.SkipLocked())
+}
+
+func Test_SqliteDialect_Notify(t *testing.T) {
+	s := NewLocalSubscriber(log.New())
+
+	received := make(chan string, 1)
+
+	s.ListenMessage("test_channel", func(notification *pq.Notification) (int, error) {
+		received <- notification.Extra
+
+		return PubSubListenContinue, nil
+	})
+
+	d := &SqliteDialect{Subscriber: s}
+
+	// not registered, the notification is lost
+	assert.NoError(t, d.Notify(nil, "test_channel", "lost"))
+
+	s.Register()
+
+	assert.NoError(t, d.Notify(nil, "test_channel", "payload"))
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, "payload", payload)
+	case <-time.After(time.Second):
+		assert.Fail(t, "the notification has not been dispatched")
+	}
+
+	s.Stop()
+}
+
+// getSqliteManager returns a manager using an in-memory SQLite database
+func getSqliteManager(t *testing.T) (*SqlNodeManager, func()) {
+	db, err := sql.Open("sqlite", ":memory:")
+	assert.NoError(t, err)
+
+	// each connection opens a new memory database
+	db.SetMaxOpenConns(1)
+
+	migrator := migration.NewMigrator(db, "test", nil)
+	migrator.Driver = config.DATABASE_SQLITE
+
+	for _, m := range append(GetMigrations(), GetSqliteMigrations()...) {
+		assert.NoError(t, migrator.Add(m))
+	}
+
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	m := &SqlNodeManager{
+		Handlers: HandlerCollection{
+			"node.user": &UserHandler{},
+		},
+		Db:      db,
+		Prefix:  "test",
+		Dialect: &SqliteDialect{},
+	}
+
+	return m, func() {
+		assert.NoError(t, migrator.Reset())
+
+		db.Close()
+	}
+}
+
+func Test_SqliteDialect_Manager(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	handlers := m.Handlers
+
+	parent := handlers.NewNode("node.user")
+	parent.Name = "Parent"
+	parent.Slug = "parent"
+	parent.Access = []string{"node:read"}
+	m.Save(parent, false)
+
+	child := handlers.NewNode("node.user")
+	child.Name = "Child"
+	child.Slug = "child"
+	child.Data.(*User).Username = "thomas"
+	m.Save(child, false)
+
+	affected, err := m.Move(child.Uuid, parent.Uuid)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	child = m.Find(child.Uuid)
+
+	assert.Equal(t, "thomas", child.Data.(*User).Username)
+	assert.Equal(t, []Reference{parent.Uuid}, child.Parents)
+	assert.Equal(t, "/parent/child", child.Path)
+
+	query := m.SelectBuilder(NewSelectOptions()).
+		Where(m.dialect().ArrayContains("parents", "?"), parent.Uuid.CleanString())
+
+	assert.Equal(t, 1, m.FindBy(query, 0, 10).Len())
+
+	query = m.SelectBuilder(NewSelectOptions()).
+		Where(squirrel.NewExprSlice(m.dialect().ArrayOverlap("access", 2), []string{"node:read", "node:write"}))
+
+	assert.Equal(t, parent.Uuid, m.FindOneBy(query).Uuid)
+
+	query = m.SelectBuilder(NewSelectOptions()).
+		Where(sq.Expr(m.dialect().JsonField("data", []string{"username"}, true)+" = ?", "thomas"))
+
+	assert.Equal(t, child.Uuid, m.FindOneBy(query).Uuid)
+}
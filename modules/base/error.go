@@ -7,7 +7,10 @@ package base
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/rande/gonode/core/helper"
 	"github.com/rande/gonode/core/security"
@@ -19,6 +22,13 @@ var (
 	ErrNotFound               = errors.New("unable to find the node")
 	ErrInvalidReferenceFormat = errors.New("unable to parse the reference")
 	ErrAlreadyDeleted         = errors.New("unable to find the node")
+	ErrNotDeleted             = errors.New("the node is not deleted")
+	ErrReferenced             = errors.New("the node is referenced by other nodes")
+	ErrHasChildren            = errors.New("the node has children")
+	ErrInvalidTransition      = errors.New("the status transition is not allowed")
+	ErrInvalidOrder           = errors.New("the nodes must be distinct children of the parent")
+	ErrLocked                 = errors.New("the node is locked by another user")
+	ErrJobLost                = errors.New("the job's claim has expired")
 	ErrNoStreamHandler        = errors.New("no stream handler defined")
 	ErrAccessForbidden        = errors.New("access forbidden")
 	ErrInvalidVersion         = errors.New("wrong node version")
@@ -109,6 +119,25 @@ func (es Errors) GetError(field string) []string {
 	return es[field]
 }
 
+// Error makes the validation errors usable as an error, ie: the errors returned by Save
+func (es Errors) Error() string {
+	fields := make([]string, 0)
+	for field := range es {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	messages := make([]string, 0)
+	for _, field := range fields {
+		for _, message := range es[field] {
+			messages = append(messages, fmt.Sprintf("%s: %s", field, message))
+		}
+	}
+
+	return fmt.Sprintf("%s, %s", ErrValidation.Error(), strings.Join(messages, ", "))
+}
+
 func (es Errors) HasErrors() bool {
 
 	for _, errors := range es {
@@ -125,6 +154,14 @@ func HandleError(req *http.Request, res http.ResponseWriter, err error) {
 		return
 	}
 
+	if es, ok := err.(Errors); ok {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusPreconditionFailed)
+		Serialize(res, es)
+
+		return
+	}
+
 	statusCode := http.StatusInternalServerError
 
 	switch err {
@@ -134,12 +171,14 @@ func HandleError(req *http.Request, res http.ResponseWriter, err error) {
 		statusCode = http.StatusGone
 	case ErrAccessForbidden, security.ErrAccessForbidden:
 		statusCode = http.StatusForbidden
-	case ErrRevision:
+	case ErrRevision, ErrReferenced:
 		statusCode = http.StatusConflict
-	case ErrValidation:
+	case ErrValidation, ErrNotDeleted, ErrInvalidTransition, ErrInvalidOrder:
 		statusCode = http.StatusPreconditionFailed
 	case ErrInvalidVersion:
 		statusCode = http.StatusBadRequest
+	case ErrLocked:
+		statusCode = http.StatusLocked
 	}
 
 	helper.SendWithHttpCode(res, statusCode, err.Error())
@@ -0,0 +1,177 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rande/gonode/core/security"
+)
+
+// FieldRoles are the roles required to read or to write a node's field, the user needs one of
+// the listed roles. An empty list does not restrict the access.
+type FieldRoles struct {
+	Read    []string
+	Write   []string
+	Private bool // the field is never serialized, and cannot be written with the serializer
+}
+
+// FieldAccess indexes the roles by the field's JSON path, ie: "data.email" or "meta.password_cost"
+type FieldAccess map[string]*FieldRoles
+
+// FieldAccessNodeHandler declares the roles required to read or to write the node's fields, the
+// Serializer strips the fields the SecurityToken cannot read and ignores the fields it cannot write.
+type FieldAccessNodeHandler interface {
+	GetFieldAccess() FieldAccess
+}
+
+// UnreadableFields returns the fields of the handler's nodes the token cannot read, only the
+// private fields are returned if the token is nil.
+func UnreadableFields(handler Handler, token security.SecurityToken) []string {
+	return deniedFields(handler, token, false)
+}
+
+// UnwritableFields returns the fields of the handler's nodes the token cannot write, only the
+// private fields are returned if the token is nil.
+func UnwritableFields(handler Handler, token security.SecurityToken) []string {
+	return deniedFields(handler, token, true)
+}
+
+func deniedFields(handler Handler, token security.SecurityToken, write bool) []string {
+	fields := make([]string, 0)
+
+	h, ok := handler.(FieldAccessNodeHandler)
+
+	if !ok {
+		return fields
+	}
+
+	for field, roles := range h.GetFieldAccess() {
+		required := roles.Read
+		if write {
+			required = roles.Write
+		}
+
+		if roles.Private || (token != nil && len(required) > 0 && !hasOneRole(token.GetRoles(), required)) {
+			fields = append(fields, field)
+		}
+	}
+
+	sort.Strings(fields)
+
+	return fields
+}
+
+func hasOneRole(roles, required []string) bool {
+	for _, role := range roles {
+		for _, r := range required {
+			if role == r {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// removeFields deletes the fields from a decoded JSON document
+func removeFields(document map[string]interface{}, fields []string) {
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		current := document
+
+		for i, name := range path {
+			if i == len(path)-1 {
+				delete(current, name)
+
+				break
+			}
+
+			next, ok := current[name].(map[string]interface{})
+
+			if !ok {
+				break
+			}
+
+			current = next
+		}
+	}
+}
+
+// CopyFields sets the node's fields with the values of the source node, the fields are reset to
+// their zero values if source is nil. The unknown fields are ignored.
+func CopyFields(node, source *Node, fields []string) {
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+
+		to, ok := fieldByPath(reflect.ValueOf(node), path)
+
+		if !ok || !to.CanSet() {
+			continue
+		}
+
+		if source == nil {
+			to.Set(reflect.Zero(to.Type()))
+
+			continue
+		}
+
+		if from, ok := fieldByPath(reflect.ValueOf(source), path); ok && from.Type() == to.Type() {
+			to.Set(from)
+		}
+	}
+}
+
+// fieldByPath returns the struct's field matching the path, the path's elements are the fields'
+// JSON names
+func fieldByPath(v reflect.Value, path []string) (reflect.Value, bool) {
+	for _, name := range path {
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+
+		found := false
+
+		for i := 0; i < v.NumField(); i++ {
+			if jsonName(v.Type().Field(i)) == name {
+				v = v.Field(i)
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+
+	return v, true
+}
+
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+
+	if tag == "-" {
+		return ""
+	}
+
+	if n := strings.Split(tag, ",")[0]; n != "" {
+		return n
+	}
+
+	return field.Name
+}
@@ -0,0 +1,123 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rande/gonode/core/security"
+	"github.com/stretchr/testify/assert"
+)
+
+type FieldAccessHandler struct {
+	UserHandler
+}
+
+func (h *FieldAccessHandler) GetFieldAccess() FieldAccess {
+	return FieldAccess{
+		"data.password": {Private: true},
+		"data.username": {Read: []string{"ROLE_ADMIN"}, Write: []string{"ROLE_ADMIN"}},
+		"name":          {Write: []string{"ROLE_ADMIN"}},
+	}
+}
+
+func getFieldAccessSerializer() *Serializer {
+	s := NewSerializer()
+	s.Handlers = HandlerCollection{
+		"default":   &UserHandler{},
+		"node.user": &FieldAccessHandler{},
+	}
+
+	return s
+}
+
+func Test_FieldAccess_Denied_Fields(t *testing.T) {
+	h := &FieldAccessHandler{}
+	user := &security.DefaultSecurityToken{Roles: []string{"ROLE_API"}}
+	admin := &security.DefaultSecurityToken{Roles: []string{"ROLE_API", "ROLE_ADMIN"}}
+
+	assert.Equal(t, []string{"data.password"}, UnreadableFields(h, nil))
+	assert.Equal(t, []string{"data.password", "data.username"}, UnreadableFields(h, user))
+	assert.Equal(t, []string{"data.password"}, UnreadableFields(h, admin))
+
+	assert.Equal(t, []string{"data.password", "data.username", "name"}, UnwritableFields(h, user))
+	assert.Equal(t, []string{"data.password"}, UnwritableFields(h, admin))
+
+	assert.Equal(t, []string{}, UnreadableFields(&UserHandler{}, user))
+}
+
+func Test_FieldAccess_Serialize(t *testing.T) {
+	s := getFieldAccessSerializer()
+
+	node := s.Handlers.NewNode("node.user")
+	node.Name = "Thomas"
+	node.Data.(*User).Username = "thomas"
+	node.Data.(*User).Password = "{bcrypt}hash"
+
+	document := func(s *Serializer) map[string]interface{} {
+		b := bytes.NewBuffer([]byte{})
+		assert.NoError(t, s.Serialize(b, node))
+
+		v := make(map[string]interface{})
+		json.Unmarshal(b.Bytes(), &v)
+
+		return v
+	}
+
+	data := document(s)["data"].(map[string]interface{})
+	assert.NotContains(t, data, "password")
+	assert.Equal(t, "thomas", data["username"])
+
+	v := document(s.WithToken(&security.DefaultSecurityToken{Roles: []string{"ROLE_API"}}))
+	assert.Equal(t, "Thomas", v["name"])
+
+	data = v["data"].(map[string]interface{})
+	assert.NotContains(t, data, "password")
+	assert.NotContains(t, data, "username")
+
+	// the node is not altered
+	assert.Equal(t, "{bcrypt}hash", node.Data.(*User).Password)
+}
+
+func Test_FieldAccess_Deserialize(t *testing.T) {
+	s := getFieldAccessSerializer().WithToken(&security.DefaultSecurityToken{Roles: []string{"ROLE_API"}})
+
+	node := NewNode()
+	err := s.Deserialize(strings.NewReader(`{"type": "node.user", "name": "Thomas", "data": {"name": "Thomas", "username": "thomas", "password": "{plain}hack"}}`), node)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "node.user", node.Type)
+	assert.Equal(t, "", node.Name)
+	assert.Equal(t, "Thomas", node.Data.(*User).Name)
+	assert.Equal(t, "", node.Data.(*User).Username)
+	assert.Equal(t, "", node.Data.(*User).Password)
+}
+
+func Test_FieldAccess_CopyFields(t *testing.T) {
+	c := HandlerCollection{"node.user": &FieldAccessHandler{}}
+
+	saved := c.NewNode("node.user")
+	saved.Name = "Thomas"
+	saved.Data.(*User).Password = "{bcrypt}hash"
+
+	node := c.NewNode("node.user")
+	node.Name = "Hacker"
+	node.Data.(*User).Name = "Thomas Rabaix"
+	node.Data.(*User).Password = "{plain}hack"
+
+	CopyFields(node, saved, []string{"name", "data.password", "data.unknown", "unknown.field"})
+
+	assert.Equal(t, "Thomas", node.Name)
+	assert.Equal(t, "{bcrypt}hash", node.Data.(*User).Password)
+	assert.Equal(t, "Thomas Rabaix", node.Data.(*User).Name)
+
+	CopyFields(node, nil, []string{"data.password"})
+
+	assert.Equal(t, "", node.Data.(*User).Password)
+}
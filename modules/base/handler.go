@@ -134,6 +134,12 @@ type ValidateNodeHandler interface {
 	Validate(node *Node, m NodeManager, e Errors)
 }
 
+// RelationNodeHandler declares the data's fields storing references to other nodes, a field
+// can be a Reference or a []Reference. The relations are stored in the nodes_relations table.
+type RelationNodeHandler interface {
+	GetReferenceFields() []string
+}
+
 type LoadNodeHandler interface {
 	Load(data []byte, meta []byte, node *Node) error
 }
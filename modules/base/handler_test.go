@@ -17,7 +17,7 @@ func Test_Manager_Validate(t *testing.T) {
 		"node.user": &UserHandler{},
 	}
 
-	m := &PgNodeManager{
+	m := &SqlNodeManager{
 		Handlers: c,
 	}
 
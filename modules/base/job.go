@@ -0,0 +1,728 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/rande/gonode/core/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Job is a unit of background work stored in the <prefix>_nodes_jobs table, the status is one
+// of the ProcessStatus values:
+//   - ProcessStatusReady: the job waits for a worker, RunAt is the date of the next attempt
+//   - ProcessStatusUpdate: the job is handled by a worker until LockedUntil
+//   - ProcessStatusDone: the job is done
+//   - ProcessStatusError: the job failed MaxAttempts times, it is kept as a dead letter
+type Job struct {
+	Id          int64      `json:"id"`
+	Queue       string     `json:"queue"`
+	Payload     string     `json:"payload"`
+	Status      int        `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	Error       string     `json:"error"`
+	RunAt       time.Time  `json:"run_at"`
+	LockedUntil *time.Time `json:"locked_until"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// JobHandler processes a job, the job is retried if an error is returned
+type JobHandler func(job *Job) error
+
+// JobOptions configures the workers of a queue
+type JobOptions struct {
+	Workers     int           // number of jobs handled at the same time by the instance
+	MaxAttempts int           // the job is dead-lettered after this number of attempts
+	Backoff     time.Duration // delay before the first retry, doubled on each retry
+	Timeout     time.Duration // duration of a claim, extended while the handler runs, a job claimed by a crashed worker is handled again after it
+}
+
+func DefaultJobOptions() *JobOptions {
+	return &JobOptions{
+		Workers:     1,
+		MaxAttempts: 5,
+		Backoff:     10 * time.Second,
+		Timeout:     5 * time.Minute,
+	}
+}
+
+// NewJobOptionsFromConfig returns the options of a queue, the default options are used for
+// the missing values
+func NewJobOptionsFromConfig(conf *config.JobQueue) (*JobOptions, error) {
+	options := DefaultJobOptions()
+
+	if conf.Workers > 0 {
+		options.Workers = conf.Workers
+	}
+
+	if conf.MaxAttempts > 0 {
+		options.MaxAttempts = conf.MaxAttempts
+	}
+
+	var err error
+
+	if conf.Backoff != "" {
+		if options.Backoff, err = time.ParseDuration(conf.Backoff); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Timeout != "" {
+		if options.Timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, err
+		}
+	}
+
+	return options, nil
+}
+
+// JobQuery filters the jobs, the empty fields are ignored
+type JobQuery struct {
+	Queue  string
+	Status *int
+}
+
+// JobStats counts the jobs of a queue by status
+type JobStats struct {
+	Ready   int `json:"ready"`
+	Running int `json:"running"`
+	Done    int `json:"done"`
+	Dead    int `json:"dead"`
+}
+
+type jobWorkers struct {
+	name    string
+	handler JobHandler
+	wake    chan struct{}
+}
+
+func (w *jobWorkers) wakeUp() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// NewJobQueue returns a queue with the default options and a one second poll interval
+func NewJobQueue(manager *SqlNodeManager, logger *log.Logger) *JobQueue {
+	return &JobQueue{
+		Manager: manager,
+		Logger:  logger,
+		Poll:    time.Second,
+		Options: make(map[string]*JobOptions),
+		queues:  make(map[string]*jobWorkers),
+	}
+}
+
+// JobQueue stores the jobs in the database, the jobs are claimed with SELECT ... FOR UPDATE SKIP
+// LOCKED so the workers of several instances can share the queues. Each queue has its own pool of
+// workers, the workers are woken up by a notification on the <prefix>_nodes_jobs channel and check
+// the queue every Poll interval.
+type JobQueue struct {
+	Manager *SqlNodeManager
+	Logger  *log.Logger
+	Poll    time.Duration
+	Options map[string]*JobOptions // the options by queue's name, DefaultJobOptions is used if not set
+
+	queues  map[string]*jobWorkers
+	lock    sync.Mutex
+	done    chan struct{}
+	running sync.WaitGroup
+}
+
+func (q *JobQueue) table() string {
+	return q.Manager.Prefix + "_nodes_jobs"
+}
+
+// Channel is the notification channel used to wake up the workers, the payload is the queue's name
+func (q *JobQueue) Channel() string {
+	return q.table()
+}
+
+func (q *JobQueue) options(name string) *JobOptions {
+	if o, ok := q.Options[name]; ok {
+		return o
+	}
+
+	return DefaultJobOptions()
+}
+
+// Handle registers the handler of the queue, the workers are started with Start
+func (q *JobQueue) Handle(name string, handler JobHandler) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.queues[name] = &jobWorkers{
+		name:    name,
+		handler: handler,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Enqueue stores a job, the job is handled as soon as a worker of the queue is available
+func (q *JobQueue) Enqueue(name, payload string) (*Job, error) {
+	job, err := q.insert(q.Manager.Db, name, payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	q.Notify(name)
+
+	return job, nil
+}
+
+// EnqueueTx stores a job with the manager's transaction, so the job is only created if the
+// transaction is committed. The workers are not woken up: call Notify once committed.
+func (q *JobQueue) EnqueueTx(tx NodeManager, name, payload string) (*Job, error) {
+	return q.insert(tx.(*SqlNodeManager).Runner(), name, payload)
+}
+
+// Notify wakes up the workers of the queue, the workers of the other instances are notified
+// with the queue's Channel
+func (q *JobQueue) Notify(name string) {
+	q.wakeUp(name)
+
+	if err := q.Manager.dialect().Notify(q.Manager.Db, q.Channel(), name); err != nil && q.Logger != nil {
+		q.Logger.WithFields(log.Fields{
+			"module": "node.jobs",
+			"queue":  name,
+			"error":  err.Error(),
+		}).Warn("unable to notify the workers")
+	}
+}
+
+func (q *JobQueue) insert(runner sq.BaseRunner, name, payload string) (*Job, error) {
+	now := time.Now()
+
+	job := &Job{
+		Queue:       name,
+		Payload:     payload,
+		Status:      ProcessStatusReady,
+		MaxAttempts: q.options(name).MaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err := sq.Insert(q.table()).
+		Columns("queue", "payload", "status", "attempts", "max_attempts", "error", "run_at", "created_at", "updated_at").
+		Values(job.Queue, job.Payload, job.Status, 0, job.MaxAttempts, "", job.RunAt, job.CreatedAt, job.UpdatedAt).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(runner).
+		QueryRow().
+		Scan(&job.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Enqueuer returns a subscriber's handler storing the notification's payload as a job, so a
+// listener's work is done by the queue's workers instead of the notification loop
+func (q *JobQueue) Enqueuer(name string) SubscriberHander {
+	return func(notification *pq.Notification) (int, error) {
+		_, err := q.Enqueue(name, notification.Extra)
+
+		return PubSubListenContinue, err
+	}
+}
+
+// ListenerJob runs a Listener as a job handler, the listener is called with the job's payload
+// as the notification's payload and the job is retried if the listener returns an error
+func ListenerJob(listener Listener, manager NodeManager) JobHandler {
+	return func(job *Job) error {
+		_, err := listener.Handle(&pq.Notification{Channel: job.Queue, Extra: job.Payload}, manager)
+
+		return err
+	}
+}
+
+// Claim locks the next job of the queue for the options' timeout, nil is returned if no job is
+// ready. A job whose claim has expired (ie, the worker crashed) can be claimed again, unless its
+// attempts are exhausted: the job is then dead-lettered.
+func (q *JobQueue) Claim(name string) (*Job, error) {
+	now := time.Now()
+	lockedUntil := now.Add(q.options(name).Timeout)
+
+	_, err := q.Manager.Db.Exec(fmt.Sprintf(`UPDATE %s SET status = $1, error = $2, locked_until = NULL, updated_at = $3
+		WHERE queue = $4 AND status = $5 AND locked_until <= $3 AND attempts >= max_attempts`, q.table()),
+		ProcessStatusError, "the claim has expired", now, name, ProcessStatusUpdate)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, attempts = attempts + 1, locked_until = $2, updated_at = $3
+		WHERE id = (
+			SELECT id FROM %s
+			WHERE queue = $4 AND ((status = $5 AND run_at <= $3) OR (status = $1 AND locked_until <= $3 AND attempts < max_attempts))
+			ORDER BY run_at ASC, id ASC
+			LIMIT 1 %s
+		)
+		RETURNING id, queue, payload, status, attempts, max_attempts, error, run_at, locked_until, created_at, updated_at`,
+		q.table(), q.table(), q.Manager.dialect().SkipLocked())
+
+	jobs, err := q.scan(q.Manager.Db.Query(query, ProcessStatusUpdate, lockedUntil, now, name, ProcessStatusReady))
+
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	return jobs[0], nil
+}
+
+// keep extends the job's claim every third of the timeout until the returned function is
+// called, so a handler running longer than the timeout is not run by another worker. The
+// renewal stops if the claim is lost.
+func (q *JobQueue) keep(job *Job) func() {
+	timeout := q.options(job.Queue).Timeout
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		if timeout <= 0 {
+			<-done
+
+			return
+		}
+
+		ticker := time.NewTicker(timeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r, err := sq.Update(q.table()).
+					Set("locked_until", time.Now().Add(timeout)).
+					Where(sq.Eq{"id": job.Id, "status": ProcessStatusUpdate, "attempts": job.Attempts}).
+					PlaceholderFormat(sq.Dollar).
+					RunWith(q.Manager.Db).
+					Exec()
+
+				if err == nil {
+					var affected int64
+					if affected, err = r.RowsAffected(); err == nil && affected == 0 {
+						err = ErrJobLost
+					}
+				}
+
+				if err != nil {
+					if q.Logger != nil {
+						q.Logger.WithFields(log.Fields{
+							"module": "node.jobs",
+							"queue":  job.Queue,
+							"job":    job.Id,
+							"error":  err.Error(),
+						}).Warn("the claim cannot be extended")
+					}
+
+					<-done
+
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// Complete marks the job as done, ErrJobLost is returned if the claim has expired and the job
+// has been claimed again
+func (q *JobQueue) Complete(job *Job) error {
+	job.Status = ProcessStatusDone
+	job.Error = ""
+
+	return q.update(job)
+}
+
+// Fail schedules the next attempt of the job with an exponential backoff, the job is
+// dead-lettered once the maximum number of attempts is reached. Like Complete, ErrJobLost is
+// returned if the job has been claimed again.
+func (q *JobQueue) Fail(job *Job, cause error) error {
+	job.Error = cause.Error()
+
+	if job.Attempts >= job.MaxAttempts {
+		job.Status = ProcessStatusError
+	} else {
+		job.Status = ProcessStatusReady
+		job.RunAt = time.Now().Add(q.options(job.Queue).Backoff << uint(job.Attempts-1))
+	}
+
+	return q.update(job)
+}
+
+func (q *JobQueue) update(job *Job) error {
+	job.LockedUntil = nil
+	job.UpdatedAt = time.Now()
+
+	// the attempts identify the claim, a new claim increments them
+	r, err := sq.Update(q.table()).
+		Set("status", job.Status).
+		Set("error", job.Error).
+		Set("run_at", job.RunAt).
+		Set("locked_until", nil).
+		Set("updated_at", job.UpdatedAt).
+		Where(sq.Eq{"id": job.Id, "status": ProcessStatusUpdate, "attempts": job.Attempts}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(q.Manager.Db).
+		Exec()
+
+	if err != nil {
+		return err
+	}
+
+	if affected, err := r.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrJobLost
+	}
+
+	return nil
+}
+
+// Retry sends a dead-lettered job back to its queue with a new set of attempts, ErrNotFound is
+// returned if the job is not dead-lettered
+func (q *JobQueue) Retry(id int64) (*Job, error) {
+	now := time.Now()
+
+	r, err := sq.Update(q.table()).
+		Set("status", ProcessStatusReady).
+		Set("attempts", 0).
+		Set("run_at", now).
+		Set("updated_at", now).
+		Where(sq.Eq{"id": id, "status": ProcessStatusError}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(q.Manager.Db).
+		Exec()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if affected, err := r.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, ErrNotFound
+	}
+
+	job, err := q.Find(id)
+
+	if err == nil {
+		q.wakeUp(job.Queue)
+	}
+
+	return job, err
+}
+
+// Find returns the job, ErrNotFound is returned if the job does not exist
+func (q *JobQueue) Find(id int64) (*Job, error) {
+	jobs, err := q.FindBy(q.SelectBuilder(&JobQuery{}).Where(sq.Eq{"id": id}), 0, 1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return jobs[0], nil
+}
+
+// SelectBuilder returns the query of the jobs matching the job query, the most recent first
+func (q *JobQueue) SelectBuilder(query *JobQuery) sq.SelectBuilder {
+	s := sq.Select("id", "queue", "payload", "status", "attempts", "max_attempts", "error", "run_at", "locked_until", "created_at", "updated_at").
+		From(q.table()).
+		OrderBy("id DESC")
+
+	if query.Queue != "" {
+		s = s.Where(sq.Eq{"queue": query.Queue})
+	}
+
+	if query.Status != nil {
+		s = s.Where(sq.Eq{"status": *query.Status})
+	}
+
+	return s
+}
+
+func (q *JobQueue) FindBy(query sq.SelectBuilder, offset, limit uint64) ([]*Job, error) {
+	return q.scan(query.
+		Offset(offset).
+		Limit(limit).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(q.Manager.Db).
+		Query())
+}
+
+func (q *JobQueue) scan(rows *sql.Rows, err error) ([]*Job, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	jobs := make([]*Job, 0)
+
+	for rows.Next() {
+		var lockedUntil sql.NullTime
+
+		job := &Job{}
+
+		if err := rows.Scan(&job.Id, &job.Queue, &job.Payload, &job.Status, &job.Attempts, &job.MaxAttempts, &job.Error, &job.RunAt, &lockedUntil, &job.CreatedAt, &job.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		if lockedUntil.Valid {
+			job.LockedUntil = &lockedUntil.Time
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Purge removes the done jobs updated before the date, the dead-lettered jobs are kept
+func (q *JobQueue) Purge(before time.Time) (int, error) {
+	r, err := q.Manager.Db.Exec(fmt.Sprintf(`DELETE FROM %s WHERE status = $1 AND updated_at < $2`, q.table()), ProcessStatusDone, before)
+
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := r.RowsAffected()
+
+	if q.Logger != nil && count > 0 {
+		q.Logger.WithFields(log.Fields{
+			"module": "node.jobs",
+			"count":  count,
+		}).Info("purge done jobs")
+	}
+
+	return int(count), err
+}
+
+// Stats returns the number of jobs by queue and by status
+func (q *JobQueue) Stats() (map[string]*JobStats, error) {
+	stats := make(map[string]*JobStats)
+
+	rows, err := q.Manager.Db.Query(fmt.Sprintf(`SELECT queue, status, COUNT(*) FROM %s GROUP BY queue, status`, q.table()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var status, count int
+
+		if err := rows.Scan(&name, &status, &count); err != nil {
+			return nil, err
+		}
+
+		if _, ok := stats[name]; !ok {
+			stats[name] = &JobStats{}
+		}
+
+		switch status {
+		case ProcessStatusReady:
+			stats[name].Ready = count
+		case ProcessStatusUpdate:
+			stats[name].Running = count
+		case ProcessStatusDone:
+			stats[name].Done = count
+		case ProcessStatusError:
+			stats[name].Dead = count
+		}
+	}
+
+	return stats, rows.Err()
+}
+
+// Run claims and handles the next job of the queue, false is returned if no job is ready
+func (q *JobQueue) Run(name string) (bool, error) {
+	q.lock.Lock()
+	workers, ok := q.queues[name]
+	q.lock.Unlock()
+
+	if !ok {
+		return false, fmt.Errorf("the queue %s has no handler", name)
+	}
+
+	job, err := q.Claim(name)
+
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	stop := q.keep(job)
+	err = q.handle(workers.handler, job)
+	stop()
+
+	if err != nil {
+		if q.Logger != nil {
+			q.Logger.WithFields(log.Fields{
+				"module":   "node.jobs",
+				"queue":    name,
+				"job":      job.Id,
+				"attempts": job.Attempts,
+				"error":    err.Error(),
+			}).Warn("job failed")
+		}
+
+		return true, q.Fail(job, err)
+	}
+
+	return true, q.Complete(job)
+}
+
+// handle calls the handler, a panic is returned as an error
+func (q *JobQueue) handle(handler JobHandler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler(job)
+}
+
+// Start starts the workers of the registered queues, the queues are also checked every Poll
+// interval for the retries and the jobs enqueued by another instance without notification
+func (q *JobQueue) Start() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.done != nil {
+		return
+	}
+
+	q.done = make(chan struct{})
+
+	for _, workers := range q.queues {
+		for i := 0; i < q.options(workers.name).Workers; i++ {
+			q.running.Add(1)
+			go q.work(workers, q.done)
+		}
+	}
+
+	q.running.Add(1)
+	go q.poll(q.done)
+}
+
+// Stop waits for the workers to complete their current job
+func (q *JobQueue) Stop() {
+	q.lock.Lock()
+	done := q.done
+	q.done = nil
+	q.lock.Unlock()
+
+	if done == nil {
+		return
+	}
+
+	close(done)
+	q.running.Wait()
+}
+
+// Wake is the subscriber's handler of the Channel, it wakes up the workers of the queue
+func (q *JobQueue) Wake(notification *pq.Notification) (int, error) {
+	q.wakeUp(notification.Extra)
+
+	return PubSubListenContinue, nil
+}
+
+func (q *JobQueue) wakeUp(name string) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if workers, ok := q.queues[name]; ok {
+		workers.wakeUp()
+	}
+}
+
+func (q *JobQueue) poll(done chan struct{}) {
+	defer q.running.Done()
+
+	if q.Poll <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(q.Poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			q.lock.Lock()
+			for _, workers := range q.queues {
+				workers.wakeUp()
+			}
+			q.lock.Unlock()
+		case <-done:
+			return
+		}
+	}
+}
+
+func (q *JobQueue) work(workers *jobWorkers, done chan struct{}) {
+	defer q.running.Done()
+
+	for {
+		select {
+		case <-workers.wake:
+			// the queue is drained, another idle worker is woken up for each job
+			for {
+				found, err := q.Run(workers.name)
+
+				if err != nil && q.Logger != nil {
+					q.Logger.WithFields(log.Fields{
+						"module": "node.jobs",
+						"queue":  workers.name,
+						"error":  err.Error(),
+					}).Warn("cannot run the job")
+				}
+
+				if !found || err != nil {
+					break
+				}
+
+				workers.wakeUp()
+
+				select {
+				case <-done:
+					return
+				default:
+				}
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rande/gonode/core/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewJobOptionsFromConfig(t *testing.T) {
+	options, err := NewJobOptionsFromConfig(&config.JobQueue{Workers: 4, Backoff: "1m"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, options.Workers)
+	assert.Equal(t, 5, options.MaxAttempts)
+	assert.Equal(t, time.Minute, options.Backoff)
+	assert.Equal(t, 5*time.Minute, options.Timeout)
+
+	_, err = NewJobOptionsFromConfig(&config.JobQueue{Timeout: "5 minutes"})
+	assert.Error(t, err)
+}
+
+func Test_JobQueue_Claim(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	q := NewJobQueue(m, nil)
+
+	first, err := q.Enqueue("media", "first")
+	assert.NoError(t, err)
+	assert.Equal(t, ProcessStatusReady, first.Status)
+
+	q.Enqueue("media", "second")
+	q.Enqueue("other", "other")
+
+	job, err := q.Claim("media")
+	assert.NoError(t, err)
+	assert.Equal(t, first.Id, job.Id)
+	assert.Equal(t, "first", job.Payload)
+	assert.Equal(t, ProcessStatusUpdate, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.NotNil(t, job.LockedUntil)
+
+	job, _ = q.Claim("media")
+	assert.Equal(t, "second", job.Payload)
+
+	job, err = q.Claim("media")
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	// the claim of a crashed worker expires
+	q.Options["other"] = &JobOptions{Timeout: -time.Second}
+
+	job, _ = q.Claim("other")
+	assert.Equal(t, 1, job.Attempts)
+
+	job, _ = q.Claim("other")
+	assert.Equal(t, 2, job.Attempts)
+}
+
+func Test_JobQueue_Fail(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	q := NewJobQueue(m, nil)
+	q.Options["media"] = &JobOptions{MaxAttempts: 2, Backoff: time.Minute, Timeout: time.Minute}
+
+	enqueued, _ := q.Enqueue("media", "payload")
+	assert.Equal(t, 2, enqueued.MaxAttempts)
+
+	job, _ := q.Claim("media")
+	assert.NoError(t, q.Fail(job, errors.New("timeout")))
+
+	job, _ = q.Find(job.Id)
+	assert.Equal(t, ProcessStatusReady, job.Status)
+	assert.Equal(t, "timeout", job.Error)
+	assert.Nil(t, job.LockedUntil)
+	assert.True(t, job.RunAt.After(time.Now().Add(50*time.Second)))
+
+	// the retry is not due
+	claimed, _ := q.Claim("media")
+	assert.Nil(t, claimed)
+
+	// the last attempt fails, the job is dead-lettered
+	_, err := m.Db.Exec(`UPDATE test_nodes_jobs SET run_at = $1 WHERE id = $2`, time.Now(), job.Id)
+	assert.NoError(t, err)
+
+	job, _ = q.Claim("media")
+	assert.Equal(t, 2, job.Attempts)
+	assert.NoError(t, q.Fail(job, errors.New("not found")))
+
+	job, _ = q.Find(job.Id)
+	assert.Equal(t, ProcessStatusError, job.Status)
+
+	stats, err := q.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats["media"].Dead)
+
+	job, err = q.Retry(job.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, ProcessStatusReady, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+
+	_, err = q.Retry(job.Id)
+	assert.Equal(t, ErrNotFound, err)
+
+	_, err = q.Find(100)
+	assert.Equal(t, ErrNotFound, err)
+
+	status := ProcessStatusReady
+	jobs, err := q.FindBy(q.SelectBuilder(&JobQuery{Queue: "media", Status: &status}), 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(jobs))
+}
+
+func Test_JobQueue_Expired_Claim(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	q := NewJobQueue(m, nil)
+	q.Options["media"] = &JobOptions{MaxAttempts: 5, Backoff: time.Minute, Timeout: -time.Second}
+
+	q.Enqueue("media", "payload")
+
+	// the claim of the slow worker expires, the job is claimed by another worker
+	slow, _ := q.Claim("media")
+	fast, _ := q.Claim("media")
+	assert.Equal(t, slow.Id, fast.Id)
+
+	assert.Equal(t, ErrJobLost, q.Complete(slow))
+	assert.Equal(t, ErrJobLost, q.Fail(slow, errors.New("timeout")))
+
+	job, _ := q.Find(fast.Id)
+	assert.Equal(t, ProcessStatusUpdate, job.Status)
+	assert.Equal(t, 2, job.Attempts)
+
+	assert.NoError(t, q.Complete(fast))
+
+	job, _ = q.Find(fast.Id)
+	assert.Equal(t, ProcessStatusDone, job.Status)
+}
+
+func Test_JobQueue_Expired_Claim_Dead_Letter(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	q := NewJobQueue(m, nil)
+	q.Options["media"] = &JobOptions{MaxAttempts: 2, Backoff: time.Minute, Timeout: -time.Second}
+
+	enqueued, _ := q.Enqueue("media", "payload")
+
+	// the worker crashes on each attempt
+	job, _ := q.Claim("media")
+	assert.Equal(t, 1, job.Attempts)
+
+	job, _ = q.Claim("media")
+	assert.Equal(t, 2, job.Attempts)
+
+	job, err := q.Claim("media")
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	job, _ = q.Find(enqueued.Id)
+	assert.Equal(t, ProcessStatusError, job.Status)
+	assert.Equal(t, "the claim has expired", job.Error)
+	assert.Equal(t, 2, job.Attempts)
+}
+
+func Test_JobQueue_Keep_Claim(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	q := NewJobQueue(m, nil)
+	q.Options["media"] = &JobOptions{Workers: 1, MaxAttempts: 2, Backoff: time.Minute, Timeout: 90 * time.Millisecond}
+
+	enqueued, _ := q.Enqueue("media", "payload")
+
+	claimed := make(chan *Job, 1)
+
+	// the handler runs longer than the timeout, the claim is extended
+	q.Handle("media", func(job *Job) error {
+		time.Sleep(200 * time.Millisecond)
+
+		other, _ := q.Claim("media")
+		claimed <- other
+
+		return nil
+	})
+
+	found, err := q.Run("media")
+	assert.True(t, found)
+	assert.NoError(t, err)
+	assert.Nil(t, <-claimed)
+
+	job, _ := q.Find(enqueued.Id)
+	assert.Equal(t, ProcessStatusDone, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+}
+
+func Test_JobQueue_Workers(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	q := NewJobQueue(m, nil)
+	q.Poll = 10 * time.Millisecond
+	q.Options["media"] = &JobOptions{Workers: 2, MaxAttempts: 3, Timeout: time.Minute}
+
+	handled := make(chan *Job, 10)
+
+	q.Handle("media", func(job *Job) error {
+		handled <- job
+
+		switch job.Attempts {
+		case 1:
+			return errors.New("unavailable")
+		case 2:
+			panic("corrupted file")
+		}
+
+		return nil
+	})
+
+	q.Start()
+
+	job, err := q.Enqueue("media", "payload")
+	assert.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		select {
+		case j := <-handled:
+			assert.Equal(t, i, j.Attempts)
+		case <-time.After(2 * time.Second):
+			assert.Fail(t, "the job has not been handled")
+		}
+	}
+
+	q.Stop()
+
+	job, _ = q.Find(job.Id)
+	assert.Equal(t, ProcessStatusDone, job.Status)
+	assert.Equal(t, 3, job.Attempts)
+
+	_, err = q.Run("unknown")
+	assert.Error(t, err)
+}
+
+type jobListener struct {
+	payloads []string
+}
+
+func (l *jobListener) Handle(notification *pq.Notification, m NodeManager) (int, error) {
+	l.payloads = append(l.payloads, notification.Extra)
+
+	return PubSubListenContinue, nil
+}
+
+func Test_JobQueue_Listener(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	q := NewJobQueue(m, nil)
+
+	listener := &jobListener{}
+	q.Handle("media", ListenerJob(listener, m))
+
+	state, err := q.Enqueuer("media")(&pq.Notification{Channel: "media_file_download", Extra: "uuid"})
+	assert.NoError(t, err)
+	assert.Equal(t, PubSubListenContinue, state)
+
+	found, err := q.Run("media")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []string{"uuid"}, listener.payloads)
+
+	found, _ = q.Run("media")
+	assert.False(t, found)
+}
@@ -0,0 +1,204 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// roles allowed to save a node locked by another user, and to release its lock
+var LockOverrideRoles = []string{"node:api:master", "node:lock:override"}
+
+func CanOverrideLock(roles []string) bool {
+	for _, role := range roles {
+		for _, override := range LockOverrideRoles {
+			if role == override {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Lock is an advisory edit lock on a node, the owner is the editor's username
+type Lock struct {
+	Uuid      Reference `json:"uuid"`
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Locker manages the edit locks, a lock is active until it is released or until it expires.
+// The Lock and Unlock events are sent on the <prefix>_manager_action channel, the subject is
+// the node's uuid and the extra field is the owner.
+type Locker struct {
+	Manager *SqlNodeManager
+	TTL     time.Duration // used if Acquire is called without ttl
+	Logger  *log.Logger
+}
+
+func (l *Locker) table() string {
+	return l.Manager.Prefix + "_nodes_locks"
+}
+
+// Find returns the node's active lock, nil is returned if the node is not locked
+func (l *Locker) Find(uuid Reference) (*Lock, error) {
+	lock := &Lock{Uuid: uuid}
+
+	err := l.Manager.runner().
+		QueryRow(fmt.Sprintf(`SELECT owner, expires_at, created_at FROM %s WHERE uuid = $1 AND expires_at > $2`, l.table()), uuid.CleanString(), time.Now()).
+		Scan(&lock.Owner, &lock.ExpiresAt, &lock.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// Acquire locks the node for the owner, or extends the owner's lock. ErrLocked is returned if
+// another user holds an active lock.
+func (l *Locker) Acquire(uuid Reference, owner string, ttl time.Duration) (*Lock, error) {
+	if ttl <= 0 {
+		ttl = l.TTL
+	}
+
+	now := time.Now()
+	lock := &Lock{
+		Uuid:      uuid,
+		Owner:     owner,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+
+	// the statement is atomic: an expired lock, or the owner's lock, is replaced
+	r, err := l.Manager.runner().Exec(fmt.Sprintf(`INSERT INTO %s (uuid, owner, expires_at, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (uuid) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at, created_at = excluded.created_at
+		WHERE %s.owner = excluded.owner OR %s.expires_at <= $5`, l.table(), l.table(), l.table()),
+		uuid.CleanString(), owner, lock.ExpiresAt, lock.CreatedAt, now)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if affected, err := r.RowsAffected(); err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, ErrLocked
+	}
+
+	l.notify("Lock", lock.Uuid, lock.Owner, lock.ExpiresAt)
+
+	return lock, nil
+}
+
+// Release removes the owner's lock, the lock of another user is removed only if force is true.
+// The function returns false if the node was not locked.
+func (l *Locker) Release(uuid Reference, owner string, force bool) (bool, error) {
+	lock, err := l.Find(uuid)
+
+	if err != nil || lock == nil {
+		return false, err
+	}
+
+	if lock.Owner != owner && !force {
+		return false, ErrLocked
+	}
+
+	r, err := l.Manager.runner().Exec(fmt.Sprintf(`DELETE FROM %s WHERE uuid = $1 AND owner = $2`, l.table()), uuid.CleanString(), lock.Owner)
+
+	if err != nil {
+		return false, err
+	}
+
+	if affected, err := r.RowsAffected(); err != nil || affected == 0 {
+		return false, err
+	}
+
+	l.notify("Unlock", uuid, lock.Owner, time.Now())
+
+	return true, nil
+}
+
+// Purge removes the expired locks, an Unlock event is sent for each lock
+func (l *Locker) Purge(now time.Time) (int, error) {
+	rows, err := l.Manager.runner().Query(fmt.Sprintf(`SELECT uuid, owner FROM %s WHERE expires_at <= $1`, l.table()), now)
+
+	if err != nil {
+		return 0, err
+	}
+
+	locks := make([]*Lock, 0)
+
+	for rows.Next() {
+		var uuid string
+
+		lock := &Lock{}
+
+		if err := rows.Scan(&uuid, &lock.Owner); err != nil {
+			rows.Close()
+
+			return 0, err
+		}
+
+		if lock.Uuid, err = GetReferenceFromString(uuid); err == nil {
+			locks = append(locks, lock)
+		}
+	}
+
+	rows.Close()
+
+	count := 0
+
+	for _, lock := range locks {
+		r, err := l.Manager.runner().Exec(fmt.Sprintf(`DELETE FROM %s WHERE uuid = $1 AND owner = $2 AND expires_at <= $3`, l.table()), lock.Uuid.CleanString(), lock.Owner, now)
+
+		if err != nil {
+			return count, err
+		}
+
+		// the lock can be acquired again in the meantime
+		if affected, _ := r.RowsAffected(); affected > 0 {
+			l.notify("Unlock", lock.Uuid, lock.Owner, now)
+			count++
+		}
+	}
+
+	if l.Logger != nil && count > 0 {
+		l.Logger.WithFields(log.Fields{
+			"module": "node.lock",
+			"count":  count,
+		}).Info("purge expired locks")
+	}
+
+	return count, nil
+}
+
+func (l *Locker) notify(action string, uuid Reference, owner string, date time.Time) {
+	if l.Logger != nil {
+		l.Logger.WithFields(log.Fields{
+			"module": "node.lock",
+			"uuid":   uuid.CleanString(),
+			"owner":  owner,
+		}).Debug(fmt.Sprintf("%s node", action))
+	}
+
+	l.Manager.sendNotification(l.Manager.Prefix+"_manager_action", &ModelEvent{
+		Action:  action,
+		Subject: uuid.CleanString(),
+		Date:    date,
+		Extra:   owner,
+	})
+}
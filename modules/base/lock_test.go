@@ -0,0 +1,108 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CanOverrideLock(t *testing.T) {
+	assert.True(t, CanOverrideLock([]string{"ROLE_API", "node:lock:override"}))
+	assert.False(t, CanOverrideLock([]string{"ROLE_API"}))
+}
+
+func Test_Locker(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	events := make([]*ModelEvent, 0)
+	m.OnNotify = func(channel, payload string) {
+		events = append(events, CreateModelEvent(&pq.Notification{Extra: payload}))
+	}
+
+	l := &Locker{Manager: m, TTL: time.Minute}
+	node := newCachedUser(m, "page")
+
+	events = events[:0]
+
+	lock, err := l.Acquire(node.Uuid, "thomas", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "thomas", lock.Owner)
+	assert.True(t, lock.ExpiresAt.After(time.Now().Add(50*time.Second)))
+
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "Lock", events[0].Action)
+	assert.Equal(t, node.Uuid.CleanString(), events[0].Subject)
+	assert.Equal(t, "thomas", events[0].Extra)
+
+	// the owner extends the lock, another user cannot acquire it
+	lock, err = l.Acquire(node.Uuid, "thomas", time.Hour)
+	assert.NoError(t, err)
+
+	_, err = l.Acquire(node.Uuid, "other", time.Hour)
+	assert.Equal(t, ErrLocked, err)
+
+	found, err := l.Find(node.Uuid)
+	assert.NoError(t, err)
+	assert.Equal(t, "thomas", found.Owner)
+
+	_, err = l.Release(node.Uuid, "other", false)
+	assert.Equal(t, ErrLocked, err)
+
+	released, err := l.Release(node.Uuid, "other", true)
+	assert.NoError(t, err)
+	assert.True(t, released)
+	assert.Equal(t, "Unlock", events[len(events)-1].Action)
+	assert.Equal(t, "thomas", events[len(events)-1].Extra)
+
+	released, err = l.Release(node.Uuid, "thomas", false)
+	assert.NoError(t, err)
+	assert.False(t, released)
+
+	found, _ = l.Find(node.Uuid)
+	assert.Nil(t, found)
+}
+
+func Test_Locker_Expiration(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	events := make([]*ModelEvent, 0)
+	m.OnNotify = func(channel, payload string) {
+		events = append(events, CreateModelEvent(&pq.Notification{Extra: payload}))
+	}
+
+	l := &Locker{Manager: m, TTL: time.Minute}
+	node := newCachedUser(m, "page")
+	other := newCachedUser(m, "other")
+
+	l.Acquire(node.Uuid, "thomas", time.Millisecond)
+	l.Acquire(other.Uuid, "thomas", time.Hour)
+
+	time.Sleep(5 * time.Millisecond)
+
+	// the expired lock is ignored, and can be acquired by another user
+	found, _ := l.Find(node.Uuid)
+	assert.Nil(t, found)
+
+	count, err := l.Purge(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "Unlock", events[len(events)-1].Action)
+	assert.Equal(t, node.Uuid.CleanString(), events[len(events)-1].Subject)
+
+	l.Acquire(node.Uuid, "thomas", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	lock, err := l.Acquire(node.Uuid, "other", time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, "other", lock.Owner)
+}
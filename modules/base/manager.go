@@ -47,4 +47,7 @@ type NodeManager interface {
 	NewNode(t string) *Node
 	Validate(node *Node) (bool, Errors)
 	Move(uuid, parent Reference) (int64, error)
+	Reorder(parent Reference, uuids []Reference, revision bool) (int64, error)
+	FindRedirect(path string) *Node
+	Transaction(f func(tx NodeManager) error) error
 }
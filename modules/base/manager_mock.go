@@ -83,3 +83,25 @@ func (m *MockedManager) Move(uuid, parentUuid Reference) (int64, error) {
 
 	return args.Get(0).(int64), args.Error(1)
 }
+
+func (m *MockedManager) Reorder(parent Reference, uuids []Reference, revision bool) (int64, error) {
+	args := m.Mock.Called(parent, uuids, revision)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockedManager) FindRedirect(path string) *Node {
+	args := m.Mock.Called(path)
+
+	if args.Get(0) == nil {
+		return nil
+	}
+
+	return args.Get(0).(*Node)
+}
+
+func (m *MockedManager) Transaction(f func(tx NodeManager) error) error {
+	args := m.Mock.Called(f)
+
+	return args.Error(0)
+}
@@ -0,0 +1,954 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"container/list"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/rande/gonode/core/helper"
+	"github.com/rande/gonode/core/security"
+	"github.com/rande/gonode/core/squirrel"
+	log "github.com/sirupsen/logrus"
+)
+
+// SqlNodeManager stores the nodes in PostgreSQL or in SQLite, the queries depending on the
+// database are built by the Dialect.
+type SqlNodeManager struct {
+	Logger   *log.Logger
+	Handlers Handlers
+	Db       *sql.DB
+	ReadOnly bool
+	Prefix   string
+
+	// Integrity prevents the removal of a node referenced by other nodes
+	Integrity bool
+
+	// Workflows restricts the status changes, optional
+	Workflows *Workflows
+
+	// FreezeSlug prevents the slug of a published node to be changed
+	FreezeSlug bool
+
+	// Dialect builds the database specific queries, PostgreSQL is used if empty
+	Dialect Dialect
+
+	// OnNotify is called with the notifications sent by the manager, optional. Unlike the
+	// subscribers, the function is called synchronously once the notification is committed.
+	OnNotify func(channel, payload string)
+
+	tx            *sql.Tx
+	notifications []*sqlNotification
+}
+
+// PgNodeManager is the previous name of SqlNodeManager, from the time PostgreSQL was the only
+// supported database.
+//
+// Deprecated: use SqlNodeManager.
+type PgNodeManager = SqlNodeManager
+
+// sqlNotification is a NOTIFY call delayed until the transaction is committed
+type sqlNotification struct {
+	channel string
+	payload string
+}
+
+// the common interface between *sql.DB and *sql.Tx
+type sqlRunner interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type SelectOptions struct {
+	TableSuffix  string
+	SelectClause string
+}
+
+func NewSelectOptions() *SelectOptions {
+	return &SelectOptions{
+		TableSuffix:  "nodes",
+		SelectClause: "id, uuid, type, name, revision, version, created_at, updated_at, set_uuid, parent_uuid, parents, slug, path, created_by, updated_by, data, meta, modules, access, deleted, enabled, source, status, weight, publish_at, unpublish_at",
+	}
+}
+
+func (m *SqlNodeManager) SelectBuilder(options *SelectOptions) sq.SelectBuilder {
+	if options == nil {
+		options = NewSelectOptions()
+	}
+
+	return sq.
+		Select(options.SelectClause).
+		From(m.Prefix + "_" + options.TableSuffix).
+		PlaceholderFormat(sq.Dollar)
+}
+
+func (m *SqlNodeManager) dialect() Dialect {
+	if m.Dialect == nil {
+		return &PgDialect{}
+	}
+
+	return m.Dialect
+}
+
+func (m *SqlNodeManager) runner() sqlRunner {
+	if m.tx != nil {
+		return m.tx
+	}
+
+	return m.Db
+}
+
+// Runner returns the transaction if the manager is bound to one, the database otherwise. The
+// modules storing rows in their own tables use it to write them with the nodes' transaction.
+func (m *SqlNodeManager) Runner() sq.StdSql {
+	return m.runner()
+}
+
+// Transaction runs the callback with a manager bound to a single database transaction,
+// all Save, RemoveOne and Move calls done with the tx manager are committed or rolled back
+// together. The notifications are only sent once the transaction is committed.
+// Calling Transaction on a manager already bound to a transaction reuses the current one.
+func (m *SqlNodeManager) Transaction(f func(tx NodeManager) error) error {
+	if m.tx != nil {
+		return f(m)
+	}
+
+	helper.PanicIf(m.ReadOnly, "The manager is readonly, cannot alter the datastore")
+
+	tx, err := m.Db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	// the transaction's manager shares the configuration, only the transaction state differs
+	txm := *m
+	txm.tx = tx
+	txm.notifications = nil
+
+	if err = m.runTransaction(&txm, f); err == nil {
+		err = txm.storeEvents()
+	}
+
+	if err != nil {
+		if m.Logger != nil {
+			m.Logger.WithFields(log.Fields{
+				"module": "node.manager",
+				"error":  err.Error(),
+			}).Warn("rollback transaction")
+		}
+
+		tx.Rollback()
+
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	// the notifications are already stored in the outbox with the transaction
+	for _, n := range txm.notifications {
+		m.send(n.channel, n.payload)
+	}
+
+	return nil
+}
+
+// runTransaction converts a panic raised inside the callback (ie, helper.PanicOnError)
+// into an error so the transaction can be rolled back.
+func (m *SqlNodeManager) runTransaction(txm *SqlNodeManager, f func(tx NodeManager) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	return f(txm)
+}
+
+// Notify stores the notification in the outbox and sends it, the notification is stored with the
+// current transaction and sent once the transaction is committed. Without transaction, a
+// transaction is used to store the notification.
+func (m *SqlNodeManager) Notify(channel string, payload string) {
+	if m.tx == nil {
+		helper.PanicOnError(m.Transaction(func(tx NodeManager) error {
+			tx.Notify(channel, payload)
+
+			return nil
+		}))
+
+		return
+	}
+
+	m.notifications = append(m.notifications, &sqlNotification{
+		channel: channel,
+		payload: payload,
+	})
+}
+
+func (m *SqlNodeManager) send(channel string, payload string) {
+	if m.OnNotify != nil {
+		m.OnNotify(channel, payload)
+	}
+
+	helper.PanicOnError(m.dialect().Notify(m.Db, channel, payload))
+}
+
+func (m *SqlNodeManager) NewNode(t string) *Node {
+	return m.Handlers.NewNode(t)
+}
+
+func (m *SqlNodeManager) FindBy(query sq.SelectBuilder, offset uint64, limit uint64) *list.List {
+	query = query.Limit(limit).Offset(offset)
+
+	rows, err := query.
+		RunWith(m.runner()).
+		Query()
+
+	list := list.New()
+
+	rawSql, _, _ := query.ToSql()
+
+	if m.Logger != nil {
+		m.Logger.WithFields(log.Fields{
+			"module": "node.manager",
+			"query":  rawSql,
+		}).Debug("Executing query")
+	}
+
+	if err != nil {
+		if m.Logger != nil {
+			m.Logger.WithFields(log.Fields{
+				"module": "node.manager",
+				"err":    err,
+				"query":  rawSql,
+			}).Warn("error while running the query")
+		}
+
+		helper.PanicOnError(err)
+	}
+
+	for rows.Next() {
+		//if m.Logger != nil {
+		//	m.Logger.WithFields(log.Fields{
+		//		"module": "node.manager",
+		//		"row":  rows,
+		//	}).Debug("hydrating row")
+		//}
+
+		node := m.hydrate(rows)
+
+		list.PushBack(node)
+	}
+
+	return list
+}
+
+func (m *SqlNodeManager) FindOneBy(query sq.SelectBuilder) *Node {
+	list := m.FindBy(query, 0, 1)
+
+	if list.Len() == 1 {
+		return list.Front().Value.(*Node)
+	}
+
+	return nil
+}
+
+func (m *SqlNodeManager) Find(uuid Reference) *Node {
+	return m.FindOneBy(m.SelectBuilder(NewSelectOptions()).Where(sq.Eq{"uuid": uuid.String()}))
+}
+
+func (m *SqlNodeManager) hydrate(rows *sql.Rows) *Node {
+	node := &Node{}
+
+	// []byte can be scanned from the text returned by the databases without a JSON type
+	data := []byte{}
+	meta := []byte{}
+	modules := []byte{}
+
+	Uuid := ""
+	SetUuid := ""
+	ParentUuid := ""
+	CreatedBy := ""
+	UpdatedBy := ""
+	Source := ""
+
+	var Access, Parents squirrel.StringSlice
+
+	err := rows.Scan(
+		&node.Id,
+		&Uuid,
+		&node.Type,
+		&node.Name,
+		&node.Revision,
+		&node.Version,
+		&node.CreatedAt,
+		&node.UpdatedAt,
+		&SetUuid,
+		&ParentUuid,
+		&Parents,
+		&node.Slug,
+		&node.Path,
+		&CreatedBy,
+		&UpdatedBy,
+		&data,
+		&meta,
+		&modules,
+		&Access,
+		&node.Deleted,
+		&node.Enabled,
+		&Source,
+		&node.Status,
+		&node.Weight,
+		&node.PublishAt,
+		&node.UnpublishAt,
+	)
+
+	helper.PanicOnError(err)
+
+	var tmpUuid uuid.UUID
+
+	// transform UUID
+	tmpUuid, _ = uuid.Parse(Uuid)
+	node.Uuid = GetReference(tmpUuid)
+	tmpUuid, _ = uuid.Parse(SetUuid)
+	node.SetUuid = GetReference(tmpUuid)
+	tmpUuid, _ = uuid.Parse(ParentUuid)
+	node.ParentUuid = GetReference(tmpUuid)
+	tmpUuid, _ = uuid.Parse(CreatedBy)
+	node.CreatedBy = GetReference(tmpUuid)
+	tmpUuid, _ = uuid.Parse(UpdatedBy)
+	node.UpdatedBy = GetReference(tmpUuid)
+	tmpUuid, _ = uuid.Parse(Source)
+	node.Source = GetReference(tmpUuid)
+
+	pUuids := make([]Reference, 0)
+
+	for _, ref := range Parents {
+		pUuid, _ := uuid.Parse(ref)
+		pUuids = append(pUuids, GetReference(pUuid))
+	}
+
+	node.Parents = pUuids
+
+	for _, access := range Access {
+		node.Access = append(node.Access, access)
+	}
+
+	handler := m.Handlers.Get(node)
+	if h, ok := handler.(LoadNodeHandler); ok {
+		h.Load(data, meta, node)
+	} else {
+		HandlerLoad(handler, data, meta, node)
+	}
+
+	err = json.Unmarshal(modules, &node.Modules)
+	helper.PanicOnError(err)
+
+	return node
+}
+
+// Remove soft deletes the nodes matching the query, the nodes and their events are saved in one
+// transaction. If Integrity is enabled, nothing is removed and ErrReferenced is returned if a
+// removed node is referenced by a node which is not removed.
+func (m *SqlNodeManager) Remove(query sq.SelectBuilder) error {
+	query = query.Where("deleted != ?", true)
+
+	return m.Transaction(func(tx NodeManager) error {
+		tm := tx.(*SqlNodeManager)
+
+		now := time.Now()
+		removed := make([]Reference, 0)
+
+		for {
+			nodes := tm.FindBy(query, 0, 1024)
+
+			if nodes.Len() == 0 {
+				break
+			}
+
+			for e := nodes.Front(); e != nil; e = e.Next() {
+				node := e.Value.(*Node)
+				node.Deleted = true
+				node.UpdatedAt = now
+
+				if _, err := tm.Save(node, false); err != nil {
+					return err
+				}
+
+				removed = append(removed, node.Uuid)
+
+				tm.sendNotification(m.Prefix+"_manager_action", &ModelEvent{
+					Type:     node.Type,
+					Name:     node.Name,
+					Action:   "SoftDelete",
+					Subject:  node.Uuid.CleanString(),
+					Revision: node.Revision,
+					Date:     node.UpdatedAt,
+				})
+
+				if m.Logger != nil {
+					m.Logger.WithFields(log.Fields{
+						"type":   node.Type,
+						"uuid":   node.Uuid,
+						"module": "node.manager",
+					}).Warn("soft delete many")
+				}
+			}
+		}
+
+		if !m.Integrity {
+			return nil
+		}
+
+		// the nodes are checked once they are all removed, so the removed nodes can reference each other
+		for _, uuid := range removed {
+			if referenced, err := tm.IsReferenced(uuid); err != nil {
+				return err
+			} else if referenced {
+				return ErrReferenced
+			}
+		}
+
+		return nil
+	})
+}
+
+// RemoveOne soft deletes the node, the node and its event are saved in one transaction
+func (m *SqlNodeManager) RemoveOne(node *Node) (*Node, error) {
+	return m.atomic(node, func(tx *SqlNodeManager) (*Node, error) {
+		return tx.removeOne(node)
+	})
+}
+
+func (m *SqlNodeManager) removeOne(node *Node) (*Node, error) {
+	if m.Integrity {
+		if referenced, err := m.IsReferenced(node.Uuid); err != nil {
+			return node, err
+		} else if referenced {
+			return node, ErrReferenced
+		}
+	}
+
+	node.UpdatedAt = time.Now()
+	node.Deleted = true
+
+	if m.Logger != nil {
+		m.Logger.WithFields(log.Fields{
+			"type":   node.Type,
+			"uuid":   node.Uuid,
+			"module": "node.manager",
+		}).Warn("soft delete one")
+	}
+
+	m.sendNotification(m.Prefix+"_manager_action", &ModelEvent{
+		Type:     node.Type,
+		Action:   "SoftDelete",
+		Subject:  node.Uuid.CleanString(),
+		Revision: node.Revision,
+		Date:     node.UpdatedAt,
+		Name:     node.Name,
+	})
+
+	return m.Save(node, true)
+}
+
+func (m *SqlNodeManager) insertNode(node *Node, table string) (*Node, error) {
+	if node.Uuid.String() == GetEmptyReference().String() {
+		node.Uuid = GetReference(uuid.New())
+	}
+
+	if node.Slug == "" {
+		node.Slug = node.Uuid.String()
+	}
+
+	Parents := make([]string, 0)
+	for _, p := range node.Parents {
+		Parents = append(Parents, p.CleanString())
+	}
+
+	node.Access = security.EnsureRoles(node.Access, "node:api:master")
+
+	Access := make([]string, 0)
+	for _, a := range node.Access {
+		Access = append(Access, a)
+	}
+
+	query := sq.Insert(table).
+		Columns(
+			"uuid", "type", "revision", "version", "name", "created_at", "updated_at", "set_uuid",
+			"parent_uuid", "parents", "slug", "path", "created_by", "updated_by", "data", "meta", "modules",
+			"access", "deleted", "enabled", "source", "status", "weight", "publish_at", "unpublish_at").
+		Values(
+			node.Uuid.CleanString(),
+			node.Type,
+			node.Revision,
+			node.Version,
+			node.Name,
+			node.CreatedAt,
+			node.UpdatedAt,
+			node.SetUuid.CleanString(),
+			node.ParentUuid.CleanString(),
+			m.dialect().ArrayValue(Parents),
+			node.Slug,
+			node.Path,
+			node.CreatedBy.CleanString(),
+			node.UpdatedBy.CleanString(),
+			string(InterfaceToJsonMessage(node.Type, node.Data)[:]),
+			string(InterfaceToJsonMessage(node.Type, node.Meta)[:]),
+			string(InterfaceToJsonMessage(node.Type, node.Modules)[:]),
+			m.dialect().ArrayValue(Access),
+			node.Deleted,
+			node.Enabled,
+			node.Source.CleanString(),
+			node.Status,
+			node.Weight,
+			node.PublishAt,
+			node.UnpublishAt,
+		).
+		Suffix("RETURNING \"id\"").
+		RunWith(m.runner()).
+		PlaceholderFormat(sq.Dollar)
+
+	err := query.QueryRow().Scan(&node.Id)
+
+	return node, err
+}
+
+func (m *SqlNodeManager) Move(uuid, parentUuid Reference) (int64, error) {
+	var affectedRows int64
+
+	err := m.Transaction(func(tm NodeManager) error {
+		tx := tm.(*SqlNodeManager).tx
+
+		r, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET parent_uuid = $1 WHERE uuid = $2 AND EXISTS(SELECT uuid FROM %s WHERE uuid = $3 and NOT (%s))`,
+			m.Prefix+"_nodes", m.Prefix+"_nodes", m.dialect().ArrayContains("parents", "$4")),
+			parentUuid.CleanString(),
+			uuid.CleanString(),
+			parentUuid.CleanString(),
+			uuid.CleanString())
+
+		if err != nil {
+			return err
+		}
+
+		if affectedRows, err = r.RowsAffected(); err != nil {
+			return err
+		}
+
+		if affectedRows > 0 {
+			paths, err := tm.(*SqlNodeManager).branchPaths(uuid)
+
+			if err != nil {
+				return err
+			}
+
+			// only the moved branch is rebuilt, the parent's other children keep their paths
+			if _, err = tx.Exec(m.dialect().TreeQuery(m.Prefix+"_nodes"), parentUuid.CleanString(), uuid.CleanString()); err != nil {
+				return err
+			}
+
+			if err = tm.(*SqlNodeManager).saveRedirects(uuid, paths); err != nil {
+				return err
+			}
+
+			tm.(*SqlNodeManager).sendNotification(m.Prefix+"_manager_action", &ModelEvent{
+				Action:  "Move",
+				Subject: uuid.CleanString(),
+				Date:    time.Now(),
+				Extra:   parentUuid.CleanString(),
+			})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+// Reorder sets the weight of the parent's children from their position in the list, the
+// children not listed keep their weight. The nodes are updated in one transaction, and one
+// Reorder event is sent with the parent as subject. If revision is true, the nodes are saved
+// like any update (hooks, revision check and new revision) without their own Update event, the
+// Reorder event covers them. Otherwise only the weight column is
+// updated in place, like Move does for the tree columns: the node's data is not changed so the
+// hooks are not called and no revision is created.
+func (m *SqlNodeManager) Reorder(parent Reference, uuids []Reference, revision bool) (int64, error) {
+	var affectedRows int64
+
+	err := m.Transaction(func(tm NodeManager) error {
+		tx := tm.(*SqlNodeManager)
+
+		values := make([]string, 0)
+		for _, uuid := range uuids {
+			values = append(values, uuid.CleanString())
+		}
+
+		query := tx.SelectBuilder(NewSelectOptions()).
+			Where(sq.Eq{"parent_uuid": parent.CleanString(), "uuid": values}).
+			Where("deleted = ?", false)
+
+		children := make(map[string]*Node)
+		for e := tx.FindBy(query, 0, uint64(len(values))).Front(); e != nil; e = e.Next() {
+			node := e.Value.(*Node)
+			children[node.Uuid.CleanString()] = node
+		}
+
+		if len(children) != len(values) {
+			return ErrInvalidOrder
+		}
+
+		now := time.Now()
+
+		for weight, uuid := range values {
+			node := children[uuid]
+
+			if node.Weight == weight {
+				continue
+			}
+
+			node.Weight = weight
+
+			if revision {
+				if _, err := tx.save(node, true, false); err != nil {
+					return err
+				}
+			} else {
+				_, err := tx.runner().Exec(fmt.Sprintf(`UPDATE %s SET weight = $1, updated_at = $2 WHERE id = $3`, m.Prefix+"_nodes"), weight, now, node.Id)
+
+				if err != nil {
+					return err
+				}
+			}
+
+			affectedRows++
+		}
+
+		extra, _ := json.Marshal(values)
+
+		tx.sendNotification(m.Prefix+"_manager_action", &ModelEvent{
+			Action:      "Reorder",
+			Subject:     parent.CleanString(),
+			Date:        now,
+			Extra:       string(extra),
+			NewRevision: revision,
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return affectedRows, nil
+}
+
+func (m *SqlNodeManager) updateNode(node *Node, table string) (*Node, error) {
+	helper.PanicIf(node.Id == 0, "Cannot update node without id")
+
+	node.Access = security.EnsureRoles(node.Access, "node:api:master")
+
+	Access := make([]string, 0)
+	for _, a := range node.Access {
+		Access = append(Access, a)
+	}
+
+	query := sq.Update(m.Prefix+"_nodes").RunWith(m.runner()).PlaceholderFormat(sq.Dollar).
+		Set("uuid", node.Uuid.CleanString()).
+		Set("type", node.Type).
+		Set("revision", node.Revision).
+		Set("version", node.Version).
+		Set("name", node.Name).
+		Set("created_at", node.CreatedAt).
+		Set("updated_at", node.UpdatedAt).
+		Set("set_uuid", node.SetUuid.CleanString()).
+		Set("slug", node.Slug).
+		Set("path", node.Path).
+		Set("created_by", node.CreatedBy.CleanString()).
+		Set("updated_by", node.UpdatedBy.CleanString()).
+		Set("deleted", node.Deleted).
+		Set("enabled", node.Enabled).
+		Set("data", string(InterfaceToJsonMessage(node.Type, node.Data)[:])).
+		Set("meta", string(InterfaceToJsonMessage(node.Type, node.Meta)[:])).
+		Set("modules", string(InterfaceToJsonMessage(node.Type, node.Modules)[:])).
+		Set("access", m.dialect().ArrayValue(Access)).
+		Set("source", node.Source.CleanString()).
+		Set("status", node.Status).
+		Set("weight", node.Weight).
+		Set("publish_at", node.PublishAt).
+		Set("unpublish_at", node.UnpublishAt).
+		Where("id = ?", node.Id)
+
+	result, err := query.Exec()
+
+	if err != nil {
+		return node, err
+	}
+
+	affected, err := result.RowsAffected()
+
+	helper.PanicOnError(err)
+
+	if affected == 0 {
+		return node, errors.New("Zero affected rows for current node")
+	}
+
+	return node, err
+}
+
+// Save inserts or updates the node, the rows and the event are saved in one transaction
+func (m *SqlNodeManager) Save(node *Node, newRevision bool) (*Node, error) {
+	return m.atomic(node, func(tx *SqlNodeManager) (*Node, error) {
+		return tx.save(node, newRevision, true)
+	})
+}
+
+// atomic runs the callback with a manager bound to a transaction, the current transaction is
+// reused if any
+func (m *SqlNodeManager) atomic(node *Node, f func(tx *SqlNodeManager) (*Node, error)) (*Node, error) {
+	if m.tx != nil {
+		return f(m)
+	}
+
+	result := node
+
+	err := m.Transaction(func(tx NodeManager) error {
+		var err error
+
+		result, err = f(tx.(*SqlNodeManager))
+
+		return err
+	})
+
+	return result, err
+}
+
+// save inserts or updates the node, the Create or Update event is only sent if notify is true:
+// the callers sending their own event (ie, Reorder) save the nodes without notification.
+func (m *SqlNodeManager) save(node *Node, newRevision, notify bool) (*Node, error) {
+
+	var contextLogger *log.Entry
+
+	if m.Logger != nil {
+		contextLogger = m.Logger.WithFields(log.Fields{
+			"uuid":     node.Uuid,
+			"id":       node.Id,
+			"type":     node.Type,
+			"revision": node.Revision,
+			"module":   "node.manager",
+		})
+
+		contextLogger.Debug("saving node")
+	}
+
+	helper.PanicIf(m.ReadOnly, "The manager is readonly, cannot alter the datastore")
+
+	var err error
+
+	handler := m.Handlers.Get(node)
+
+	if node.Id == 0 {
+
+		if h, ok := handler.(DatabaseNodeHandler); ok {
+			h.PreInsert(node, m)
+		}
+
+		if err = m.prepareSlug(node, nil); err != nil {
+			return node, err
+		}
+
+		node, err = m.insertNode(node, m.Prefix+"_nodes_audit")
+		helper.PanicOnError(err)
+
+		node.Id = 0
+
+		node, err = m.insertNode(node, m.Prefix+"_nodes")
+
+		if errors, ok := m.slugViolation(err); ok {
+			return node, errors
+		}
+
+		helper.PanicOnError(err)
+
+		helper.PanicOnError(m.saveRelations(node))
+
+		if contextLogger != nil {
+			contextLogger.Debug("creating node")
+		}
+
+		if h, ok := handler.(DatabaseNodeHandler); ok {
+			h.PostInsert(node, m)
+		}
+
+		if notify {
+			m.sendNotification(m.Prefix+"_manager_action", &ModelEvent{
+				Type:        node.Type,
+				Action:      "Create",
+				Subject:     node.Uuid.CleanString(),
+				Date:        node.CreatedAt,
+				Name:        node.Name,
+				Revision:    node.Revision,
+				NewRevision: newRevision,
+			})
+		}
+
+		return node, err
+	}
+
+	if h, ok := handler.(DatabaseNodeHandler); ok {
+		h.PreUpdate(node, m)
+	}
+
+	// 1. check if the one in the datastore is older
+	saved := m.FindOneBy(m.SelectBuilder(NewSelectOptions()).Where(sq.Eq{"uuid": node.Uuid.String()}))
+
+	if saved != nil && node.Revision != saved.Revision {
+		if contextLogger != nil {
+			contextLogger.Info("invalid revision for node")
+		}
+
+		return node, NewRevisionError(fmt.Sprintf("Invalid revision for node: %s, saved rev: %d, current rev: %d", node.Uuid, saved.Revision, node.Revision))
+	}
+
+	if err = m.prepareSlug(node, saved); err != nil {
+		return node, err
+	}
+
+	followSlug(node, saved)
+
+	// the previous paths of the branch are redirected once the path has changed
+	var paths map[string]string
+
+	if saved != nil && saved.Path != "" && saved.Path != node.Path {
+		if paths, err = m.branchPaths(node.Uuid); err != nil {
+			return node, err
+		}
+	}
+
+	if contextLogger != nil {
+		contextLogger.Debug("updating node")
+	}
+
+	if newRevision {
+		// 3. Update the revision number
+		node.Revision++
+		node.CreatedAt = saved.CreatedAt
+
+		if contextLogger != nil {
+			contextLogger.Debug("increment revision")
+		}
+	}
+
+	node.UpdatedAt = time.Now()
+
+	node, err = m.updateNode(node, m.Prefix+"_nodes")
+
+	if errors, ok := m.slugViolation(err); ok {
+		return node, errors
+	}
+
+	helper.PanicOnError(err)
+
+	if paths != nil {
+		helper.PanicOnError(m.rebuildPaths(saved.Path, node.Path, paths))
+		helper.PanicOnError(m.saveRedirects(node.Uuid, paths))
+	}
+
+	helper.PanicOnError(m.saveRelations(node))
+
+	if h, ok := handler.(DatabaseNodeHandler); ok {
+		h.PostUpdate(node, m)
+	}
+
+	if newRevision {
+		// the audit row keeps the date of the revision, so the audit can be queried by date
+		id := node.Id
+		_, err = m.insertNode(node, m.Prefix+"_nodes_audit")
+
+		node.Id = id
+
+		helper.PanicOnError(err)
+	}
+
+	if notify {
+		m.sendNotification(m.Prefix+"_manager_action", &ModelEvent{
+			Type:        node.Type,
+			Action:      "Update",
+			Subject:     node.Uuid.CleanString(),
+			Revision:    node.Revision,
+			Date:        node.UpdatedAt,
+			Name:        node.Name,
+			NewRevision: newRevision,
+		})
+	}
+
+	return node, err
+}
+
+func (m *SqlNodeManager) sendNotification(channel string, element interface{}) {
+	data, _ := json.Marshal(element)
+
+	m.Notify(channel, string(data[:]))
+}
+
+func (m *SqlNodeManager) Validate(node *Node) (bool, Errors) {
+	errors := NewErrors()
+
+	if node.Name == "" {
+		errors.AddError("name", "Name cannot be empty")
+	}
+
+	if node.Type == "" {
+		errors.AddError("type", "Type cannot be empty")
+	}
+
+	if len(node.Access) == 0 {
+		errors.AddError("access", "Access cannot be empty")
+	}
+
+	if node.Status < 0 || node.Status > 3 {
+		errors.AddError("status", "Invalid status")
+	}
+
+	var saved *Node
+
+	if node.Id != 0 {
+		saved = m.Find(node.Uuid)
+	}
+
+	// an empty slug is generated from the name on save
+	m.validateSlug(node, saved, errors)
+
+	if m.Workflows != nil {
+		if _, err := m.Workflows.Check(saved, node); err != nil {
+			errors.AddError("status", err.Error())
+		}
+	}
+
+	if h, ok := m.Handlers.Get(node).(ValidateNodeHandler); ok {
+		h.Validate(node, m, errors)
+	}
+
+	return !errors.HasErrors(), errors
+}
@@ -0,0 +1,177 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+type HookedHandler struct {
+	UserHandler
+	updates int
+}
+
+func (h *HookedHandler) PreUpdate(node *Node, m NodeManager) error {
+	h.updates++
+
+	return nil
+}
+
+func Test_SqlNodeManager_Reorder(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	events := make([]*ModelEvent, 0)
+	m.OnNotify = func(channel, payload string) {
+		events = append(events, CreateModelEvent(&pq.Notification{Extra: payload}))
+	}
+
+	parent := newCachedUser(m, "menu")
+	a := newCachedUser(m, "a")
+	b := newCachedUser(m, "b")
+	c := newCachedUser(m, "c")
+	other := newCachedUser(m, "other")
+
+	for _, n := range []*Node{a, b, c} {
+		m.Move(n.Uuid, parent.Uuid)
+	}
+
+	events = events[:0]
+
+	affected, err := m.Reorder(parent.Uuid, []Reference{c.Uuid, a.Uuid, b.Uuid}, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), affected) // c is already at the position 0
+
+	assert.Equal(t, 0, m.Find(c.Uuid).Weight)
+	assert.Equal(t, 1, m.Find(a.Uuid).Weight)
+	assert.Equal(t, 2, m.Find(b.Uuid).Weight)
+	assert.Equal(t, 1, m.Find(a.Uuid).Revision)
+
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "Reorder", events[0].Action)
+	assert.Equal(t, parent.Uuid.CleanString(), events[0].Subject)
+
+	uuids := []string{}
+	json.Unmarshal([]byte(events[0].Extra), &uuids)
+	assert.Equal(t, []string{c.Uuid.CleanString(), a.Uuid.CleanString(), b.Uuid.CleanString()}, uuids)
+
+	// the revision mode runs the handler's hooks, only the Reorder event is sent
+	hooked := &HookedHandler{}
+	m.Handlers.(HandlerCollection)["node.user"] = hooked
+	events = events[:0]
+
+	affected, err = m.Reorder(parent.Uuid, []Reference{a.Uuid, b.Uuid}, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+	assert.Equal(t, 2, m.Find(a.Uuid).Revision)
+	assert.Equal(t, 0, m.Find(a.Uuid).Weight)
+	assert.Equal(t, 2, hooked.updates)
+
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "Reorder", events[0].Action)
+	assert.True(t, events[0].NewRevision)
+
+	// not a child, or listed twice
+	_, err = m.Reorder(parent.Uuid, []Reference{a.Uuid, other.Uuid}, false)
+	assert.Equal(t, ErrInvalidOrder, err)
+
+	_, err = m.Reorder(parent.Uuid, []Reference{a.Uuid, a.Uuid}, false)
+	assert.Equal(t, ErrInvalidOrder, err)
+
+	// a deleted child cannot be ordered
+	m.RemoveOne(m.Find(c.Uuid))
+
+	_, err = m.Reorder(parent.Uuid, []Reference{a.Uuid, b.Uuid, c.Uuid}, false)
+	assert.Equal(t, ErrInvalidOrder, err)
+}
+
+func Test_SqlNodeManager_Move_Redirects(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	site := newCachedUser(m, "site")
+	blog := newCachedUser(m, "blog")
+	post := newCachedUser(m, "post")
+	sibling := newCachedUser(m, "sibling")
+
+	m.Move(sibling.Uuid, site.Uuid)
+
+	// only the moved branch is rebuilt
+	sibling = m.Find(sibling.Uuid)
+	sibling.Path = "/custom"
+	m.Save(sibling, false)
+
+	m.Move(post.Uuid, blog.Uuid)
+	m.Move(blog.Uuid, site.Uuid)
+
+	assert.Equal(t, "/custom", m.Find(sibling.Uuid).Path)
+	assert.Equal(t, "/site/blog/post", m.Find(post.Uuid).Path)
+	assert.Equal(t, []Reference{site.Uuid, blog.Uuid}, m.Find(post.Uuid).Parents)
+
+	assert.Equal(t, post.Uuid, m.FindRedirect("/post").Uuid)
+	assert.Equal(t, post.Uuid, m.FindRedirect("/blog/post").Uuid)
+	assert.Equal(t, blog.Uuid, m.FindRedirect("/blog").Uuid)
+	assert.Nil(t, m.FindRedirect("/site/blog/post"))
+	assert.Nil(t, m.FindRedirect("/missing"))
+
+	// a path used again is not redirected anymore
+	m.Move(post.Uuid, site.Uuid)
+	assert.Equal(t, post.Uuid, m.FindRedirect("/site/blog/post").Uuid)
+
+	m.Move(post.Uuid, blog.Uuid)
+	assert.Nil(t, m.FindRedirect("/site/blog/post"))
+	assert.Equal(t, post.Uuid, m.FindRedirect("/site/post").Uuid)
+
+	// the redirects of a deleted node are ignored
+	post = m.Find(post.Uuid)
+	m.RemoveOne(post)
+	assert.Nil(t, m.FindRedirect("/post"))
+}
+
+func Test_SqlNodeManager_Save_Redirects(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	blog := newCachedUser(m, "blog")
+	post := newCachedUser(m, "post")
+	custom := newCachedUser(m, "custom")
+
+	m.Move(post.Uuid, blog.Uuid)
+	m.Move(custom.Uuid, blog.Uuid)
+
+	custom = m.Find(custom.Uuid)
+	custom.Path = "/custom"
+	m.Save(custom, false)
+
+	// the path follows the slug, the descendants are rebuilt
+	blog = m.Find(blog.Uuid)
+	blog.Slug = "news"
+	_, err := m.Save(blog, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/news", m.Find(blog.Uuid).Path)
+	assert.Equal(t, "/news/post", m.Find(post.Uuid).Path)
+	assert.Equal(t, "/custom", m.Find(custom.Uuid).Path)
+
+	assert.Equal(t, blog.Uuid, m.FindRedirect("/blog").Uuid)
+	assert.Equal(t, post.Uuid, m.FindRedirect("/blog/post").Uuid)
+	assert.Equal(t, custom.Uuid, m.FindRedirect("/blog/custom").Uuid)
+
+	// a path set by the client is kept
+	post = m.Find(post.Uuid)
+	post.Path = "/archives/post"
+	m.Save(post, false)
+
+	assert.Equal(t, "/archives/post", m.Find(post.Uuid).Path)
+	assert.Equal(t, post.Uuid, m.FindRedirect("/news/post").Uuid)
+	assert.Equal(t, post.Uuid, m.FindRedirect("/blog/post").Uuid)
+}
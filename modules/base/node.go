@@ -95,30 +95,32 @@ func (p Modules) Get(name string) (interface{}, error) {
 }
 
 type Node struct {
-	Id         int         `json:"-"`
-	Uuid       Reference   `json:"uuid"`
-	Type       string      `json:"type"`
-	Name       string      `json:"name"`
-	Slug       string      `json:"slug"`
-	Path       string      `json:"path"`
-	Data       interface{} `json:"data"`
-	Meta       interface{} `json:"meta"`
-	Status     int         `json:"status"`
-	Weight     int         `json:"weight"`
-	Revision   int         `json:"revision"`
-	Version    int         `json:"version"`
-	CreatedAt  time.Time   `json:"created_at"`
-	UpdatedAt  time.Time   `json:"updated_at"`
-	Enabled    bool        `json:"enabled"`
-	Deleted    bool        `json:"deleted"`
-	Parents    []Reference `json:"parents"`
-	UpdatedBy  Reference   `json:"updated_by"`
-	CreatedBy  Reference   `json:"created_by"`
-	ParentUuid Reference   `json:"parent_uuid"`
-	SetUuid    Reference   `json:"set_uuid"`
-	Source     Reference   `json:"source"`
-	Modules    Modules     `json:"modules"`
-	Access     []string    `json:"access"` // key => roles required to access the nodes
+	Id          int         `json:"-"`
+	Uuid        Reference   `json:"uuid"`
+	Type        string      `json:"type"`
+	Name        string      `json:"name"`
+	Slug        string      `json:"slug"`
+	Path        string      `json:"path"`
+	Data        interface{} `json:"data"`
+	Meta        interface{} `json:"meta"`
+	Status      int         `json:"status"`
+	Weight      int         `json:"weight"`
+	Revision    int         `json:"revision"`
+	Version     int         `json:"version"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+	Enabled     bool        `json:"enabled"`
+	Deleted     bool        `json:"deleted"`
+	Parents     []Reference `json:"parents"`
+	UpdatedBy   Reference   `json:"updated_by"`
+	CreatedBy   Reference   `json:"created_by"`
+	ParentUuid  Reference   `json:"parent_uuid"`
+	SetUuid     Reference   `json:"set_uuid"`
+	Source      Reference   `json:"source"`
+	Modules     Modules     `json:"modules"`
+	Access      []string    `json:"access"`       // key => roles required to access the nodes
+	PublishAt   *time.Time  `json:"publish_at"`   // the node is enabled by the publisher at this date
+	UnpublishAt *time.Time  `json:"unpublish_at"` // the node is disabled by the publisher at this date
 }
 
 func (node *Node) UniqueId() string {
@@ -172,5 +174,7 @@ func DumpNode(node *Node) {
 	fmt.Printf(" Parents:    %v\n", node.Parents)
 	fmt.Printf(" SetUuid:    %s\n", node.SetUuid)
 	fmt.Printf(" Source:     %s\n", node.Source)
+	fmt.Printf(" PublishAt:  %v\n", node.PublishAt)
+	fmt.Printf(" UnpublishAt: %v\n", node.UnpublishAt)
 	fmt.Printf(" <<< End Node\n")
 }
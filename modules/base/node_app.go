@@ -10,10 +10,15 @@ import (
 	"fmt"
 	tpl "html/template"
 	"reflect"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/rande/goapp"
 	"github.com/rande/gonode/core/config"
+	"github.com/rande/gonode/core/helper"
+	"github.com/rande/gonode/core/migration"
 	"github.com/rande/gonode/core/security"
+	"github.com/rande/gonode/core/vault"
 	"github.com/rande/gonode/modules/template"
 
 	log "github.com/sirupsen/logrus"
@@ -42,6 +47,13 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 			return ViewHandlerCollection{}
 		})
 
+		app.Set("gonode.scheduler", func(app *goapp.App) interface{} {
+			scheduler := NewScheduler(app.Get("logger").(*log.Logger))
+			scheduler.Claims = app.Get("gonode.claims").(*Claims)
+
+			return scheduler
+		})
+
 		app.Set("gonode.security.voter.access", func(app *goapp.App) interface{} {
 			return &AccessVoter{}
 		})
@@ -74,16 +86,95 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 	})
 
 	l.Prepare(func(app *goapp.App) error {
+		migrator := app.Get("gonode.migrator").(*migration.Migrator)
+		for _, m := range append(GetMigrations(), GetSqliteMigrations()...) {
+			helper.PanicOnError(migrator.Add(m))
+		}
+
+		app.Set("gonode.workflows", func(app *goapp.App) interface{} {
+			workflows := NewWorkflows(app.Get("gonode.handler_collection").(Handlers))
+
+			for nodeType, workflow := range conf.Workflows {
+				workflows.Add(nodeType, NewWorkflowFromConfig(workflow))
+			}
+
+			return workflows
+		})
+
 		app.Set("gonode.manager", func(app *goapp.App) interface{} {
-			return &PgNodeManager{
-				Logger:   app.Get("logger").(*log.Logger),
-				Db:       app.Get("gonode.postgres.connection").(*sql.DB),
-				ReadOnly: false,
-				Handlers: app.Get("gonode.handler_collection").(Handlers),
-				Prefix:   conf.Databases["master"].Prefix,
+			return &SqlNodeManager{
+				Logger:     app.Get("logger").(*log.Logger),
+				Db:         app.Get("gonode.postgres.connection").(*sql.DB),
+				ReadOnly:   false,
+				Handlers:   app.Get("gonode.handler_collection").(Handlers),
+				Prefix:     conf.Databases["master"].Prefix,
+				Integrity:  conf.Relations.Integrity,
+				FreezeSlug: conf.Slugs.Freeze,
+				Workflows:  app.Get("gonode.workflows").(*Workflows),
+				Dialect:    app.Get("gonode.dialect").(Dialect),
+			}
+		})
+
+		app.Set("gonode.manager.cache", func(app *goapp.App) interface{} {
+			return NewNodeCache(app.Get("gonode.manager").(*SqlNodeManager), conf.Cache.Size, app.Get("logger").(*log.Logger))
+		})
+
+		app.Set("gonode.outbox", func(app *goapp.App) interface{} {
+			return &Outbox{
+				Manager: app.Get("gonode.manager").(*SqlNodeManager),
+				Logger:  app.Get("logger").(*log.Logger),
+			}
+		})
+
+		app.Set("gonode.jobs", func(app *goapp.App) interface{} {
+			queue := NewJobQueue(app.Get("gonode.manager").(*SqlNodeManager), app.Get("logger").(*log.Logger))
+
+			poll, err := time.ParseDuration(conf.Jobs.Poll)
+			helper.PanicOnError(err)
+
+			queue.Poll = poll
+
+			for name, c := range conf.Jobs.Queues {
+				options, err := NewJobOptionsFromConfig(c)
+				helper.PanicOnError(err)
+
+				queue.Options[name] = options
+			}
+
+			return queue
+		})
+
+		app.Set("gonode.claims", func(app *goapp.App) interface{} {
+			claims := NewClaims(app.Get("gonode.manager").(*SqlNodeManager))
+			claims.Logger = app.Get("logger").(*log.Logger)
+
+			if conf.Listeners.ClaimTTL != "" {
+				ttl, err := time.ParseDuration(conf.Listeners.ClaimTTL)
+				helper.PanicOnError(err)
+
+				claims.TTL = ttl
 			}
+
+			return claims
 		})
 
+		sub := app.Get("gonode.postgres.subscriber").(*Subscriber)
+		sub.Outbox = app.Get("gonode.outbox").(*Outbox)
+		sub.Claims = app.Get("gonode.claims").(*Claims)
+		sub.ListenMessage(app.Get("gonode.jobs").(*JobQueue).Channel(), func(notification *pq.Notification) (int, error) {
+			return app.Get("gonode.jobs").(*JobQueue).Wake(notification)
+		})
+		sub.ListenMessage(conf.Databases["master"].Prefix+"_manager_action", func(notification *pq.Notification) (int, error) {
+			return app.Get("gonode.manager.cache").(*NodeCache).Handle(notification)
+		})
+
+		// the local changes are applied to the cache without waiting for the subscriber
+		app.Get("gonode.manager").(*SqlNodeManager).OnNotify = func(channel, payload string) {
+			if channel == conf.Databases["master"].Prefix+"_manager_action" {
+				app.Get("gonode.manager.cache").(*NodeCache).Handle(&pq.Notification{Channel: channel, Extra: payload})
+			}
+		}
+
 		app.Set("gonode.node.serializer", func(app *goapp.App) interface{} {
 			s := NewSerializer()
 			s.Handlers = app.Get("gonode.handler_collection").(Handlers)
@@ -91,6 +182,147 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 			return s
 		})
 
+		app.Set("gonode.node.schemas", func(app *goapp.App) interface{} {
+			return NewSchemas(app.Get("gonode.handler_collection").(Handlers))
+		})
+
+		app.Set("gonode.trash", func(app *goapp.App) interface{} {
+			return &Trash{
+				Manager: app.Get("gonode.manager").(*SqlNodeManager),
+				Vault:   app.Get("gonode.vault.fs").(*vault.Vault),
+				Logger:  app.Get("logger").(*log.Logger),
+			}
+		})
+
+		app.Set("gonode.copier", func(app *goapp.App) interface{} {
+			return &Copier{
+				Manager: app.Get("gonode.manager").(*SqlNodeManager),
+				Vault:   app.Get("gonode.vault.fs").(*vault.Vault),
+				Logger:  app.Get("logger").(*log.Logger),
+			}
+		})
+
+		app.Set("gonode.locker", func(app *goapp.App) interface{} {
+			ttl, err := time.ParseDuration(conf.Locks.TTL)
+			helper.PanicOnError(err)
+
+			return &Locker{
+				Manager: app.Get("gonode.manager").(*SqlNodeManager),
+				TTL:     ttl,
+				Logger:  app.Get("logger").(*log.Logger),
+			}
+		})
+
+		app.Set("gonode.audit", func(app *goapp.App) interface{} {
+			return &Audit{
+				Manager: app.Get("gonode.manager").(*SqlNodeManager),
+			}
+		})
+
+		app.Set("gonode.exporter", func(app *goapp.App) interface{} {
+			return &Exporter{
+				Manager: app.Get("gonode.manager").(*SqlNodeManager),
+				Vault:   app.Get("gonode.vault.fs").(*vault.Vault),
+				Logger:  app.Get("logger").(*log.Logger),
+			}
+		})
+
+		app.Set("gonode.importer", func(app *goapp.App) interface{} {
+			return &Importer{
+				Manager: app.Get("gonode.manager").(*SqlNodeManager),
+				Vault:   app.Get("gonode.vault.fs").(*vault.Vault),
+				Logger:  app.Get("logger").(*log.Logger),
+			}
+		})
+
+		app.Set("gonode.publisher", func(app *goapp.App) interface{} {
+			return &Publisher{
+				Manager: app.Get("gonode.manager").(*SqlNodeManager),
+				Logger:  app.Get("logger").(*log.Logger),
+			}
+		})
+
+		if conf.Publication.Interval != "" {
+			interval, err := time.ParseDuration(conf.Publication.Interval)
+			helper.PanicOnError(err)
+
+			app.Get("gonode.scheduler").(*Scheduler).AddExclusive("publication.publish", interval, func() error {
+				_, err := app.Get("gonode.publisher").(*Publisher).Run(time.Now())
+
+				return err
+			})
+		}
+
+		if conf.Trash.Interval != "" {
+			interval, err := time.ParseDuration(conf.Trash.Interval)
+			helper.PanicOnError(err)
+
+			retention, err := time.ParseDuration(conf.Trash.Retention)
+			helper.PanicOnError(err)
+
+			app.Get("gonode.scheduler").(*Scheduler).AddExclusive("trash.purge", interval, func() error {
+				_, err := app.Get("gonode.trash").(*Trash).Purge(retention)
+
+				return err
+			})
+		}
+
+		if conf.Locks.Interval != "" {
+			interval, err := time.ParseDuration(conf.Locks.Interval)
+			helper.PanicOnError(err)
+
+			app.Get("gonode.scheduler").(*Scheduler).Add("lock.purge", interval, func() error {
+				_, err := app.Get("gonode.locker").(*Locker).Purge(time.Now())
+
+				return err
+			})
+		}
+
+		if conf.Events.Interval != "" {
+			interval, err := time.ParseDuration(conf.Events.Interval)
+			helper.PanicOnError(err)
+
+			retention, err := time.ParseDuration(conf.Events.Retention)
+			helper.PanicOnError(err)
+
+			app.Get("gonode.scheduler").(*Scheduler).Add("events.purge", interval, func() error {
+				_, err := app.Get("gonode.outbox").(*Outbox).Purge(time.Now().Add(-retention))
+
+				return err
+			})
+		}
+
+		if conf.Jobs.Interval != "" {
+			interval, err := time.ParseDuration(conf.Jobs.Interval)
+			helper.PanicOnError(err)
+
+			retention, err := time.ParseDuration(conf.Jobs.Retention)
+			helper.PanicOnError(err)
+
+			app.Get("gonode.scheduler").(*Scheduler).AddExclusive("jobs.purge", interval, func() error {
+				_, err := app.Get("gonode.jobs").(*JobQueue).Purge(time.Now().Add(-retention))
+
+				return err
+			})
+		}
+
+		return nil
+	})
+
+	l.Run(func(app *goapp.App, state *goapp.GoroutineState) error {
+		app.Get("gonode.scheduler").(*Scheduler).Start()
+
+		if conf.Listeners.InProcess {
+			app.Get("gonode.jobs").(*JobQueue).Start()
+		}
+
+		return nil
+	})
+
+	l.Exit(func(app *goapp.App) error {
+		app.Get("gonode.scheduler").(*Scheduler).Stop()
+		app.Get("gonode.jobs").(*JobQueue).Stop()
+
 		return nil
 	})
 }
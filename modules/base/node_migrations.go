@@ -0,0 +1,425 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"github.com/rande/gonode/core/config"
+	"github.com/rande/gonode/core/migration"
+)
+
+func GetMigrations() []*migration.Migration {
+	return []*migration.Migration{
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 1,
+			Name:    "create nodes and nodes_audit tables",
+			// the statements do not fail if the tables were created by the former /setup/install endpoint
+			Up: `CREATE SEQUENCE IF NOT EXISTS "{prefix}_nodes_id_seq" INCREMENT 1 MINVALUE 0 MAXVALUE 2147483647 START 1 CACHE 1;
+
+			CREATE TABLE IF NOT EXISTS "{prefix}_nodes" (
+				"id" INTEGER DEFAULT nextval('{prefix}_nodes_id_seq'::regclass) NOT NULL UNIQUE,
+				"uuid" UUid NOT NULL,
+				"type" CHARACTER VARYING( 64 ) COLLATE "pg_catalog"."default" NOT NULL,
+				"name" CHARACTER VARYING( 2044 ) COLLATE "pg_catalog"."default" DEFAULT ''::CHARACTER VARYING NOT NULL,
+				"enabled" BOOLEAN DEFAULT 'true' NOT NULL,
+				"current" BOOLEAN DEFAULT 'false' NOT NULL,
+				"revision" INTEGER DEFAULT '1' NOT NULL,
+				"version" INTEGER DEFAULT '1' NOT NULL,
+				"status" INTEGER DEFAULT '0' NOT NULL,
+				"deleted" BOOLEAN DEFAULT 'false' NOT NULL,
+				"data" jsonb DEFAULT '{}'::jsonb NOT NULL,
+				"meta" jsonb DEFAULT '{}'::jsonb NOT NULL,
+				"modules" jsonb DEFAULT '{}'::jsonb NOT NULL,
+				"access" text[] DEFAULT '{}' NOT NULL,
+				"slug" CHARACTER VARYING( 256 ) COLLATE "default" NOT NULL,
+				"path" CHARACTER VARYING( 2000 ) COLLATE "default" NOT NULL,
+				"source" UUid,
+				"set_uuid" UUid,
+				"parent_uuid" UUid,
+				"parents" UUid[],
+				"created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				"created_by" UUid NOT NULL,
+				"updated_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				"updated_by" UUid NOT NULL,
+				"weight" INTEGER DEFAULT '0' NOT NULL,
+				PRIMARY KEY ( "id" ),
+				CONSTRAINT "{prefix}_slug" UNIQUE( "parent_uuid","slug","revision" ),
+				CONSTRAINT "{prefix}_uuid" UNIQUE( "revision","uuid" )
+			);
+
+			CREATE INDEX IF NOT EXISTS "{prefix}_uuid_idx" ON "{prefix}_nodes" USING btree( "uuid" ASC NULLS LAST );
+			CREATE INDEX IF NOT EXISTS "{prefix}_uuid_current_idx" ON "{prefix}_nodes" USING btree( "uuid" ASC NULLS LAST, "current" ASC NULLS LAST );
+			CREATE INDEX IF NOT EXISTS "{prefix}_access_idx" ON "{prefix}_nodes" USING GIN("access");
+
+			CREATE SEQUENCE IF NOT EXISTS "{prefix}_nodes_audit_id_seq" INCREMENT 1 MINVALUE 0 MAXVALUE 2147483647 START 1 CACHE 1;
+
+			CREATE TABLE IF NOT EXISTS "{prefix}_nodes_audit" (
+				"id" INTEGER DEFAULT nextval('{prefix}_nodes_audit_id_seq'::regclass) NOT NULL UNIQUE,
+				"uuid" UUid NOT NULL,
+				"type" CHARACTER VARYING( 64 ) COLLATE "pg_catalog"."default" NOT NULL,
+				"name" CHARACTER VARYING( 2044 ) COLLATE "pg_catalog"."default" DEFAULT ''::CHARACTER VARYING NOT NULL,
+				"enabled" BOOLEAN DEFAULT 'true' NOT NULL,
+				"current" BOOLEAN DEFAULT 'false' NOT NULL,
+				"revision" INTEGER DEFAULT '1' NOT NULL,
+				"version" INTEGER DEFAULT '1' NOT NULL,
+				"status" INTEGER DEFAULT '0' NOT NULL,
+				"deleted" BOOLEAN DEFAULT 'false' NOT NULL,
+				"data" jsonb DEFAULT '{}'::jsonb NOT NULL,
+				"meta" jsonb DEFAULT '{}'::jsonb NOT NULL,
+				"modules" jsonb DEFAULT '{}'::jsonb NOT NULL,
+				"access" text[] DEFAULT '{}' NOT NULL,
+				"slug" CHARACTER VARYING( 256 ) COLLATE "default" NOT NULL,
+				"path" CHARACTER VARYING( 2000 ) COLLATE "default" NOT NULL,
+				"source" UUid,
+				"set_uuid" UUid,
+				"parent_uuid" UUid,
+				"parents" UUid[],
+				"created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				"created_by" UUid NOT NULL,
+				"updated_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				"updated_by" UUid NOT NULL,
+				"weight" INTEGER DEFAULT '0' NOT NULL,
+				PRIMARY KEY ( "id" )
+			);`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes";
+			DROP TABLE IF EXISTS "{prefix}_nodes_audit";
+			DROP INDEX IF EXISTS "{prefix}_uuid_idx";
+			DROP INDEX IF EXISTS "{prefix}_uuid_current_idx";
+			DROP SEQUENCE IF EXISTS "{prefix}_nodes_id_seq" CASCADE;
+			DROP SEQUENCE IF EXISTS "{prefix}_nodes_audit_id_seq" CASCADE;`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 2,
+			Name:    "create nodes_relations table",
+			Up: `CREATE TABLE "{prefix}_nodes_relations" (
+				"id" SERIAL NOT NULL,
+				"source_uuid" UUid NOT NULL,
+				"target_uuid" UUid NOT NULL,
+				"field" CHARACTER VARYING( 64 ) NOT NULL,
+				"created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				PRIMARY KEY ( "id" ),
+				CONSTRAINT "{prefix}_relations_link" UNIQUE( "source_uuid", "target_uuid", "field" )
+			);
+
+			CREATE INDEX "{prefix}_relations_target_idx" ON "{prefix}_nodes_relations" USING btree( "target_uuid" );`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_relations";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 3,
+			Name:    "add publication dates",
+			Up: `ALTER TABLE "{prefix}_nodes" ADD COLUMN "publish_at" TIMESTAMP WITHOUT TIME ZONE NULL, ADD COLUMN "unpublish_at" TIMESTAMP WITHOUT TIME ZONE NULL;
+			ALTER TABLE "{prefix}_nodes_audit" ADD COLUMN "publish_at" TIMESTAMP WITHOUT TIME ZONE NULL, ADD COLUMN "unpublish_at" TIMESTAMP WITHOUT TIME ZONE NULL;
+
+			CREATE INDEX "{prefix}_publish_at_idx" ON "{prefix}_nodes" USING btree( "publish_at" ) WHERE "publish_at" IS NOT NULL;
+			CREATE INDEX "{prefix}_unpublish_at_idx" ON "{prefix}_nodes" USING btree( "unpublish_at" ) WHERE "unpublish_at" IS NOT NULL;`,
+			Down: `DROP INDEX IF EXISTS "{prefix}_publish_at_idx";
+			DROP INDEX IF EXISTS "{prefix}_unpublish_at_idx";
+			ALTER TABLE IF EXISTS "{prefix}_nodes" DROP COLUMN IF EXISTS "publish_at", DROP COLUMN IF EXISTS "unpublish_at";
+			ALTER TABLE IF EXISTS "{prefix}_nodes_audit" DROP COLUMN IF EXISTS "publish_at", DROP COLUMN IF EXISTS "unpublish_at";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 4,
+			Name:    "create nodes_redirects table",
+			Up: `CREATE TABLE "{prefix}_nodes_redirects" (
+				"id" SERIAL NOT NULL,
+				"path" CHARACTER VARYING( 2000 ) NOT NULL,
+				"uuid" UUid NOT NULL,
+				"created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				PRIMARY KEY ( "id" ),
+				CONSTRAINT "{prefix}_redirects_path" UNIQUE( "path" )
+			);
+
+			CREATE INDEX "{prefix}_redirects_uuid_idx" ON "{prefix}_nodes_redirects" USING btree( "uuid" );`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_redirects";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 5,
+			Name:    "create nodes_locks table",
+			Up: `CREATE TABLE "{prefix}_nodes_locks" (
+				"id" SERIAL NOT NULL,
+				"uuid" UUid NOT NULL,
+				"owner" CHARACTER VARYING( 256 ) NOT NULL,
+				"expires_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				"created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				PRIMARY KEY ( "id" ),
+				CONSTRAINT "{prefix}_locks_uuid" UNIQUE( "uuid" )
+			);`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_locks";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 6,
+			Name:    "create nodes_events and nodes_events_cursors tables",
+			Up: `CREATE TABLE "{prefix}_nodes_events" (
+				"id" BIGSERIAL NOT NULL,
+				"channel" CHARACTER VARYING( 128 ) NOT NULL,
+				"payload" TEXT NOT NULL,
+				"created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				PRIMARY KEY ( "id" )
+			);
+
+			CREATE INDEX "{prefix}_events_created_at_idx" ON "{prefix}_nodes_events" USING btree( "created_at" );
+
+			CREATE TABLE "{prefix}_nodes_events_cursors" (
+				"consumer" CHARACTER VARYING( 128 ) NOT NULL,
+				"event_id" BIGINT NOT NULL,
+				"updated_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				PRIMARY KEY ( "consumer" )
+			);`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_events_cursors";
+			DROP TABLE IF EXISTS "{prefix}_nodes_events";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 7,
+			Name:    "create nodes_jobs table",
+			Up: `CREATE TABLE "{prefix}_nodes_jobs" (
+				"id" BIGSERIAL NOT NULL,
+				"queue" CHARACTER VARYING( 128 ) NOT NULL,
+				"payload" TEXT NOT NULL,
+				"status" INTEGER NOT NULL,
+				"attempts" INTEGER DEFAULT '0' NOT NULL,
+				"max_attempts" INTEGER NOT NULL,
+				"error" TEXT DEFAULT '' NOT NULL,
+				"run_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				"locked_until" TIMESTAMP WITHOUT TIME ZONE,
+				"created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				"updated_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				PRIMARY KEY ( "id" )
+			);
+
+			CREATE INDEX "{prefix}_jobs_queue_idx" ON "{prefix}_nodes_jobs" USING btree( "queue", "status", "run_at" );`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_jobs";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 8,
+			Name:    "create nodes_claims table",
+			Up: `CREATE TABLE "{prefix}_nodes_claims" (
+				"name" CHARACTER VARYING( 128 ) NOT NULL,
+				"owner" CHARACTER VARYING( 256 ) NOT NULL,
+				"expires_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				"updated_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				PRIMARY KEY ( "name" )
+			);`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_claims";`,
+		},
+	}
+}
+
+// GetSqliteMigrations returns the SQLite version of the base migrations, the arrays are stored
+// as JSON arrays. The first version already contains the publication columns as SQLite cannot
+// drop a column in an idempotent way.
+func GetSqliteMigrations() []*migration.Migration {
+	return []*migration.Migration{
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 1,
+			Name:    "create nodes and nodes_audit tables",
+			Up: `CREATE TABLE IF NOT EXISTS "{prefix}_nodes" (
+				"id" INTEGER NOT NULL,
+				"uuid" CHARACTER( 36 ) NOT NULL,
+				"type" CHARACTER VARYING( 64 ) NOT NULL,
+				"name" CHARACTER VARYING( 2044 ) DEFAULT '' NOT NULL,
+				"enabled" BOOLEAN DEFAULT true NOT NULL,
+				"current" BOOLEAN DEFAULT false NOT NULL,
+				"revision" INTEGER DEFAULT 1 NOT NULL,
+				"version" INTEGER DEFAULT 1 NOT NULL,
+				"status" INTEGER DEFAULT 0 NOT NULL,
+				"deleted" BOOLEAN DEFAULT false NOT NULL,
+				"data" JSON DEFAULT '{}' NOT NULL,
+				"meta" JSON DEFAULT '{}' NOT NULL,
+				"modules" JSON DEFAULT '{}' NOT NULL,
+				"access" JSON DEFAULT '[]' NOT NULL,
+				"slug" CHARACTER VARYING( 256 ) NOT NULL,
+				"path" CHARACTER VARYING( 2000 ) NOT NULL,
+				"source" CHARACTER( 36 ),
+				"set_uuid" CHARACTER( 36 ),
+				"parent_uuid" CHARACTER( 36 ),
+				"parents" JSON DEFAULT '[]',
+				"created_at" TIMESTAMP NOT NULL,
+				"created_by" CHARACTER( 36 ) NOT NULL,
+				"updated_at" TIMESTAMP NOT NULL,
+				"updated_by" CHARACTER( 36 ) NOT NULL,
+				"weight" INTEGER DEFAULT 0 NOT NULL,
+				"publish_at" TIMESTAMP NULL,
+				"unpublish_at" TIMESTAMP NULL,
+				PRIMARY KEY ( "id" ),
+				CONSTRAINT "{prefix}_slug" UNIQUE( "parent_uuid","slug","revision" ),
+				CONSTRAINT "{prefix}_uuid" UNIQUE( "revision","uuid" )
+			);
+
+			CREATE INDEX IF NOT EXISTS "{prefix}_uuid_idx" ON "{prefix}_nodes" ( "uuid" );
+			CREATE INDEX IF NOT EXISTS "{prefix}_uuid_current_idx" ON "{prefix}_nodes" ( "uuid", "current" );
+
+			CREATE TABLE IF NOT EXISTS "{prefix}_nodes_audit" (
+				"id" INTEGER NOT NULL,
+				"uuid" CHARACTER( 36 ) NOT NULL,
+				"type" CHARACTER VARYING( 64 ) NOT NULL,
+				"name" CHARACTER VARYING( 2044 ) DEFAULT '' NOT NULL,
+				"enabled" BOOLEAN DEFAULT true NOT NULL,
+				"current" BOOLEAN DEFAULT false NOT NULL,
+				"revision" INTEGER DEFAULT 1 NOT NULL,
+				"version" INTEGER DEFAULT 1 NOT NULL,
+				"status" INTEGER DEFAULT 0 NOT NULL,
+				"deleted" BOOLEAN DEFAULT false NOT NULL,
+				"data" JSON DEFAULT '{}' NOT NULL,
+				"meta" JSON DEFAULT '{}' NOT NULL,
+				"modules" JSON DEFAULT '{}' NOT NULL,
+				"access" JSON DEFAULT '[]' NOT NULL,
+				"slug" CHARACTER VARYING( 256 ) NOT NULL,
+				"path" CHARACTER VARYING( 2000 ) NOT NULL,
+				"source" CHARACTER( 36 ),
+				"set_uuid" CHARACTER( 36 ),
+				"parent_uuid" CHARACTER( 36 ),
+				"parents" JSON DEFAULT '[]',
+				"created_at" TIMESTAMP NOT NULL,
+				"created_by" CHARACTER( 36 ) NOT NULL,
+				"updated_at" TIMESTAMP NOT NULL,
+				"updated_by" CHARACTER( 36 ) NOT NULL,
+				"weight" INTEGER DEFAULT 0 NOT NULL,
+				"publish_at" TIMESTAMP NULL,
+				"unpublish_at" TIMESTAMP NULL,
+				PRIMARY KEY ( "id" )
+			);`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes";
+			DROP TABLE IF EXISTS "{prefix}_nodes_audit";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 2,
+			Name:    "create nodes_relations table",
+			Up: `CREATE TABLE "{prefix}_nodes_relations" (
+				"id" INTEGER NOT NULL,
+				"source_uuid" CHARACTER( 36 ) NOT NULL,
+				"target_uuid" CHARACTER( 36 ) NOT NULL,
+				"field" CHARACTER VARYING( 64 ) NOT NULL,
+				"created_at" TIMESTAMP NOT NULL,
+				PRIMARY KEY ( "id" ),
+				CONSTRAINT "{prefix}_relations_link" UNIQUE( "source_uuid", "target_uuid", "field" )
+			);
+
+			CREATE INDEX "{prefix}_relations_target_idx" ON "{prefix}_nodes_relations" ( "target_uuid" );`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_relations";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 3,
+			Name:    "add publication dates",
+			Up: `CREATE INDEX "{prefix}_publish_at_idx" ON "{prefix}_nodes" ( "publish_at" ) WHERE "publish_at" IS NOT NULL;
+			CREATE INDEX "{prefix}_unpublish_at_idx" ON "{prefix}_nodes" ( "unpublish_at" ) WHERE "unpublish_at" IS NOT NULL;`,
+			Down: `DROP INDEX IF EXISTS "{prefix}_publish_at_idx";
+			DROP INDEX IF EXISTS "{prefix}_unpublish_at_idx";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 4,
+			Name:    "create nodes_redirects table",
+			Up: `CREATE TABLE "{prefix}_nodes_redirects" (
+				"id" INTEGER NOT NULL,
+				"path" CHARACTER VARYING( 2000 ) NOT NULL,
+				"uuid" CHARACTER( 36 ) NOT NULL,
+				"created_at" TIMESTAMP NOT NULL,
+				PRIMARY KEY ( "id" ),
+				CONSTRAINT "{prefix}_redirects_path" UNIQUE( "path" )
+			);
+
+			CREATE INDEX "{prefix}_redirects_uuid_idx" ON "{prefix}_nodes_redirects" ( "uuid" );`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_redirects";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 5,
+			Name:    "create nodes_locks table",
+			Up: `CREATE TABLE "{prefix}_nodes_locks" (
+				"id" INTEGER NOT NULL,
+				"uuid" CHARACTER( 36 ) NOT NULL,
+				"owner" CHARACTER VARYING( 256 ) NOT NULL,
+				"expires_at" TIMESTAMP NOT NULL,
+				"created_at" TIMESTAMP NOT NULL,
+				PRIMARY KEY ( "id" ),
+				CONSTRAINT "{prefix}_locks_uuid" UNIQUE( "uuid" )
+			);`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_locks";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 6,
+			Name:    "create nodes_events and nodes_events_cursors tables",
+			Up: `CREATE TABLE "{prefix}_nodes_events" (
+				"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+				"channel" CHARACTER VARYING( 128 ) NOT NULL,
+				"payload" TEXT NOT NULL,
+				"created_at" TIMESTAMP NOT NULL
+			);
+
+			CREATE INDEX "{prefix}_events_created_at_idx" ON "{prefix}_nodes_events" ( "created_at" );
+
+			CREATE TABLE "{prefix}_nodes_events_cursors" (
+				"consumer" CHARACTER VARYING( 128 ) NOT NULL,
+				"event_id" INTEGER NOT NULL,
+				"updated_at" TIMESTAMP NOT NULL,
+				PRIMARY KEY ( "consumer" )
+			);`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_events_cursors";
+			DROP TABLE IF EXISTS "{prefix}_nodes_events";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 7,
+			Name:    "create nodes_jobs table",
+			Up: `CREATE TABLE "{prefix}_nodes_jobs" (
+				"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+				"queue" CHARACTER VARYING( 128 ) NOT NULL,
+				"payload" TEXT NOT NULL,
+				"status" INTEGER NOT NULL,
+				"attempts" INTEGER DEFAULT '0' NOT NULL,
+				"max_attempts" INTEGER NOT NULL,
+				"error" TEXT DEFAULT '' NOT NULL,
+				"run_at" TIMESTAMP NOT NULL,
+				"locked_until" TIMESTAMP,
+				"created_at" TIMESTAMP NOT NULL,
+				"updated_at" TIMESTAMP NOT NULL
+			);
+
+			CREATE INDEX "{prefix}_jobs_queue_idx" ON "{prefix}_nodes_jobs" ( "queue", "status", "run_at" );`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_jobs";`,
+		},
+		{
+			Module:  "base",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 8,
+			Name:    "create nodes_claims table",
+			Up: `CREATE TABLE "{prefix}_nodes_claims" (
+				"name" CHARACTER VARYING( 128 ) NOT NULL,
+				"owner" CHARACTER VARYING( 256 ) NOT NULL,
+				"expires_at" TIMESTAMP NOT NULL,
+				"updated_at" TIMESTAMP NOT NULL,
+				PRIMARY KEY ( "name" )
+			);`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_nodes_claims";`,
+		},
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/rande/gonode/core/config"
+	"github.com/rande/gonode/core/migration"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Migrations_Existing_Schema(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	db.SetMaxOpenConns(1)
+
+	migrator := migration.NewMigrator(db, "test", nil)
+	migrator.Driver = config.DATABASE_SQLITE
+
+	for _, m := range append(GetMigrations(), GetSqliteMigrations()...) {
+		assert.NoError(t, migrator.Add(m))
+	}
+
+	// the tables created by the former /setup/install endpoint, without the migrations table
+	_, err = db.Exec(strings.Replace(GetSqliteMigrations()[0].Up, "{prefix}", "test", -1))
+	assert.NoError(t, err)
+
+	_, err = db.Exec(`INSERT INTO "test_nodes" (id, uuid, type, slug, path, created_at, created_by, updated_at, updated_by)
+		VALUES (1, '11111111-1111-1111-1111-111111111111', 'core.user', 'user', '', CURRENT_TIMESTAMP, '', CURRENT_TIMESTAMP, '')`)
+	assert.NoError(t, err)
+
+	done, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrator.Migrations()), len(done))
+
+	// the existing rows are kept
+	count := 0
+	assert.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM "test_nodes"`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	status, err := migrator.Status()
+	assert.NoError(t, err)
+
+	for _, s := range status {
+		assert.True(t, s.Applied)
+	}
+
+	done, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(done))
+}
@@ -0,0 +1,219 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	log "github.com/sirupsen/logrus"
+)
+
+// Event is a notification stored in the <prefix>_nodes_events table
+type Event struct {
+	Id        int64     `json:"id"`
+	Channel   string    `json:"channel"`
+	Payload   string    `json:"payload"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// storeEvents writes the transaction's notifications in the outbox, just before the commit. The
+// table is locked until the commit, so the ids are assigned in the commit order: a consumer
+// reading the events after its cursor cannot miss an event committed later with a lower id.
+func (m *SqlNodeManager) storeEvents() error {
+	if len(m.notifications) == 0 {
+		return nil
+	}
+
+	table := m.Prefix + "_nodes_events"
+
+	if lock := m.dialect().LockTable(table); lock != "" {
+		if _, err := m.runner().Exec(lock); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+
+	for _, n := range m.notifications {
+		_, err := sq.Insert(table).
+			Columns("channel", "payload", "created_at").
+			Values(n.channel, n.payload, now).
+			PlaceholderFormat(sq.Dollar).
+			RunWith(m.runner()).
+			Exec()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Outbox reads the notifications stored by the manager, so an event missed by a consumer
+// (ie, disconnected or restarting) can be delivered again. Each consumer keeps the id of the
+// last handled event, its cursor.
+type Outbox struct {
+	Manager *SqlNodeManager
+	Logger  *log.Logger
+}
+
+func (o *Outbox) table() string {
+	return o.Manager.Prefix + "_nodes_events"
+}
+
+// Events returns the events of the channels stored after the event id, the oldest first
+func (o *Outbox) Events(channels []string, after int64, limit uint64) ([]*Event, error) {
+	query := sq.Select("id", "channel", "payload", "created_at").
+		From(o.table()).
+		Where(sq.Gt{"id": after}).
+		OrderBy("id ASC").
+		Limit(limit)
+
+	if len(channels) > 0 {
+		query = query.Where(sq.Eq{"channel": channels})
+	}
+
+	return o.find(query)
+}
+
+// Range returns the events of the channels created between the two dates, the oldest first.
+// All the channels are returned if channels is empty.
+func (o *Outbox) Range(channels []string, from, to time.Time) ([]*Event, error) {
+	query := sq.Select("id", "channel", "payload", "created_at").
+		From(o.table()).
+		Where(sq.GtOrEq{"created_at": from}).
+		Where(sq.Lt{"created_at": to}).
+		OrderBy("id ASC")
+
+	if len(channels) > 0 {
+		query = query.Where(sq.Eq{"channel": channels})
+	}
+
+	return o.find(query)
+}
+
+func (o *Outbox) find(query sq.SelectBuilder) ([]*Event, error) {
+	rows, err := query.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(o.Manager.runner()).
+		Query()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	events := make([]*Event, 0)
+
+	for rows.Next() {
+		e := &Event{}
+
+		if err := rows.Scan(&e.Id, &e.Channel, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// Last returns the id of the last stored event, 0 if the outbox is empty
+func (o *Outbox) Last() (int64, error) {
+	var id sql.NullInt64
+
+	err := o.Manager.runner().QueryRow(fmt.Sprintf(`SELECT MAX(id) FROM %s`, o.table())).Scan(&id)
+
+	return id.Int64, err
+}
+
+// Cursor returns the id of the last event handled by the consumer, false is returned if the
+// consumer has never been registered
+func (o *Outbox) Cursor(consumer string) (int64, bool, error) {
+	var id int64
+
+	err := o.Manager.runner().
+		QueryRow(fmt.Sprintf(`SELECT event_id FROM %s_cursors WHERE consumer = $1`, o.table()), consumer).
+		Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	return id, true, nil
+}
+
+// SetCursor stores the id of the last event handled by the consumer
+func (o *Outbox) SetCursor(consumer string, id int64) error {
+	_, err := o.Manager.runner().Exec(fmt.Sprintf(`INSERT INTO %s_cursors (consumer, event_id, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (consumer) DO UPDATE SET event_id = excluded.event_id, updated_at = excluded.updated_at`, o.table()),
+		consumer, id, time.Now())
+
+	return err
+}
+
+// Redeliver sends again the events created between the two dates, the events are stored as
+// new events so the consumers handle them again. All the channels are used if channels is empty.
+func (o *Outbox) Redeliver(channels []string, from, to time.Time) (int, error) {
+	events, err := o.Range(channels, from, to)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = o.Manager.Transaction(func(tx NodeManager) error {
+		for _, e := range events {
+			tx.Notify(e.Channel, e.Payload)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if o.Logger != nil {
+		o.Logger.WithFields(log.Fields{
+			"module": "node.outbox",
+			"count":  len(events),
+			"from":   from,
+			"to":     to,
+		}).Info("redeliver events")
+	}
+
+	return len(events), nil
+}
+
+// Purge removes the events created before the date, a consumer stopped for a longer period
+// cannot replay the removed events.
+func (o *Outbox) Purge(before time.Time) (int, error) {
+	r, err := o.Manager.runner().Exec(fmt.Sprintf(`DELETE FROM %s WHERE created_at < $1`, o.table()), before)
+
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := r.RowsAffected()
+
+	if o.Logger != nil && count > 0 {
+		o.Logger.WithFields(log.Fields{
+			"module": "node.outbox",
+			"count":  count,
+		}).Info("purge events")
+	}
+
+	return int(count), err
+}
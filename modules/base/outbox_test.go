@@ -0,0 +1,339 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	pq "github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Outbox_Events(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	o := &Outbox{Manager: m}
+
+	last, err := o.Last()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), last)
+
+	node := newCachedUser(m, "thomas")
+
+	// a rollback removes the events
+	m.Transaction(func(tx NodeManager) error {
+		tx.Notify("media_file_download", node.Uuid.CleanString())
+
+		return errors.New("rollback")
+	})
+
+	m.Notify("media_file_download", node.Uuid.CleanString())
+
+	events, err := o.Events(nil, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(events))
+
+	assert.Equal(t, "test_manager_action", events[0].Channel)
+	assert.Equal(t, "Create", CreateModelEvent(&pq.Notification{Extra: events[0].Payload}).Action)
+	assert.Equal(t, "media_file_download", events[1].Channel)
+	assert.Equal(t, node.Uuid.CleanString(), events[1].Payload)
+
+	events, err = o.Events([]string{"media_file_download"}, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+
+	events, err = o.Events(nil, events[0].Id, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(events))
+
+	// the cursors
+	_, ok, err := o.Cursor("media")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, o.SetCursor("media", 1))
+	assert.NoError(t, o.SetCursor("media", 2))
+
+	cursor, ok, err := o.Cursor("media")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), cursor)
+
+	// the redelivered events are stored as new events
+	count, err := o.Redeliver([]string{"media_file_download"}, time.Now().Add(-time.Hour), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	events, err = o.Events(nil, 2, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, node.Uuid.CleanString(), events[0].Payload)
+
+	count, err = o.Purge(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// the ids are not reused
+	m.Notify("media_file_download", node.Uuid.CleanString())
+
+	last, err = o.Last()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), last)
+}
+
+func Test_Subscriber_Consume(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	s := NewLocalSubscriber(log.New())
+	s.Outbox = &Outbox{Manager: m}
+	m.Dialect = &SqliteDialect{Subscriber: s}
+
+	received := make(chan string, 10)
+
+	s.Consume("test.consumer", "test_channel", func(notification *pq.Notification) (int, error) {
+		received <- notification.Extra
+
+		return PubSubListenContinue, nil
+	})
+
+	// the events sent before the first registration are ignored
+	m.Notify("test_channel", "before")
+
+	s.Register()
+	assert.NoError(t, s.Replay("test.consumer"))
+
+	m.Notify("test_channel", "live")
+
+	select {
+	case payload := <-received:
+		assert.Equal(t, "live", payload)
+	case <-time.After(time.Second):
+		assert.Fail(t, "the event has not been dispatched")
+	}
+
+	// the events sent while the subscriber is stopped are replayed
+	s.Stop()
+
+	m.Notify("test_channel", "missed 1")
+	m.Notify("test_channel", "missed 2")
+	m.Notify("other_channel", "other")
+
+	s.Register()
+
+	for _, expected := range []string{"missed 1", "missed 2"} {
+		select {
+		case payload := <-received:
+			assert.Equal(t, expected, payload)
+		case <-time.After(time.Second):
+			assert.Fail(t, "the event has not been replayed")
+		}
+	}
+
+	s.Stop()
+
+	assert.Error(t, s.Replay("unknown"))
+}
+
+func Test_Subscriber_Consume_Failure(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	s := NewLocalSubscriber(log.New())
+	s.Outbox = &Outbox{Manager: m}
+	s.Attempts = 2
+	s.Backoff = 50 * time.Millisecond
+
+	handled := make([]string, 0)
+	failures := map[string]int{"flaky": 1, "broken": 10}
+
+	s.Consume("test.consumer", "test_channel", func(notification *pq.Notification) (int, error) {
+		handled = append(handled, notification.Extra)
+
+		if failures[notification.Extra] > 0 {
+			failures[notification.Extra]--
+
+			return PubSubListenContinue, errors.New("database unavailable")
+		}
+
+		return PubSubListenContinue, nil
+	})
+
+	// the subscriber is not registered, the events are only handled with Replay
+	assert.NoError(t, s.Replay("test.consumer"))
+
+	m.Notify("test_channel", "flaky")
+	m.Notify("test_channel", "next")
+
+	// the cursor stays on the previous event, the next event waits
+	assert.NoError(t, s.Replay("test.consumer"))
+	assert.Equal(t, []string{"flaky"}, handled)
+
+	// the event is not tried again before the backoff
+	assert.NoError(t, s.Replay("test.consumer"))
+	assert.Equal(t, []string{"flaky"}, handled)
+
+	time.Sleep(60 * time.Millisecond)
+
+	assert.NoError(t, s.Replay("test.consumer"))
+	assert.Equal(t, []string{"flaky", "flaky", "next"}, handled)
+
+	// the event is skipped once the attempts are exhausted
+	m.Notify("test_channel", "broken")
+	m.Notify("test_channel", "last")
+
+	assert.NoError(t, s.Replay("test.consumer"))
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, s.Replay("test.consumer"))
+	assert.Equal(t, []string{"flaky", "flaky", "next", "broken", "broken", "last"}, handled)
+
+	cursor, _, _ := s.Outbox.Cursor("test.consumer")
+	last, _ := s.Outbox.Last()
+	assert.Equal(t, last, cursor)
+}
+
+func Test_Subscriber_Consume_Passive(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	s := NewLocalSubscriber(log.New())
+	s.Outbox = &Outbox{Manager: m}
+	s.Passive = true
+	m.Dialect = &SqliteDialect{Subscriber: s}
+
+	received := make(chan string, 10)
+
+	s.Consume("test.consumer", "test_channel", func(notification *pq.Notification) (int, error) {
+		received <- notification.Extra
+
+		return PubSubListenContinue, nil
+	})
+
+	s.Register()
+	defer s.Stop()
+
+	m.Notify("test_channel", "live")
+
+	select {
+	case <-received:
+		assert.Fail(t, "the passive subscriber has dispatched the event")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the consumer is not registered
+	assert.Error(t, s.Replay("test.consumer"))
+}
+
+func Test_Subscriber_ConsumeExclusive(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	received := make([]string, 0)
+
+	subscriber := func(claims *Claims) *Subscriber {
+		s := NewLocalSubscriber(log.New())
+		s.Outbox = &Outbox{Manager: m}
+		s.Claims = claims
+
+		s.ConsumeExclusive("test.consumer", "test_channel", func(notification *pq.Notification) (int, error) {
+			received = append(received, claims.Owner+" "+notification.Extra)
+
+			return PubSubListenContinue, nil
+		})
+
+		return s
+	}
+
+	// the claim of the first instance expires right away, like a crashed instance
+	crashed := NewClaims(m)
+	crashed.Owner = "crashed"
+	crashed.TTL = -time.Second
+
+	running := NewClaims(m)
+	running.Owner = "running"
+
+	first := subscriber(crashed)
+	second := subscriber(running)
+
+	assert.NoError(t, first.Replay("test.consumer"))
+
+	m.Notify("test_channel", "event 1")
+
+	assert.NoError(t, first.Replay("test.consumer"))
+
+	m.Notify("test_channel", "event 2")
+
+	// the second instance takes over from the shared cursor
+	assert.NoError(t, second.Replay("test.consumer"))
+
+	m.Notify("test_channel", "event 3")
+
+	// the claim of the second instance is active
+	assert.NoError(t, first.Replay("test.consumer"))
+	assert.NoError(t, second.Replay("test.consumer"))
+
+	assert.Equal(t, []string{"crashed event 1", "running event 2", "running event 3"}, received)
+}
+
+func Test_Subscriber_ConsumeExclusive_LongHandler(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	started := make(chan struct{})
+	handled := make(chan string, 10)
+
+	subscriber := func(owner string, delay time.Duration) *Subscriber {
+		claims := NewClaims(m)
+		claims.Owner = owner
+		claims.TTL = 90 * time.Millisecond
+
+		s := NewLocalSubscriber(log.New())
+		s.Outbox = &Outbox{Manager: m}
+		s.Claims = claims
+
+		s.ConsumeExclusive("test.consumer", "test_channel", func(notification *pq.Notification) (int, error) {
+			if delay > 0 {
+				close(started)
+				time.Sleep(delay)
+			}
+
+			handled <- owner + " " + notification.Extra
+
+			return PubSubListenContinue, nil
+		})
+
+		return s
+	}
+
+	// the handler of the first instance runs longer than the claim's TTL
+	first := subscriber("first", 300*time.Millisecond)
+	second := subscriber("second", 0)
+
+	assert.NoError(t, first.Replay("test.consumer"))
+
+	m.Notify("test_channel", "event")
+
+	go first.Replay("test.consumer")
+
+	<-started
+	time.Sleep(200 * time.Millisecond)
+
+	// the claim is still held by the first instance
+	assert.NoError(t, second.Replay("test.consumer"))
+
+	select {
+	case payload := <-handled:
+		assert.Equal(t, "first event", payload)
+	case <-time.After(time.Second):
+		assert.Fail(t, "the event has not been handled")
+	}
+
+	assert.Equal(t, 0, len(handled))
+}
@@ -0,0 +1,120 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	log "github.com/sirupsen/logrus"
+)
+
+// roles allowed to see the nodes out of their publication window
+var EditorRoles = []string{"node:api:master", "node:editor"}
+
+func IsEditor(roles []string) bool {
+	for _, role := range roles {
+		for _, editor := range EditorRoles {
+			if role == editor {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsPublished returns true if the date is inside the node's publication window
+func IsPublished(node *Node, now time.Time) bool {
+	if node.PublishAt != nil && node.PublishAt.After(now) {
+		return false
+	}
+
+	if node.UnpublishAt != nil && !node.UnpublishAt.After(now) {
+		return false
+	}
+
+	return true
+}
+
+// WherePublished restricts the query to the nodes inside their publication window
+func WherePublished(query sq.SelectBuilder, now time.Time) sq.SelectBuilder {
+	return query.
+		Where("(publish_at IS NULL OR publish_at <= ?)", now).
+		Where("(unpublish_at IS NULL OR unpublish_at > ?)", now)
+}
+
+// Publisher enables the nodes when the publish date is reached, and disables the nodes when the
+// unpublish date is reached. The date is reset once the node is altered, so an editor can
+// still change the enabled flag later on. The status is not altered, it is managed by the
+// node's workflow.
+type Publisher struct {
+	Manager *SqlNodeManager
+	Logger  *log.Logger
+}
+
+// Run publishes and unpublishes the nodes, the function returns the number of altered nodes.
+// A node which cannot be saved is logged and skipped, it is processed again on the next run.
+func (p *Publisher) Run(now time.Time) (int, error) {
+	published := p.process(p.Manager.SelectBuilder(NewSelectOptions()).Where("publish_at <= ?", now), func(node *Node) {
+		node.PublishAt = nil
+		node.Enabled = true
+	})
+
+	unpublished := p.process(p.Manager.SelectBuilder(NewSelectOptions()).Where("unpublish_at <= ?", now), func(node *Node) {
+		node.UnpublishAt = nil
+		node.Enabled = false
+	})
+
+	return published + unpublished, nil
+}
+
+func (p *Publisher) process(query sq.SelectBuilder, alter func(node *Node)) int {
+	query = query.Where("deleted = ?", false)
+
+	count := 0
+	last := 0
+
+	for {
+		// the nodes are iterated by id, so the skipped nodes are not loaded again
+		nodes := p.Manager.FindBy(query.Where("id > ?", last).OrderBy("id ASC"), 0, 128)
+
+		if nodes.Len() == 0 {
+			return count
+		}
+
+		for e := nodes.Front(); e != nil; e = e.Next() {
+			node := e.Value.(*Node)
+			last = node.Id
+
+			alter(node)
+
+			if _, err := p.Manager.Save(node, true); err != nil {
+				if p.Logger != nil {
+					p.Logger.WithFields(log.Fields{
+						"type":   node.Type,
+						"uuid":   node.Uuid,
+						"error":  err,
+						"module": "node.publisher",
+					}).Warn("unable to update node publication")
+				}
+
+				continue
+			}
+
+			if p.Logger != nil {
+				p.Logger.WithFields(log.Fields{
+					"type":    node.Type,
+					"uuid":    node.Uuid,
+					"enabled": node.Enabled,
+					"module":  "node.publisher",
+				}).Info("update node publication")
+			}
+
+			count++
+		}
+	}
+}
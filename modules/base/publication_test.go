@@ -0,0 +1,96 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsPublished(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	cases := []struct {
+		publishAt   *time.Time
+		unpublishAt *time.Time
+		published   bool
+	}{
+		{nil, nil, true},
+		{&past, nil, true},
+		{&future, nil, false},
+		{nil, &future, true},
+		{nil, &past, false},
+		{&now, nil, true},
+		{nil, &now, false},
+		{&past, &future, true},
+	}
+
+	for _, c := range cases {
+		node := NewNode()
+		node.PublishAt = c.publishAt
+		node.UnpublishAt = c.unpublishAt
+
+		assert.Equal(t, c.published, IsPublished(node, now))
+	}
+}
+
+func Test_IsEditor(t *testing.T) {
+	assert.True(t, IsEditor([]string{"ROLE_API", "node:editor"}))
+	assert.True(t, IsEditor([]string{"node:api:master"}))
+	assert.False(t, IsEditor([]string{"IS_AUTHENTICATED_ANONYMOUSLY", "node:prism:render"}))
+	assert.False(t, IsEditor(nil))
+}
+
+type BrokenHandler struct {
+	UserHandler
+}
+
+func (h *BrokenHandler) PreUpdate(node *Node, m NodeManager) error {
+	panic(errors.New("broken node"))
+}
+
+func Test_Publisher_Skip_Failing_Nodes(t *testing.T) {
+	m, clean := getSqliteManager(t)
+	defer clean()
+
+	m.Handlers.(HandlerCollection)["test.broken"] = &BrokenHandler{}
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+
+	// the failing node is loaded first
+	broken := m.NewNode("test.broken")
+	broken.Name = "Broken"
+	broken.PublishAt = &past
+	_, err := m.Save(broken, false)
+	assert.NoError(t, err)
+
+	user := m.NewNode("node.user")
+	user.Name = "User"
+	user.Enabled = false
+	user.Status = StatusDraft
+	user.PublishAt = &past
+	_, err = m.Save(user, false)
+	assert.NoError(t, err)
+
+	p := &Publisher{Manager: m}
+
+	count, err := p.Run(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	user = m.Find(user.Uuid)
+	assert.True(t, user.Enabled)
+	assert.Nil(t, user.PublishAt)
+	assert.Equal(t, StatusDraft, user.Status)
+
+	assert.NotNil(t, m.Find(broken.Uuid).PublishAt)
+}
@@ -9,6 +9,7 @@ import (
 	"container/list"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	pq "github.com/lib/pq"
@@ -33,6 +34,10 @@ type Listener interface {
 
 type SubscriberHander func(notification *pq.Notification) (int, error)
 
+// EventHandler is a durable consumer's handler, the event's id identifies the event across the
+// replays of the outbox
+type EventHandler func(e *Event) error
+
 type ModelEvent struct {
 	Subject     string    `json:"subject"`
 	Action      string    `json:"action"`
@@ -46,14 +51,27 @@ type ModelEvent struct {
 
 func NewSubscriber(conninfo string, logger *log.Logger) *Subscriber {
 	return &Subscriber{
-		conninfo: conninfo,
-		handlers: make(map[string]*list.List, 1024),
-		exit:     make(chan int),
-		logger:   logger,
-		channels: make([]string, 0),
+		conninfo:  conninfo,
+		handlers:  make(map[string]*list.List, 1024),
+		exit:      make(chan int),
+		logger:    logger,
+		channels:  make([]string, 0),
+		consumers: make([]*consumer, 0),
+		Poll:      20 * time.Second,
+		Attempts:  5,
+		Backoff:   time.Second,
 	}
 }
 
+// NewLocalSubscriber returns a subscriber dispatching the notifications published in the
+// current process, it replaces LISTEN/NOTIFY when the database is not PostgreSQL.
+func NewLocalSubscriber(logger *log.Logger) *Subscriber {
+	s := NewSubscriber("", logger)
+	s.local = true
+
+	return s
+}
+
 func CreateModelEvent(notification *pq.Notification) *ModelEvent {
 	m := &ModelEvent{}
 
@@ -63,20 +81,87 @@ func CreateModelEvent(notification *pq.Notification) *ModelEvent {
 }
 
 type Subscriber struct {
-	conninfo string
-	handlers map[string]*list.List
-	listener *pq.Listener
-	exit     chan int
-	init     bool
-	logger   *log.Logger
-	channels []string
+	conninfo  string
+	handlers  map[string]*list.List
+	listener  *pq.Listener
+	exit      chan int
+	init      bool
+	local     bool
+	logger    *log.Logger
+	channels  []string
+	consumers []*consumer
+	done      chan struct{}
+	running   sync.WaitGroup
+
+	// Outbox stores the events, the consumers read the events from the outbox if set
+	Outbox *Outbox
+
+	// Poll is the interval used by the consumers to check the outbox without notification
+	Poll time.Duration
+
+	// Passive ignores the durable consumers, the events are handled by another process (ie, the
+	// worker command). The regular handlers are still registered.
+	Passive bool
+
+	// Claims coordinates the exclusive consumers between the instances
+	Claims *Claims
+
+	// Attempts is the number of times a consumer's handler is called with a failing event, the
+	// event is skipped once the attempts are exhausted
+	Attempts int
+
+	// Backoff is the delay before the second attempt of a failed event, doubled on each attempt.
+	// The attempt is run on the next wake up or poll once the delay is elapsed.
+	Backoff time.Duration
+}
+
+// consumer is a durable handler, the events are read from the outbox after the consumer's cursor
+type consumer struct {
+	name      string
+	channel   string
+	handler   EventHandler
+	exclusive bool
+	wake      chan struct{}
+	lock      sync.Mutex
+
+	// the failures of the event blocking the consumer
+	failed   int64
+	failures int
+	retryAt  time.Time
+}
+
+// wakeUp asks the consumer to read the outbox, the call never blocks
+func (c *consumer) wakeUp() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
 }
 
 func (s *Subscriber) Stop() {
+	if !s.init {
+		return
+	}
+
 	s.logger.WithFields(log.Fields{
 		"module": "node.pubsub",
 	}).Debug("Sending a stop to channel subscriber")
 
+	// the consumers complete the current event
+	if s.done != nil {
+		close(s.done)
+		s.running.Wait()
+		s.done = nil
+
+		s.release()
+	}
+
+	s.init = false
+
+	if s.local {
+		return
+	}
+
 	s.exit <- 1
 	s.listener.Close()
 }
@@ -87,6 +172,18 @@ func (s *Subscriber) Register() {
 	}
 
 	s.init = true
+	s.done = make(chan struct{})
+
+	for _, c := range s.consumers {
+		s.start(c)
+	}
+
+	s.running.Add(1)
+	go s.poll(s.done)
+
+	if s.local {
+		return
+	}
 
 	// listen to the specific channel
 	s.listener = pq.NewListener(s.conninfo, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
@@ -114,58 +211,13 @@ func (s *Subscriber) waitAndDispatch() {
 					"module": "node.pubsub",
 				}).Warn("received a nil notification, the underlying driver reconnect")
 
+				// the notifications sent while disconnected are lost, the consumers replay them
+				s.wakeUp()
+
 				continue
 			}
 
-			s.logger.WithFields(log.Fields{
-				"channel": notification.Channel,
-				"module":  "node.pubsub",
-			}).Debug("received notification on channel")
-
-			if _, ok := s.handlers[notification.Channel]; ok {
-				// go some handlers register
-				for e := s.handlers[notification.Channel].Front(); e != nil; e = e.Next() {
-					go func(e *list.Element) {
-						var f = e.Value.(SubscriberHander)
-						s.logger.WithFields(log.Fields{
-							"channel": notification.Channel,
-							"payload": notification.Extra,
-							"module":  "node.pubsub",
-							"handler": fmt.Sprintf("%T", f),
-						}).Debug("send payload to handler")
-
-						if state, err := f(notification); state != PubSubListenContinue {
-							// close listener
-							s.handlers[notification.Channel].Remove(e)
-							s.logger.WithFields(log.Fields{
-								"channel": notification.Channel,
-								"state":   state,
-								"module":  "node.pubsub",
-							}).Debug("removing handler for channel - state != PubSubListenContinue")
-						} else if err != nil {
-							s.logger.WithFields(log.Fields{
-								"channel": notification.Channel,
-								"payload": notification.Extra,
-								"module":  "node.pubsub",
-								"error":   err.Error(),
-							}).Debug("End processing message (ie: func return, goroutine started ?)")
-						}
-
-						s.logger.WithFields(log.Fields{
-							"channel": notification.Channel,
-							"payload": notification.Extra,
-							"module":  "node.pubsub",
-							"handler": fmt.Sprintf("%T", f),
-						}).Debug("End processing message (ie: func return, goroutine started ?)")
-
-					}(e)
-				}
-			} else {
-				s.logger.WithFields(log.Fields{
-					"channel": notification.Channel,
-					"module":  "node.pubsub",
-				}).Debug("skipping, no handler for channel")
-			}
+			s.dispatch(notification)
 
 		case <-time.After(20 * time.Second):
 			go func() {
@@ -184,11 +236,76 @@ func (s *Subscriber) waitAndDispatch() {
 	}
 }
 
+// Publish sends a notification to the handlers of a local subscriber, like NOTIFY the
+// notification is lost if the subscriber is not registered.
+func (s *Subscriber) Publish(channel string, payload string) {
+	if !s.local || !s.init {
+		return
+	}
+
+	s.dispatch(&pq.Notification{
+		Channel: channel,
+		Extra:   payload,
+	})
+}
+
+func (s *Subscriber) dispatch(notification *pq.Notification) {
+	s.logger.WithFields(log.Fields{
+		"channel": notification.Channel,
+		"module":  "node.pubsub",
+	}).Debug("received notification on channel")
+
+	if _, ok := s.handlers[notification.Channel]; ok {
+		// go some handlers register
+		for e := s.handlers[notification.Channel].Front(); e != nil; e = e.Next() {
+			go func(e *list.Element) {
+				var f = e.Value.(SubscriberHander)
+				s.logger.WithFields(log.Fields{
+					"channel": notification.Channel,
+					"payload": notification.Extra,
+					"module":  "node.pubsub",
+					"handler": fmt.Sprintf("%T", f),
+				}).Debug("send payload to handler")
+
+				if state, err := f(notification); state != PubSubListenContinue {
+					// close listener
+					s.handlers[notification.Channel].Remove(e)
+					s.logger.WithFields(log.Fields{
+						"channel": notification.Channel,
+						"state":   state,
+						"module":  "node.pubsub",
+					}).Debug("removing handler for channel - state != PubSubListenContinue")
+				} else if err != nil {
+					s.logger.WithFields(log.Fields{
+						"channel": notification.Channel,
+						"payload": notification.Extra,
+						"module":  "node.pubsub",
+						"error":   err.Error(),
+					}).Debug("End processing message (ie: func return, goroutine started ?)")
+				}
+
+				s.logger.WithFields(log.Fields{
+					"channel": notification.Channel,
+					"payload": notification.Extra,
+					"module":  "node.pubsub",
+					"handler": fmt.Sprintf("%T", f),
+				}).Debug("End processing message (ie: func return, goroutine started ?)")
+
+			}(e)
+		}
+	} else {
+		s.logger.WithFields(log.Fields{
+			"channel": notification.Channel,
+			"module":  "node.pubsub",
+		}).Debug("skipping, no handler for channel")
+	}
+}
+
 func (s *Subscriber) ListenMessage(name string, handler SubscriberHander) {
 	if _, ok := s.handlers[name]; !ok {
 		s.handlers[name] = list.New()
 
-		if s.init {
+		if s.init && !s.local {
 			err := s.listener.Listen(name)
 			helper.PanicOnError(err)
 		} else {
@@ -198,3 +315,275 @@ func (s *Subscriber) ListenMessage(name string, handler SubscriberHander) {
 
 	s.handlers[name].PushBack(handler)
 }
+
+// Consume registers a durable handler: the events of the channel are read from the outbox, and
+// the id of the last handled event is stored with the consumer's name. So the events sent while
+// the consumer is stopped or disconnected are replayed once the subscriber is registered or
+// reconnected. A new consumer only handles the events sent after its registration. The handler
+// is called with the events one by one and the returned state is ignored. The cursor stays on
+// the previous event if the handler fails, the event is tried again after the subscriber's
+// Backoff, and skipped with an error log once the Attempts are exhausted.
+//
+// The handler is registered as a regular handler if the subscriber does not have an outbox, and
+// is ignored if the subscriber is passive.
+func (s *Subscriber) Consume(name, channel string, handler SubscriberHander) {
+	s.addConsumer(name, channel, notificationHandler(handler), false)
+}
+
+// ConsumeExclusive registers a durable handler run by a single instance: the instance holding
+// the consumer's claim reads the outbox, the other instances skip the events. The claim is
+// extended before each event, once the claim of a stopped or crashed instance expires another
+// instance resumes from the shared cursor. Without claims, the handler is registered like
+// Consume does.
+func (s *Subscriber) ConsumeExclusive(name, channel string, handler SubscriberHander) {
+	s.addConsumer(name, channel, notificationHandler(handler), true)
+}
+
+// ConsumeEvents registers a durable handler like Consume does, the handler receives the outbox's
+// event so it can detect an event handled twice (ie, the handler fails after a partial work).
+// The event's id is 0 if the subscriber does not have an outbox.
+func (s *Subscriber) ConsumeEvents(name, channel string, handler EventHandler) {
+	s.addConsumer(name, channel, handler, false)
+}
+
+// ConsumeEventsExclusive registers a durable handler like ConsumeExclusive does, the handler
+// receives the outbox's event.
+func (s *Subscriber) ConsumeEventsExclusive(name, channel string, handler EventHandler) {
+	s.addConsumer(name, channel, handler, true)
+}
+
+func notificationHandler(handler SubscriberHander) EventHandler {
+	return func(e *Event) error {
+		_, err := handler(&pq.Notification{Channel: e.Channel, Extra: e.Payload})
+
+		return err
+	}
+}
+
+func (s *Subscriber) addConsumer(name, channel string, handler EventHandler, exclusive bool) {
+	if s.Passive {
+		return
+	}
+
+	if s.Outbox == nil {
+		s.ListenMessage(channel, func(notification *pq.Notification) (int, error) {
+			return PubSubListenContinue, handler(&Event{Channel: notification.Channel, Payload: notification.Extra})
+		})
+
+		return
+	}
+
+	c := &consumer{
+		name:      name,
+		channel:   channel,
+		handler:   handler,
+		exclusive: exclusive,
+		wake:      make(chan struct{}, 1),
+	}
+
+	s.consumers = append(s.consumers, c)
+
+	// the notification only wakes up the consumer, the event is read from the outbox
+	s.ListenMessage(channel, func(notification *pq.Notification) (int, error) {
+		c.wakeUp()
+
+		return PubSubListenContinue, nil
+	})
+
+	if s.init {
+		s.start(c)
+	}
+}
+
+func (s *Subscriber) start(c *consumer) {
+	s.running.Add(1)
+	go s.consume(c, s.done)
+}
+
+// Replay handles the events stored after the consumer's cursor, the function returns once the
+// consumer reaches the last event
+func (s *Subscriber) Replay(name string) error {
+	for _, c := range s.consumers {
+		if c.name == name {
+			return s.replay(c)
+		}
+	}
+
+	return fmt.Errorf("the consumer %s does not exist", name)
+}
+
+func (s *Subscriber) wakeUp() {
+	for _, c := range s.consumers {
+		c.wakeUp()
+	}
+}
+
+func (s *Subscriber) poll(done chan struct{}) {
+	defer s.running.Done()
+
+	if s.Poll <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.Poll)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.wakeUp()
+		case <-done:
+			return
+		}
+	}
+}
+
+func (s *Subscriber) consume(c *consumer, done chan struct{}) {
+	defer s.running.Done()
+
+	c.wakeUp()
+
+	for {
+		select {
+		case <-c.wake:
+			if err := s.replay(c); err != nil {
+				s.logger.WithFields(log.Fields{
+					"module":   "node.pubsub",
+					"consumer": c.name,
+					"error":    err.Error(),
+				}).Warn("cannot read the outbox")
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// claim acquires or extends the claim of an exclusive consumer, false is returned if another
+// instance handles the consumer's events
+func (s *Subscriber) claim(c *consumer) (bool, error) {
+	if !c.exclusive || s.Claims == nil {
+		return true, nil
+	}
+
+	return s.Claims.Claim("consumer." + c.name)
+}
+
+// keep extends the claim of an exclusive consumer while its handler runs, so a handler running
+// longer than the claim's TTL is not run by another instance at the same time
+func (s *Subscriber) keep(c *consumer) func() {
+	if !c.exclusive || s.Claims == nil {
+		return func() {}
+	}
+
+	return s.Claims.Keep("consumer."+c.name, s.Claims.TTL)
+}
+
+// release gives up the claims of the exclusive consumers, so another instance takes over at once
+func (s *Subscriber) release() {
+	if s.Claims == nil {
+		return
+	}
+
+	for _, c := range s.consumers {
+		if !c.exclusive {
+			continue
+		}
+
+		if err := s.Claims.Release("consumer." + c.name); err != nil {
+			s.logger.WithFields(log.Fields{
+				"module":   "node.pubsub",
+				"consumer": c.name,
+				"error":    err.Error(),
+			}).Warn("cannot release the claim")
+		}
+	}
+}
+
+// skip records the handler's failure, true is returned once the event's attempts are exhausted
+func (s *Subscriber) skip(c *consumer, e *Event, err error) bool {
+	if c.failed != e.Id {
+		c.failed, c.failures = e.Id, 0
+	}
+
+	c.failures++
+
+	fields := log.Fields{
+		"module":   "node.pubsub",
+		"consumer": c.name,
+		"event":    e.Id,
+		"attempts": c.failures,
+		"error":    err.Error(),
+	}
+
+	if c.failures >= s.Attempts {
+		s.logger.WithFields(fields).Error("the event is skipped, the attempts are exhausted")
+
+		return true
+	}
+
+	c.retryAt = time.Now().Add(s.Backoff << uint(c.failures-1))
+
+	s.logger.WithFields(fields).Warn("error while handling the event, the event will be tried again")
+
+	return false
+}
+
+func (s *Subscriber) replay(c *consumer) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if claimed, err := s.claim(c); !claimed {
+		return err
+	}
+
+	cursor, ok, err := s.Outbox.Cursor(c.name)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		// a new consumer starts after the last event
+		if cursor, err = s.Outbox.Last(); err != nil {
+			return err
+		}
+
+		return s.Outbox.SetCursor(c.name, cursor)
+	}
+
+	for {
+		events, err := s.Outbox.Events([]string{c.channel}, cursor, 100)
+
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		for _, e := range events {
+			// the failed event waits for its backoff
+			if c.failed == e.Id && time.Now().Before(c.retryAt) {
+				return nil
+			}
+
+			// the event is skipped if another instance took over the consumer
+			if claimed, err := s.claim(c); !claimed {
+				return err
+			}
+
+			stop := s.keep(c)
+			err := c.handler(e)
+			stop()
+
+			if err != nil && !s.skip(c, e, err) {
+				return nil
+			}
+
+			c.failed, c.failures = 0, 0
+			cursor = e.Id
+
+			if err := s.Outbox.SetCursor(c.name, cursor); err != nil {
+				return err
+			}
+		}
+	}
+}
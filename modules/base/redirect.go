@@ -0,0 +1,135 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rande/gonode/core/helper"
+)
+
+// branchPaths returns the current path of the node and of its descendants, indexed by uuid
+func (m *SqlNodeManager) branchPaths(uuid Reference) (map[string]string, error) {
+	rows, err := m.runner().Query(fmt.Sprintf(`SELECT uuid, path FROM %s WHERE uuid = $1 OR %s`, m.Prefix+"_nodes", m.dialect().ArrayContains("parents", "$2")),
+		uuid.CleanString(),
+		uuid.CleanString())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	paths := make(map[string]string)
+
+	for rows.Next() {
+		var u, path string
+
+		if err := rows.Scan(&u, &path); err != nil {
+			return nil, err
+		}
+
+		paths[u] = path
+	}
+
+	return paths, rows.Err()
+}
+
+// followSlug updates the path of a node whose slug has changed, if the path ends with the
+// previous slug like the paths computed by Move
+func followSlug(node, saved *Node) {
+	if saved == nil || node.Slug == saved.Slug || node.Path != saved.Path || !strings.HasSuffix(saved.Path, "/"+saved.Slug) {
+		return
+	}
+
+	node.Path = strings.TrimSuffix(saved.Path, saved.Slug) + node.Slug
+}
+
+// rebuildPaths replaces the previous path of a node in the paths of its descendants, the
+// descendants with a custom path keep it
+func (m *SqlNodeManager) rebuildPaths(from, to string, paths map[string]string) error {
+	for u, path := range paths {
+		if !strings.HasPrefix(path, from+"/") {
+			continue
+		}
+
+		if _, err := m.runner().Exec(fmt.Sprintf(`UPDATE %s SET path = $1 WHERE uuid = $2`, m.Prefix+"_nodes"), to+strings.TrimPrefix(path, from), u); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveRedirects records the previous paths of the moved or renamed branch, a path used again by a node
+// of the branch is not redirected anymore
+func (m *SqlNodeManager) saveRedirects(uuid Reference, previous map[string]string) error {
+	paths, err := m.branchPaths(uuid)
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for u, path := range paths {
+		old := previous[u]
+
+		// the redirect of a path is replaced if the path is used again
+		if _, err := m.runner().Exec(fmt.Sprintf(`DELETE FROM "%s_nodes_redirects" WHERE path IN ($1, $2)`, m.Prefix), path, old); err != nil {
+			return err
+		}
+
+		if old == "" || old == path {
+			continue
+		}
+
+		_, err := sq.Insert(m.Prefix+"_nodes_redirects").
+			Columns("path", "uuid", "created_at").
+			Values(old, u, now).
+			PlaceholderFormat(sq.Dollar).
+			RunWith(m.runner()).
+			Exec()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindRedirect returns the node which used the path before being moved, nil is returned if
+// the path has never been used or if the node is deleted
+func (m *SqlNodeManager) FindRedirect(path string) *Node {
+	var uuid string
+
+	err := m.runner().QueryRow(fmt.Sprintf(`SELECT uuid FROM "%s_nodes_redirects" WHERE path = $1`, m.Prefix), path).Scan(&uuid)
+
+	if err == sql.ErrNoRows {
+		return nil
+	}
+
+	helper.PanicOnError(err)
+
+	reference, err := GetReferenceFromString(uuid)
+
+	if err != nil {
+		return nil
+	}
+
+	node := m.Find(reference)
+
+	if node == nil || node.Deleted {
+		return nil
+	}
+
+	return node
+}
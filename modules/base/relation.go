@@ -0,0 +1,166 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Relation is a link from a source node to a target node, the target's reference is stored
+// in one of the source's data field.
+type Relation struct {
+	Source Reference `json:"source"`
+	Target Reference `json:"target"`
+	Field  string    `json:"field"`
+}
+
+// RelationManager restricts a select query to the nodes linked to a node, the field argument
+// is optional and can be used to only match one reference field.
+type RelationManager interface {
+	References(query sq.SelectBuilder, uuid Reference, field string) sq.SelectBuilder
+	ReferencedBy(query sq.SelectBuilder, uuid Reference, field string) sq.SelectBuilder
+	IsReferenced(uuid Reference) (bool, error)
+}
+
+// GetRelations returns the relations declared by the node's handler, the empty references
+// are ignored.
+func GetRelations(handler Handler, node *Node) []*Relation {
+	relations := make([]*Relation, 0)
+
+	h, ok := handler.(RelationNodeHandler)
+
+	if !ok || node.Data == nil {
+		return relations
+	}
+
+	for _, field := range h.GetReferenceFields() {
+		var references []Reference
+
+		switch v := GetValue(node.Data, field).(type) {
+		case Reference:
+			references = []Reference{v}
+		case *Reference:
+			if v != nil {
+				references = []Reference{*v}
+			}
+		case []Reference:
+			references = v
+		}
+
+		for _, reference := range references {
+			if reference == GetEmptyReference() || reference == GetRootReference() {
+				continue
+			}
+
+			relations = append(relations, &Relation{
+				Source: node.Uuid,
+				Target: reference,
+				Field:  field,
+			})
+		}
+	}
+
+	return relations
+}
+
+func (m *SqlNodeManager) saveRelations(node *Node) error {
+	handler := m.Handlers.Get(node)
+
+	if _, ok := handler.(RelationNodeHandler); !ok {
+		return nil
+	}
+
+	return m.Transaction(func(tx NodeManager) error {
+		tm := tx.(*SqlNodeManager)
+
+		if _, err := tm.tx.Exec(fmt.Sprintf(`DELETE FROM "%s_nodes_relations" WHERE source_uuid = $1`, m.Prefix), node.Uuid.CleanString()); err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		for _, relation := range GetRelations(handler, node) {
+			_, err := sq.Insert(m.Prefix+"_nodes_relations").
+				Columns("source_uuid", "target_uuid", "field", "created_at").
+				Values(relation.Source.CleanString(), relation.Target.CleanString(), relation.Field, now).
+				Suffix("ON CONFLICT DO NOTHING").
+				RunWith(tm.tx).
+				PlaceholderFormat(sq.Dollar).
+				Exec()
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// References restricts the query to the nodes referenced by the node
+func (m *SqlNodeManager) References(query sq.SelectBuilder, uuid Reference, field string) sq.SelectBuilder {
+	return m.whereRelation(query, "target_uuid", "source_uuid", uuid, field)
+}
+
+// ReferencedBy restricts the query to the nodes referencing the node
+func (m *SqlNodeManager) ReferencedBy(query sq.SelectBuilder, uuid Reference, field string) sq.SelectBuilder {
+	return m.whereRelation(query, "source_uuid", "target_uuid", uuid, field)
+}
+
+func (m *SqlNodeManager) whereRelation(query sq.SelectBuilder, column, on string, uuid Reference, field string) sq.SelectBuilder {
+	if field == "" {
+		return query.Where(fmt.Sprintf(`uuid IN (SELECT %s FROM "%s_nodes_relations" WHERE %s = ?)`, column, m.Prefix, on), uuid.CleanString())
+	}
+
+	return query.Where(fmt.Sprintf(`uuid IN (SELECT %s FROM "%s_nodes_relations" WHERE %s = ? AND field = ?)`, column, m.Prefix, on), uuid.CleanString(), field)
+}
+
+// IsReferenced returns true if a non deleted node references the node
+func (m *SqlNodeManager) IsReferenced(uuid Reference) (bool, error) {
+	referenced := false
+
+	err := m.runner().QueryRow(fmt.Sprintf(`SELECT EXISTS(
+			SELECT 1 FROM "%s_nodes_relations" r
+			JOIN "%s_nodes" n ON n.uuid = r.source_uuid
+			WHERE r.target_uuid = $1 AND r.source_uuid <> $1 AND n.deleted = false
+		)`, m.Prefix, m.Prefix), uuid.CleanString()).Scan(&referenced)
+
+	return referenced, err
+}
+
+// RebuildRelations stores the relations of all the nodes, including the deleted ones. The
+// function is used to fill the relation table for nodes created before the table existed,
+// it returns the number of nodes processed.
+func (m *SqlNodeManager) RebuildRelations() (int, error) {
+	count := 0
+	last := 0
+
+	for {
+		query := m.SelectBuilder(NewSelectOptions()).
+			Where("id > ?", last).
+			OrderBy("id ASC")
+
+		nodes := m.FindBy(query, 0, 256)
+
+		if nodes.Len() == 0 {
+			return count, nil
+		}
+
+		for e := nodes.Front(); e != nil; e = e.Next() {
+			node := e.Value.(*Node)
+
+			if err := m.saveRelations(node); err != nil {
+				return count, err
+			}
+
+			last = node.Id
+			count++
+		}
+	}
+}
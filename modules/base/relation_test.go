@@ -0,0 +1,57 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type Gallery struct {
+	Cover  Reference   `json:"cover"`
+	Medias []Reference `json:"medias"`
+}
+
+type GalleryHandler struct {
+}
+
+func (h *GalleryHandler) GetStruct() (NodeData, NodeMeta) {
+	return &Gallery{}, &UserMeta{}
+}
+
+func (h *GalleryHandler) GetReferenceFields() []string {
+	return []string{"Cover", "Medias", "Missing"}
+}
+
+func Test_GetRelations(t *testing.T) {
+	c := HandlerCollection{
+		"media.gallery": &GalleryHandler{},
+		"node.user":     &UserHandler{},
+	}
+
+	cover := GetReference(uuid.New())
+	media := GetReference(uuid.New())
+
+	node := c.NewNode("media.gallery")
+	node.Uuid = GetReference(uuid.New())
+	node.Data.(*Gallery).Cover = cover
+	node.Data.(*Gallery).Medias = []Reference{media, GetEmptyReference()}
+
+	relations := GetRelations(c.Get(node), node)
+
+	assert.Equal(t, 2, len(relations))
+	assert.Equal(t, &Relation{Source: node.Uuid, Target: cover, Field: "Cover"}, relations[0])
+	assert.Equal(t, &Relation{Source: node.Uuid, Target: media, Field: "Medias"}, relations[1])
+
+	// the root reference is the zero value
+	node.Data.(*Gallery).Cover = GetRootReference()
+	assert.Equal(t, 1, len(GetRelations(c.Get(node), node)))
+
+	user := c.NewNode("node.user")
+	assert.Equal(t, 0, len(GetRelations(c.Get(user), user)))
+}
@@ -0,0 +1,157 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type ScheduledJob func() error
+
+type scheduledEntry struct {
+	name      string
+	interval  time.Duration
+	job       ScheduledJob
+	exclusive bool
+}
+
+func NewScheduler(logger *log.Logger) *Scheduler {
+	return &Scheduler{
+		entries: make([]*scheduledEntry, 0),
+		logger:  logger,
+	}
+}
+
+// Scheduler runs registered jobs at a fixed interval, each job runs in its own goroutine
+// and a job is never run twice at the same time.
+type Scheduler struct {
+	entries []*scheduledEntry
+	exit    chan int
+	started bool
+	logger  *log.Logger
+	wg      sync.WaitGroup
+
+	// Claims runs the exclusive jobs on a single instance, optional
+	Claims *Claims
+}
+
+func (s *Scheduler) Add(name string, interval time.Duration, job ScheduledJob) {
+	s.entries = append(s.entries, &scheduledEntry{
+		name:     name,
+		interval: interval,
+		job:      job,
+	})
+}
+
+// AddExclusive registers a job run by a single instance: the instance holding the job's claim
+// runs it, the claim is kept for the interval once the job is done so the other instances skip
+// their runs. The job runs on every instance if the scheduler does not have claims.
+func (s *Scheduler) AddExclusive(name string, interval time.Duration, job ScheduledJob) {
+	s.entries = append(s.entries, &scheduledEntry{
+		name:      name,
+		interval:  interval,
+		job:       job,
+		exclusive: true,
+	})
+}
+
+func (s *Scheduler) Start() {
+	if s.started {
+		return
+	}
+
+	s.started = true
+	s.exit = make(chan int)
+
+	for _, e := range s.entries {
+		s.wg.Add(1)
+
+		go s.loop(e)
+	}
+}
+
+func (s *Scheduler) Stop() {
+	if !s.started {
+		return
+	}
+
+	close(s.exit)
+
+	s.wg.Wait()
+
+	s.started = false
+}
+
+func (s *Scheduler) loop(e *scheduledEntry) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.run(e)
+		case <-s.exit:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) run(e *scheduledEntry) {
+	defer func() {
+		if r := recover(); r != nil && s.logger != nil {
+			s.logger.WithFields(log.Fields{
+				"module": "node.scheduler",
+				"job":    e.name,
+				"panic":  r,
+			}).Error("job panicked")
+		}
+	}()
+
+	if e.exclusive && s.Claims != nil {
+		name := "scheduler." + e.name
+
+		if claimed, err := s.Claims.ClaimFor(name, e.interval); err != nil || !claimed {
+			if s.logger != nil {
+				s.logger.WithFields(log.Fields{
+					"module": "node.scheduler",
+					"job":    e.name,
+					"error":  err,
+				}).Debug("skipping job, the job is run by another instance")
+			}
+
+			return
+		}
+
+		stop := s.Claims.Keep(name, e.interval)
+
+		defer func() {
+			stop()
+
+			// the next run is due in one interval from now
+			s.Claims.ClaimFor(name, e.interval)
+		}()
+	}
+
+	if s.logger != nil {
+		s.logger.WithFields(log.Fields{
+			"module": "node.scheduler",
+			"job":    e.name,
+		}).Debug("running job")
+	}
+
+	if err := e.job(); err != nil && s.logger != nil {
+		s.logger.WithFields(log.Fields{
+			"module": "node.scheduler",
+			"job":    e.name,
+			"error":  err.Error(),
+		}).Warn("job returned an error")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Scheduler_Run_Jobs(t *testing.T) {
+	s := NewScheduler(nil)
+
+	var count, failures int32
+
+	s.Add("count", 5*time.Millisecond, func() error {
+		atomic.AddInt32(&count, 1)
+
+		return nil
+	})
+
+	s.Add("failure", 5*time.Millisecond, func() error {
+		atomic.AddInt32(&failures, 1)
+
+		return errors.New("job error")
+	})
+
+	s.Start()
+	time.Sleep(50 * time.Millisecond)
+	s.Stop()
+
+	stopped := atomic.LoadInt32(&count)
+
+	assert.True(t, stopped > 0)
+	assert.True(t, atomic.LoadInt32(&failures) > 0)
+
+	time.Sleep(20 * time.Millisecond)
+
+	assert.Equal(t, stopped, atomic.LoadInt32(&count))
+}
+
+func Test_Scheduler_Exclusive_Jobs(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	var count int32
+
+	schedulers := make([]*Scheduler, 0)
+
+	for i := 0; i < 3; i++ {
+		s := NewScheduler(nil)
+		s.Claims = NewClaims(m)
+		s.AddExclusive("purge", 20*time.Millisecond, func() error {
+			atomic.AddInt32(&count, 1)
+
+			return nil
+		})
+
+		schedulers = append(schedulers, s)
+	}
+
+	for _, s := range schedulers {
+		s.Start()
+	}
+
+	time.Sleep(110 * time.Millisecond)
+
+	for _, s := range schedulers {
+		s.Stop()
+	}
+
+	// the job runs once per interval, whatever the number of instances
+	runs := atomic.LoadInt32(&count)
+
+	assert.True(t, runs > 0)
+	assert.True(t, runs <= 6, "the job has run %d times", runs)
+}
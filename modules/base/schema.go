@@ -0,0 +1,374 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SCHEMA_DRAFT = "http://json-schema.org/draft-07/schema#"
+
+	SCHEMA_STRING  = "string"
+	SCHEMA_INTEGER = "integer"
+	SCHEMA_NUMBER  = "number"
+	SCHEMA_BOOLEAN = "boolean"
+	SCHEMA_ARRAY   = "array"
+	SCHEMA_OBJECT  = "object"
+)
+
+var (
+	referenceType = reflect.TypeOf(Reference{})
+	timeType      = reflect.TypeOf(time.Time{})
+)
+
+// Schema is a subset of the JSON Schema specification, enough to describe the handler's structs.
+// An empty Type accepts any value.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"-"`
+	Nullable             bool               `json:"-"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type schema Schema
+
+	v := struct {
+		Type interface{} `json:"type,omitempty"`
+		*schema
+	}{
+		schema: (*schema)(s),
+	}
+
+	if s.Type != "" && s.Nullable {
+		v.Type = []string{s.Type, "null"}
+	} else if s.Type != "" {
+		v.Type = s.Type
+	}
+
+	return json.Marshal(v)
+}
+
+// GetSchema generates the schema of a value by reflecting over its type, the json tags are used
+// to name the properties.
+func GetSchema(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+
+	return getTypeSchema(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+func getTypeSchema(t reflect.Type, visited map[reflect.Type]bool) *Schema {
+	nullable := false
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := &Schema{}
+
+	switch {
+	case t == referenceType:
+		schema.Type = SCHEMA_STRING
+		schema.Format = "uuid"
+	case t == timeType:
+		schema.Type = SCHEMA_STRING
+		schema.Format = "date-time"
+	default:
+		switch t.Kind() {
+		case reflect.Bool:
+			schema.Type = SCHEMA_BOOLEAN
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			schema.Type = SCHEMA_INTEGER
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			min := float64(0)
+			schema.Type = SCHEMA_INTEGER
+			schema.Minimum = &min
+		case reflect.Float32, reflect.Float64:
+			schema.Type = SCHEMA_NUMBER
+		case reflect.String:
+			schema.Type = SCHEMA_STRING
+		case reflect.Slice, reflect.Array:
+			if t.Elem().Kind() == reflect.Uint8 {
+				// encoding/json stores []byte as a base64 string
+				schema.Type = SCHEMA_STRING
+			} else {
+				schema.Type = SCHEMA_ARRAY
+				schema.Items = getTypeSchema(t.Elem(), visited)
+			}
+
+			nullable = nullable || t.Kind() == reflect.Slice
+		case reflect.Map:
+			schema.Type = SCHEMA_OBJECT
+			schema.AdditionalProperties = getTypeSchema(t.Elem(), visited)
+			nullable = true
+		case reflect.Struct:
+			if visited[t] {
+				// recursive structure, accept any value
+				return &Schema{}
+			}
+
+			visited[t] = true
+			schema.Type = SCHEMA_OBJECT
+			schema.Properties = make(map[string]*Schema)
+			addStructProperties(schema, t, visited)
+			delete(visited, t)
+		}
+	}
+
+	if schema.Type != "" {
+		schema.Nullable = nullable
+	}
+
+	return schema
+}
+
+func addStructProperties(schema *Schema, t reflect.Type, visited map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := field.Name
+		tag := field.Tag.Get("json")
+
+		if tag == "-" {
+			continue
+		}
+
+		if tag != "" {
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+
+		if field.Anonymous && tag == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				addStructProperties(schema, ft, visited)
+
+				continue
+			}
+		}
+
+		if field.PkgPath != "" { // unexported field
+			continue
+		}
+
+		schema.Properties[name] = getTypeSchema(field.Type, visited)
+	}
+}
+
+// Validate checks a decoded JSON value against the schema, the errors are stored with the
+// value's path as key.
+func (s *Schema) Validate(path string, value interface{}, errors Errors) {
+	if s.Type == "" {
+		return
+	}
+
+	if value == nil {
+		if !s.Nullable {
+			errors.AddError(path, fmt.Sprintf("must be of type %s, null given", s.Type))
+		}
+
+		return
+	}
+
+	switch s.Type {
+	case SCHEMA_BOOLEAN:
+		if _, ok := value.(bool); !ok {
+			errors.AddError(path, "must be a boolean")
+		}
+	case SCHEMA_INTEGER:
+		n, ok := value.(json.Number)
+
+		if !ok {
+			errors.AddError(path, "must be an integer")
+
+			return
+		}
+
+		if _, err := n.Int64(); err != nil {
+			errors.AddError(path, "must be an integer")
+		} else if f, _ := n.Float64(); s.Minimum != nil && f < *s.Minimum {
+			errors.AddError(path, fmt.Sprintf("must be greater than or equal to %v", *s.Minimum))
+		}
+	case SCHEMA_NUMBER:
+		if _, ok := value.(json.Number); !ok {
+			errors.AddError(path, "must be a number")
+		}
+	case SCHEMA_STRING:
+		str, ok := value.(string)
+
+		if !ok {
+			errors.AddError(path, "must be a string")
+
+			return
+		}
+
+		s.validateFormat(path, str, errors)
+	case SCHEMA_ARRAY:
+		list, ok := value.([]interface{})
+
+		if !ok {
+			errors.AddError(path, "must be an array")
+
+			return
+		}
+
+		for i, v := range list {
+			s.Items.Validate(fmt.Sprintf("%s[%d]", path, i), v, errors)
+		}
+	case SCHEMA_OBJECT:
+		object, ok := value.(map[string]interface{})
+
+		if !ok {
+			errors.AddError(path, "must be an object")
+
+			return
+		}
+
+		for name, v := range object {
+			if property := s.property(name); property != nil {
+				property.Validate(joinSchemaPath(path, name), v, errors)
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.Validate(joinSchemaPath(path, name), v, errors)
+			}
+		}
+	}
+}
+
+// property returns the property's schema, like encoding/json the name is case insensitive
+func (s *Schema) property(name string) *Schema {
+	if property, ok := s.Properties[name]; ok {
+		return property
+	}
+
+	for key, property := range s.Properties {
+		if strings.EqualFold(key, name) {
+			return property
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateFormat(path, value string, errors Errors) {
+	switch s.Format {
+	case "uuid":
+		// an empty string is used for an empty reference
+		if _, err := uuid.Parse(value); value != "" && err != nil {
+			errors.AddError(path, "must be a valid uuid")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+			errors.AddError(path, "must be a valid RFC 3339 date")
+		}
+	}
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+func NewSchemas(handlers Handlers) *Schemas {
+	return &Schemas{
+		Handlers: handlers,
+		schemas:  make(map[string]*Schema),
+	}
+}
+
+// Schemas generates and caches the node's schema of each handler
+type Schemas struct {
+	Handlers Handlers
+	schemas  map[string]*Schema
+	lock     sync.Mutex
+}
+
+// Get returns the schema of a node type, the node's Data and Meta are described with the
+// handler's structs.
+func (s *Schemas) Get(nodeType string) *Schema {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if schema, ok := s.schemas[nodeType]; ok {
+		return schema
+	}
+
+	schema := GetSchema(&Node{})
+	schema.Schema = SCHEMA_DRAFT
+	schema.Title = nodeType
+	schema.Nullable = false
+
+	if s.Handlers.HasType(nodeType) {
+		data, meta := s.Handlers.GetByType(nodeType).GetStruct()
+
+		schema.Properties["data"] = GetSchema(data)
+		schema.Properties["meta"] = GetSchema(meta)
+	}
+
+	s.schemas[nodeType] = schema
+
+	return schema
+}
+
+// Validate checks a JSON node document, the schema is selected with the document's type
+func (s *Schemas) Validate(data []byte) Errors {
+	errors := NewErrors()
+
+	var document interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&document); err != nil {
+		errors.AddError("document", fmt.Sprintf("invalid JSON: %s", err))
+
+		return errors
+	}
+
+	object, ok := document.(map[string]interface{})
+
+	if !ok {
+		errors.AddError("document", "must be an object")
+
+		return errors
+	}
+
+	nodeType := ""
+
+	for key, value := range object {
+		if strings.EqualFold(key, "type") {
+			nodeType, _ = value.(string)
+		}
+	}
+
+	s.Get(nodeType).Validate("", object, errors)
+
+	return errors
+}
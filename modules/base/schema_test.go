@@ -0,0 +1,81 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type SchemaEmbedded struct {
+	Position uint `json:"position"`
+}
+
+type SchemaData struct {
+	SchemaEmbedded
+	Title     string            `json:"title"`
+	Tags      []string          `json:"tags"`
+	Date      time.Time         `json:"date"`
+	Image     Reference         `json:"image"`
+	Extra     map[string]string `json:"extra"`
+	Score     float64           `json:"score"`
+	Ignored   string            `json:"-"`
+	NoTag     bool
+	Published *time.Time `json:"published,omitempty"`
+	private   string
+}
+
+func Test_GetSchema(t *testing.T) {
+	schema := GetSchema(&SchemaData{})
+
+	assert.Equal(t, SCHEMA_OBJECT, schema.Type)
+	assert.True(t, schema.Nullable)
+	assert.Equal(t, 9, len(schema.Properties))
+
+	assert.Equal(t, SCHEMA_INTEGER, schema.Properties["position"].Type)
+	assert.Equal(t, float64(0), *schema.Properties["position"].Minimum)
+	assert.Equal(t, SCHEMA_STRING, schema.Properties["title"].Type)
+	assert.Equal(t, SCHEMA_ARRAY, schema.Properties["tags"].Type)
+	assert.Equal(t, SCHEMA_STRING, schema.Properties["tags"].Items.Type)
+	assert.Equal(t, "date-time", schema.Properties["date"].Format)
+	assert.Equal(t, "uuid", schema.Properties["image"].Format)
+	assert.Equal(t, SCHEMA_STRING, schema.Properties["extra"].AdditionalProperties.Type)
+	assert.Equal(t, SCHEMA_NUMBER, schema.Properties["score"].Type)
+	assert.Equal(t, SCHEMA_BOOLEAN, schema.Properties["NoTag"].Type)
+	assert.True(t, schema.Properties["published"].Nullable)
+
+	data, _ := json.Marshal(schema.Properties["published"])
+	assert.Equal(t, `{"type":["string","null"],"format":"date-time"}`, string(data))
+}
+
+func Test_Schemas_Validate(t *testing.T) {
+	schemas := NewSchemas(HandlerCollection{
+		"node.user": &UserHandler{},
+	})
+
+	errors := schemas.Validate([]byte(`{"type": "node.user", "name": "Thomas", "weight": 1, "data": {"name": "Thomas", "username": 12}}`))
+	assert.Equal(t, []string{"must be a string"}, errors.GetError("data.username"))
+	assert.Equal(t, 1, len(errors))
+
+	errors = schemas.Validate([]byte(`{"type": "node.user", "weight": 1.5, "uuid": "foo", "access": [1], "enabled": null}`))
+	assert.True(t, errors.HasError("weight"))
+	assert.True(t, errors.HasError("uuid"))
+	assert.True(t, errors.HasError("access[0]"))
+	assert.True(t, errors.HasError("enabled"))
+
+	errors = schemas.Validate([]byte(`{"Type": "node.user", "Data": {"Username": "thomas"}, "parents": null, "uuid": ""}`))
+	assert.False(t, errors.HasErrors())
+
+	errors = schemas.Validate([]byte(`{"type": "node.user"`))
+	assert.True(t, errors.HasError("document"))
+
+	// unknown type, the data is not validated
+	errors = schemas.Validate([]byte(`{"type": "node.unknown", "data": {"username": 12}}`))
+	assert.False(t, errors.HasErrors())
+}
@@ -11,6 +11,7 @@ import (
 	"io"
 
 	"github.com/rande/gonode/core/helper"
+	"github.com/rande/gonode/core/security"
 )
 
 type NodeSerializer func(w io.Writer, node *Node) error
@@ -27,6 +28,18 @@ type Serializer struct {
 	serializers   map[string]NodeSerializer
 	deserializers map[string]NodeDeserializer
 	Handlers      Handlers
+	Token         security.SecurityToken // the fields are filtered with the token's roles, see FieldAccessNodeHandler
+}
+
+// WithToken returns a serializer sharing the registered functions, the nodes' fields are
+// filtered with the token's roles
+func (s *Serializer) WithToken(token security.SecurityToken) *Serializer {
+	return &Serializer{
+		serializers:   s.serializers,
+		deserializers: s.deserializers,
+		Handlers:      s.Handlers,
+		Token:         token,
+	}
 }
 
 func (s *Serializer) AddSerializer(name string, f NodeSerializer) {
@@ -40,14 +53,51 @@ func (s *Serializer) AddDeserializer(name string, f NodeDeserializer) {
 func (s *Serializer) Serialize(w io.Writer, data interface{}) error {
 	switch d := data.(type) {
 	case *Node:
-		if _, ok := s.serializers[d.Type]; ok {
-			return s.serializers[d.Type](w, d)
+		fields := s.deniedFields(d, false)
+
+		if len(fields) == 0 {
+			return s.serializeNode(w, d)
+		}
+
+		var buffer bytes.Buffer
+		if err := s.serializeNode(&buffer, d); err != nil {
+			return err
+		}
+
+		document, err := decodeDocument(&buffer)
+
+		if err != nil {
+			return err
 		}
+
+		removeFields(document, fields)
+
+		return Serialize(w, document)
 	}
 
 	return Serialize(w, data)
 }
 
+func (s *Serializer) serializeNode(w io.Writer, node *Node) error {
+	if _, ok := s.serializers[node.Type]; ok {
+		return s.serializers[node.Type](w, node)
+	}
+
+	return Serialize(w, node)
+}
+
+func (s *Serializer) deniedFields(node *Node, write bool) []string {
+	if s.Handlers == nil {
+		return nil
+	}
+
+	if write {
+		return UnwritableFields(s.Handlers.Get(node), s.Token)
+	}
+
+	return UnreadableFields(s.Handlers.Get(node), s.Token)
+}
+
 func (s *Serializer) Deserialize(r io.Reader, o interface{}) error {
 	var buffer bytes.Buffer
 	read, err := buffer.ReadFrom(r)
@@ -62,14 +112,37 @@ func (s *Serializer) Deserialize(r io.Reader, o interface{}) error {
 		node := o.(*Node)
 		if node.Type == "" {
 			// we need to deserialize twice to load the correct Meta/Data structure
-			if err := Deserialize(reader, node); err != nil {
+			probe := &struct {
+				Type string `json:"type"`
+			}{}
+
+			if err := Deserialize(reader, probe); err != nil {
 				return err
 			}
 
 			reader.Seek(0, 0)
+			node.Type = probe.Type
 			node.Data, node.Meta = s.Handlers.Get(node).GetStruct()
 		}
 
+		// the fields the token cannot write are ignored
+		if fields := s.deniedFields(node, true); len(fields) > 0 {
+			document, err := decodeDocument(reader)
+
+			if err != nil {
+				return err
+			}
+
+			removeFields(document, fields)
+
+			buffer.Reset()
+			if err := Serialize(&buffer, document); err != nil {
+				return err
+			}
+
+			reader = bytes.NewReader(buffer.Bytes())
+		}
+
 		if _, ok := s.deserializers[node.Type]; ok {
 			return s.deserializers[node.Type](reader, node)
 		}
@@ -85,6 +158,20 @@ func Serialize(w io.Writer, data interface{}) error {
 	return err
 }
 
+// decodeDocument decodes a JSON object, the numbers are kept as is
+func decodeDocument(r io.Reader) (map[string]interface{}, error) {
+	document := make(map[string]interface{})
+
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
 func Deserialize(r io.Reader, data interface{}) error {
 	decoder := json.NewDecoder(r)
 	err := decoder.Decode(data)
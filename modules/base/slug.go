@@ -0,0 +1,173 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	sq "github.com/Masterminds/squirrel"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const SLUG_MAX_LENGTH = 200
+
+var (
+	// the letters without a decomposed form
+	slugReplacer = strings.NewReplacer(
+		"ß", "ss", "æ", "ae", "Æ", "ae", "œ", "oe", "Œ", "oe", "ø", "o", "Ø", "o",
+		"đ", "d", "Đ", "d", "ð", "d", "Ð", "d", "ł", "l", "Ł", "l", "þ", "th", "Þ", "th",
+	)
+
+	rexSlugSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Slugify converts the value into a lower case ASCII slug, the accents are removed and the
+// other characters are replaced by a dash: "Crème Brûlée!" => "creme-brulee". An empty string
+// is returned if the value does not contain any letter or digit.
+func Slugify(value string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	slug, _, err := transform.String(t, slugReplacer.Replace(value))
+
+	if err != nil {
+		slug = value
+	}
+
+	slug = strings.Trim(rexSlugSeparator.ReplaceAllString(strings.ToLower(slug), "-"), "-")
+
+	if len(slug) > SLUG_MAX_LENGTH {
+		slug = strings.TrimRight(slug[:SLUG_MAX_LENGTH], "-")
+	}
+
+	return slug
+}
+
+// isSlugFrozen returns true if the slug of the saved node cannot be changed anymore
+func (m *SqlNodeManager) isSlugFrozen(saved *Node) bool {
+	return m.FreezeSlug && saved != nil && saved.Enabled && !saved.Deleted && IsPublished(saved, time.Now())
+}
+
+// validateSlug adds an error if the node's slug is used by a sibling, or if the slug of a
+// published node is altered.
+func (m *SqlNodeManager) validateSlug(node, saved *Node, errors Errors) {
+	if node.Slug == "" {
+		return
+	}
+
+	if m.isSlugFrozen(saved) && node.Slug != saved.Slug {
+		errors.AddError("slug", "The slug cannot be changed once the node is published")
+	}
+
+	if used, err := m.slugs(node.ParentUuid, node.Uuid, node.Slug); err == nil && used[node.Slug] {
+		errors.AddError("slug", "The slug is already used by a sibling")
+	}
+}
+
+// slugViolation converts the violation of the unique slug constraint into a validation error,
+// a sibling can take the slug between the validation and the insert
+func (m *SqlNodeManager) slugViolation(err error) (Errors, bool) {
+	if err == nil || !m.dialect().UniqueViolation(err, m.Prefix+"_slug", m.Prefix+"_nodes.slug") {
+		return nil, false
+	}
+
+	errors := NewErrors()
+	errors.AddError("slug", "The slug is already used by a sibling")
+
+	return errors, true
+}
+
+// prepareSlug generates the slug from the name if the slug is empty, the generated slug is
+// suffixed to be unique among the parent's children. A provided slug is validated, an Errors
+// value is returned if it cannot be used.
+func (m *SqlNodeManager) prepareSlug(node, saved *Node) error {
+	if node.Slug == "" && m.isSlugFrozen(saved) {
+		node.Slug = saved.Slug
+	}
+
+	if node.Slug != "" {
+		errors := NewErrors()
+
+		if m.validateSlug(node, saved, errors); errors.HasErrors() {
+			return errors
+		}
+
+		return nil
+	}
+
+	slug := Slugify(node.Name)
+
+	if slug == "" {
+		// the uuid is used as slug, a new node gets it once the uuid is generated
+		if node.Uuid.String() != GetEmptyReference().String() {
+			node.Slug = node.Uuid.String()
+		}
+
+		return nil
+	}
+
+	slug, err := m.UniqueSlug(node.ParentUuid, node.Uuid, slug)
+
+	node.Slug = slug
+
+	return err
+}
+
+// UniqueSlug returns the first slug not used by the parent's children: slug, slug-1, slug-2, ...
+// The node identified by exclude is ignored.
+func (m *SqlNodeManager) UniqueSlug(parent, exclude Reference, slug string) (string, error) {
+	slugs, err := m.slugs(parent, exclude, slug)
+
+	if err != nil {
+		return "", err
+	}
+
+	candidate := slug
+	for i := 1; slugs[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+
+	return candidate, nil
+}
+
+// slugs returns the slugs of the parent's children matching the slug or its suffixed versions
+func (m *SqlNodeManager) slugs(parent, exclude Reference, slug string) (map[string]bool, error) {
+	like := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(slug) + "-%"
+
+	rows, err := sq.Select("slug").
+		From(m.Prefix + "_nodes").
+		Where(sq.Eq{"parent_uuid": parent.CleanString()}).
+		Where(sq.NotEq{"uuid": exclude.CleanString()}).
+		Where(sq.Or{sq.Eq{"slug": slug}, sq.Expr(`slug LIKE ? ESCAPE '\'`, like)}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(m.runner()).
+		Query()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	slugs := make(map[string]bool)
+
+	for rows.Next() {
+		s := ""
+
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+
+		slugs[s] = true
+	}
+
+	return slugs, rows.Err()
+}
@@ -0,0 +1,150 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Slugify(t *testing.T) {
+	cases := []struct {
+		value string
+		slug  string
+	}{
+		{"Hello World", "hello-world"},
+		{"Crème Brûlée!", "creme-brulee"},
+		{"  Straße & Smørrebrød  ", "strasse-smorrebrod"},
+		{"Łódź 2023", "lodz-2023"},
+		{"humans.txt", "humans-txt"},
+		{"日本", ""},
+		{"---", ""},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.slug, Slugify(c.value), c.value)
+	}
+
+	assert.Equal(t, SLUG_MAX_LENGTH, len(Slugify(strings.Repeat("a", 300))))
+}
+
+func Test_SqlNodeManager_Save_Slug(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	parent := newCachedUser(m, "blog")
+
+	nodes := make([]*Node, 0)
+	for i := 0; i < 3; i++ {
+		node := m.NewNode("node.user")
+		node.Name = "Crème Brûlée"
+		node.ParentUuid = parent.Uuid
+
+		_, err := m.Save(node, false)
+		assert.NoError(t, err)
+
+		nodes = append(nodes, node)
+	}
+
+	assert.Equal(t, "creme-brulee", nodes[0].Slug)
+	assert.Equal(t, "creme-brulee-1", nodes[1].Slug)
+	assert.Equal(t, "creme-brulee-2", nodes[2].Slug)
+
+	// the same slug can be used with another parent
+	other := m.NewNode("node.user")
+	other.Name = "Crème Brûlée"
+
+	m.Save(other, false)
+	assert.Equal(t, "creme-brulee", other.Slug)
+
+	// the node keeps its own slug
+	nodes[1].Slug = ""
+	_, err := m.Save(nodes[1], false)
+	assert.NoError(t, err)
+	assert.Equal(t, "creme-brulee-1", nodes[1].Slug)
+
+	// a name without latin characters
+	node := m.NewNode("node.user")
+	node.Name = "日本"
+
+	m.Save(node, false)
+	assert.Equal(t, node.Uuid.String(), node.Slug)
+
+	// a provided slug used by a sibling
+	nodes[2].Slug = "creme-brulee"
+	_, err = m.Save(nodes[2], false)
+
+	assert.IsType(t, Errors{}, err)
+	assert.True(t, err.(Errors).HasError("slug"))
+
+	ok, errors := m.Validate(nodes[2])
+	assert.False(t, ok)
+	assert.Equal(t, []string{"The slug is already used by a sibling"}, errors.GetError("slug"))
+}
+
+func Test_SqlNodeManager_Save_Slug_Freeze(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	m.FreezeSlug = true
+
+	node := newCachedUser(m, "page")
+
+	node.Slug = "renamed"
+	_, err := m.Save(node, false)
+
+	assert.IsType(t, Errors{}, err)
+	assert.Equal(t, "unable to validate data, slug: The slug cannot be changed once the node is published", err.Error())
+
+	// an empty slug is not generated again
+	node.Name = "Another name"
+	node.Slug = ""
+	_, err = m.Save(node, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "page", node.Slug)
+
+	// the node is not published yet
+	publishAt := time.Now().Add(time.Hour)
+	node.PublishAt = &publishAt
+	m.Save(node, false)
+
+	node.Slug = "renamed"
+	_, err = m.Save(node, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "renamed", m.Find(node.Uuid).Slug)
+}
+
+func Test_SqlNodeManager_Save_Slug_Race(t *testing.T) {
+	m, done := getSqliteManager(t)
+	defer done()
+
+	parent := newCachedUser(m, "blog")
+
+	// a sibling takes the slug between the validation and the insert
+	_, err := m.Db.Exec(`CREATE TRIGGER test_race AFTER INSERT ON test_nodes_audit WHEN NEW.slug = 'race' BEGIN
+		INSERT INTO test_nodes (uuid, type, slug, path, parent_uuid, revision, created_at, created_by, updated_at, updated_by)
+		VALUES ('11111111-1111-1111-1111-111111111111', NEW.type, NEW.slug, '', NEW.parent_uuid, NEW.revision, NEW.created_at, NEW.created_by, NEW.updated_at, NEW.updated_by);
+	END`)
+	assert.NoError(t, err)
+
+	node := m.NewNode("node.user")
+	node.Name = "Race"
+	node.ParentUuid = parent.Uuid
+
+	_, err = m.Save(node, false)
+
+	assert.IsType(t, Errors{}, err)
+	assert.Equal(t, []string{"The slug is already used by a sibling"}, err.(Errors).GetError("slug"))
+
+	// the transaction is rolled back
+	sibling, _ := GetReferenceFromString("11111111-1111-1111-1111-111111111111")
+	assert.Nil(t, m.Find(sibling))
+}
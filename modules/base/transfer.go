@@ -0,0 +1,425 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rande/gonode/core/vault"
+	log "github.com/sirupsen/logrus"
+)
+
+// errDryRun is used to rollback the import's transaction
+var errDryRun = errors.New("dry run")
+
+// ExportRecord is one line of a NDJSON export, the binaries are the vault's names of the
+// side files stored next to the export.
+type ExportRecord struct {
+	Node      json.RawMessage   `json:"node"`
+	Revisions []json.RawMessage `json:"revisions,omitempty"`
+	Binaries  []string          `json:"binaries,omitempty"`
+}
+
+type ExportOptions struct {
+	Types     []string // only export these node types, all types if empty
+	Revisions bool     // export the nodes_audit rows
+	Binaries  string   // directory used to store the vault's binaries, disabled if empty
+}
+
+type ImportOptions struct {
+	Upsert   bool   // update the nodes already stored, the nodes are skipped otherwise
+	DryRun   bool   // rollback the import once all the records are processed
+	Hooks    bool   // run the DatabaseNodeHandler hooks
+	Binaries string // directory containing the vault's binaries, disabled if empty
+}
+
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+// Exporter streams the nodes as newline delimited JSON
+type Exporter struct {
+	Manager *SqlNodeManager
+	Vault   *vault.Vault
+	Logger  *log.Logger
+}
+
+// Export writes one ExportRecord per node, the function returns the number of exported nodes.
+func (e *Exporter) Export(w io.Writer, options *ExportOptions) (int, error) {
+	query := e.Manager.SelectBuilder(NewSelectOptions()).OrderBy("id ASC")
+
+	if len(options.Types) > 0 {
+		query = query.Where(sq.Eq{"type": options.Types})
+	}
+
+	count := 0
+	last := 0
+
+	// the nodes are paged by id, so the nodes created during the export do not shift the pages
+	for {
+		nodes := e.Manager.FindBy(query.Where("id > ?", last), 0, 128)
+
+		if nodes.Len() == 0 {
+			return count, nil
+		}
+
+		for el := nodes.Front(); el != nil; el = el.Next() {
+			node := el.Value.(*Node)
+
+			if err := e.export(w, node, options); err != nil {
+				return count, err
+			}
+
+			last = node.Id
+			count++
+		}
+	}
+}
+
+func (e *Exporter) export(w io.Writer, node *Node, options *ExportOptions) error {
+	var err error
+
+	record := &ExportRecord{}
+
+	if record.Node, err = json.Marshal(node); err != nil {
+		return err
+	}
+
+	revisions := []*Node{node}
+
+	if options.Revisions {
+		selectOptions := NewSelectOptions()
+		selectOptions.TableSuffix = "nodes_audit"
+
+		query := e.Manager.SelectBuilder(selectOptions).
+			Where(sq.Eq{"uuid": node.Uuid.CleanString()}).
+			OrderBy("revision ASC")
+
+		revisions = make([]*Node, 0)
+
+		for el := e.Manager.FindBy(query, 0, 1<<20).Front(); el != nil; el = el.Next() {
+			revision := el.Value.(*Node)
+
+			data, err := json.Marshal(revision)
+
+			if err != nil {
+				return err
+			}
+
+			record.Revisions = append(record.Revisions, data)
+			revisions = append(revisions, revision)
+		}
+	}
+
+	if options.Binaries != "" && e.Vault != nil {
+		for _, revision := range revisions {
+			name := revision.UniqueId()
+
+			if !e.Vault.Has(name) {
+				continue
+			}
+
+			if err := e.exportBinary(name, options.Binaries); err != nil {
+				return err
+			}
+
+			record.Binaries = append(record.Binaries, name)
+		}
+	}
+
+	data, err := json.Marshal(record)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	if e.Logger != nil {
+		e.Logger.WithFields(log.Fields{
+			"type":     node.Type,
+			"uuid":     node.Uuid,
+			"binaries": len(record.Binaries),
+			"module":   "node.export",
+		}).Debug("export node")
+	}
+
+	return nil
+}
+
+func (e *Exporter) exportBinary(name, dir string) error {
+	file, err := os.Create(filepath.Join(dir, name+".bin"))
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	_, err = e.Vault.Get(name, file)
+
+	return err
+}
+
+// Importer loads a NDJSON export, the uuid, parents and path of the nodes are preserved.
+// The records are imported in one transaction.
+type Importer struct {
+	Manager *SqlNodeManager
+	Vault   *vault.Vault
+	Logger  *log.Logger
+}
+
+func (i *Importer) Import(r io.Reader, options *ImportOptions) (*ImportResult, error) {
+	result := &ImportResult{}
+	binaries := make(map[string]*Node)
+
+	err := i.Manager.Transaction(func(tx NodeManager) error {
+		m := tx.(*SqlNodeManager)
+
+		decoder := json.NewDecoder(r)
+
+		for line := 1; ; line++ {
+			record := &ExportRecord{}
+
+			if err := decoder.Decode(record); err == io.EOF {
+				break
+			} else if err != nil {
+				return fmt.Errorf("record %d: %w", line, err)
+			}
+
+			if err := i.importRecord(m, record, options, result, binaries); err != nil {
+				return fmt.Errorf("record %d: %w", line, err)
+			}
+		}
+
+		if options.DryRun {
+			return errDryRun
+		}
+
+		return nil
+	})
+
+	if err == errDryRun {
+		return result, nil
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	// the binaries are only written once the records are committed, so a dry run or a failed import
+	// does not leave files in the vault
+	for name, node := range binaries {
+		if err := i.importBinary(name, node, options.Binaries); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func (i *Importer) importRecord(m *SqlNodeManager, record *ExportRecord, options *ImportOptions, result *ImportResult, binaries map[string]*Node) error {
+	node, err := i.load(record.Node)
+
+	if err != nil {
+		return err
+	}
+
+	saved := m.Find(node.Uuid)
+
+	if saved != nil && !options.Upsert {
+		result.Skipped++
+
+		return nil
+	}
+
+	handler := m.Handlers.Get(node)
+	hooks, _ := handler.(DatabaseNodeHandler)
+
+	if !options.Hooks {
+		hooks = nil
+	}
+
+	if saved == nil {
+		if hooks != nil {
+			hooks.PreInsert(node, m)
+		}
+
+		if _, err := m.insertNode(node, m.Prefix+"_nodes"); err != nil {
+			return err
+		}
+
+		if hooks != nil {
+			hooks.PostInsert(node, m)
+		}
+
+		result.Created++
+	} else {
+		node.Id = saved.Id
+
+		if hooks != nil {
+			hooks.PreUpdate(node, m)
+		}
+
+		if _, err := m.updateNode(node, m.Prefix+"_nodes"); err != nil {
+			return err
+		}
+
+		if hooks != nil {
+			hooks.PostUpdate(node, m)
+		}
+
+		result.Updated++
+	}
+
+	// the update query does not alter the tree, the imported position is kept as is
+	parents := make([]string, 0)
+	for _, p := range node.Parents {
+		parents = append(parents, p.CleanString())
+	}
+
+	_, err = m.tx.Exec(fmt.Sprintf(`UPDATE "%s_nodes" SET parent_uuid = $1, parents = $2, path = $3 WHERE id = $4`, m.Prefix),
+		node.ParentUuid.CleanString(), m.dialect().ArrayValue(parents), node.Path, node.Id)
+
+	if err != nil {
+		return err
+	}
+
+	if err := i.importRevisions(m, node, record); err != nil {
+		return err
+	}
+
+	if err := m.saveRelations(node); err != nil {
+		return err
+	}
+
+	if options.Binaries != "" {
+		revisions := map[string]*Node{node.UniqueId(): node}
+
+		for _, raw := range record.Revisions {
+			if revision, err := i.load(raw); err == nil {
+				revisions[revision.UniqueId()] = revision
+			}
+		}
+
+		for _, name := range record.Binaries {
+			if revision, ok := revisions[name]; ok {
+				binaries[name] = revision
+			}
+		}
+	}
+
+	m.sendNotification(m.Prefix+"_manager_action", &ModelEvent{
+		Type:     node.Type,
+		Action:   "Import",
+		Subject:  node.Uuid.CleanString(),
+		Revision: node.Revision,
+		Date:     time.Now(),
+		Name:     node.Name,
+	})
+
+	if i.Logger != nil {
+		i.Logger.WithFields(log.Fields{
+			"type":   node.Type,
+			"uuid":   node.Uuid,
+			"update": saved != nil,
+			"module": "node.import",
+		}).Debug("import node")
+	}
+
+	return nil
+}
+
+// importRevisions replaces the audit rows with the exported revisions, if the revisions are not
+// exported the current revision is added to the audit table.
+func (i *Importer) importRevisions(m *SqlNodeManager, node *Node, record *ExportRecord) error {
+	id := node.Id
+	defer func() {
+		node.Id = id
+	}()
+
+	if len(record.Revisions) == 0 {
+		exists := false
+
+		err := m.tx.QueryRow(fmt.Sprintf(`SELECT EXISTS(SELECT 1 FROM "%s_nodes_audit" WHERE uuid = $1 AND revision = $2)`, m.Prefix),
+			node.Uuid.CleanString(), node.Revision).Scan(&exists)
+
+		if err != nil || exists {
+			return err
+		}
+
+		_, err = m.insertNode(node, m.Prefix+"_nodes_audit")
+
+		return err
+	}
+
+	if _, err := m.tx.Exec(fmt.Sprintf(`DELETE FROM "%s_nodes_audit" WHERE uuid = $1`, m.Prefix), node.Uuid.CleanString()); err != nil {
+		return err
+	}
+
+	for _, raw := range record.Revisions {
+		revision, err := i.load(raw)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := m.insertNode(revision, m.Prefix+"_nodes_audit"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// load decodes the full node, the handler's deserializers are not used as they can drop
+// private fields (ie, the user's password).
+func (i *Importer) load(data json.RawMessage) (*Node, error) {
+	node := NewNode()
+
+	// we need to deserialize twice to load the correct Meta/Data structure
+	if err := Deserialize(bytes.NewReader(data), node); err != nil {
+		return nil, err
+	}
+
+	node.Data, node.Meta = i.Manager.Handlers.Get(node).GetStruct()
+
+	if err := Deserialize(bytes.NewReader(data), node); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+func (i *Importer) importBinary(name string, node *Node, dir string) error {
+	file, err := os.Open(filepath.Join(dir, name+".bin"))
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	if i.Vault.Has(name) {
+		i.Vault.Remove(name)
+	}
+
+	_, err = i.Vault.Put(name, GetVaultMetadata(node), file)
+
+	return err
+}
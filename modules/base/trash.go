@@ -0,0 +1,241 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rande/gonode/core/vault"
+	log "github.com/sirupsen/logrus"
+)
+
+// Trash manages the soft deleted nodes: undelete and hard purge
+type Trash struct {
+	Manager *SqlNodeManager
+	Vault   *vault.Vault
+	Logger  *log.Logger
+}
+
+// Undelete restores a soft deleted node, if recursive is true the deleted nodes having the node
+// in their parents are also restored. The function returns the number of restored nodes.
+func (t *Trash) Undelete(node *Node, recursive bool) (int, error) {
+	if !node.Deleted {
+		return 0, nil
+	}
+
+	count := 0
+
+	err := t.Manager.Transaction(func(tx NodeManager) error {
+		m := tx.(*SqlNodeManager)
+
+		if err := t.undelete(m, node); err != nil {
+			return err
+		}
+
+		count++
+
+		if !recursive {
+			return nil
+		}
+
+		query := m.SelectBuilder(NewSelectOptions()).
+			Where("deleted = ?", true).
+			Where(m.dialect().ArrayContains("parents", "?"), node.Uuid.CleanString())
+
+		for {
+			nodes := m.FindBy(query, 0, 1024)
+
+			if nodes.Len() == 0 {
+				return nil
+			}
+
+			for e := nodes.Front(); e != nil; e = e.Next() {
+				if err := t.undelete(m, e.Value.(*Node)); err != nil {
+					return err
+				}
+
+				count++
+			}
+		}
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (t *Trash) undelete(m *SqlNodeManager, node *Node) error {
+	node.Deleted = false
+
+	if _, err := m.Save(node, true); err != nil {
+		return err
+	}
+
+	m.sendNotification(m.Prefix+"_manager_action", &ModelEvent{
+		Type:     node.Type,
+		Action:   "Undelete",
+		Subject:  node.Uuid.CleanString(),
+		Revision: node.Revision,
+		Date:     node.UpdatedAt,
+		Name:     node.Name,
+	})
+
+	if t.Logger != nil {
+		t.Logger.WithFields(log.Fields{
+			"type":   node.Type,
+			"uuid":   node.Uuid,
+			"module": "node.trash",
+		}).Info("undelete node")
+	}
+
+	return nil
+}
+
+// Purge removes the nodes deleted before the retention period, the rows from the nodes and
+// nodes_audit tables are removed with the vault's binaries of each revision. A node having
+// children is kept until its children are purged, so the deleted children are purged first and
+// a deleted node with active children stays in the trash.
+// The function returns the number of purged nodes.
+func (t *Trash) Purge(retention time.Duration) (int, error) {
+	table := t.Manager.Prefix + "_nodes"
+
+	query := t.Manager.SelectBuilder(NewSelectOptions()).
+		Where("deleted = ?", true).
+		Where("updated_at < ?", time.Now().Add(-retention)).
+		Where(fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s c WHERE c.parent_uuid = %s.uuid)", table, table))
+
+	count := 0
+
+	for {
+		nodes := t.Manager.FindBy(query, 0, 128)
+
+		if nodes.Len() == 0 {
+			return count, nil
+		}
+
+		for e := nodes.Front(); e != nil; e = e.Next() {
+			err := t.purge(e.Value.(*Node))
+
+			// a child has been added in the meantime, the node is not selected anymore
+			if err == ErrHasChildren {
+				continue
+			}
+
+			if err != nil {
+				return count, err
+			}
+
+			count++
+		}
+	}
+}
+
+func (t *Trash) purge(node *Node) error {
+	revisions := make([]int, 0)
+
+	err := t.Manager.Transaction(func(tx NodeManager) error {
+		m := tx.(*SqlNodeManager)
+
+		children := 0
+
+		if err := m.runner().QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s_nodes" WHERE parent_uuid = $1`, m.Prefix), node.Uuid.CleanString()).Scan(&children); err != nil {
+			return err
+		}
+
+		if children > 0 {
+			return ErrHasChildren
+		}
+
+		rows, err := sq.Select("revision").
+			From(m.Prefix + "_nodes_audit").
+			Where(sq.Eq{"uuid": node.Uuid.CleanString()}).
+			PlaceholderFormat(sq.Dollar).
+			RunWith(m.runner()).
+			Query()
+
+		if err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			revision := 0
+
+			if err := rows.Scan(&revision); err != nil {
+				rows.Close()
+
+				return err
+			}
+
+			revisions = append(revisions, revision)
+		}
+
+		if err := rows.Close(); err != nil {
+			return err
+		}
+
+		if _, err := m.tx.Exec(fmt.Sprintf(`DELETE FROM "%s_nodes_audit" WHERE uuid = $1`, m.Prefix), node.Uuid.CleanString()); err != nil {
+			return err
+		}
+
+		if _, err := m.tx.Exec(fmt.Sprintf(`DELETE FROM "%s_nodes" WHERE uuid = $1`, m.Prefix), node.Uuid.CleanString()); err != nil {
+			return err
+		}
+
+		// the relations of the other nodes to the purged node cannot be resolved anymore
+		if _, err := m.tx.Exec(fmt.Sprintf(`DELETE FROM "%s_nodes_relations" WHERE source_uuid = $1 OR target_uuid = $2`, m.Prefix), node.Uuid.CleanString(), node.Uuid.CleanString()); err != nil {
+			return err
+		}
+
+		if _, err := m.tx.Exec(fmt.Sprintf(`DELETE FROM "%s_nodes_redirects" WHERE uuid = $1`, m.Prefix), node.Uuid.CleanString()); err != nil {
+			return err
+		}
+
+		m.sendNotification(m.Prefix+"_manager_action", &ModelEvent{
+			Type:     node.Type,
+			Action:   "Purge",
+			Subject:  node.Uuid.CleanString(),
+			Revision: node.Revision,
+			Date:     time.Now(),
+			Name:     node.Name,
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if t.Logger != nil {
+		t.Logger.WithFields(log.Fields{
+			"type":      node.Type,
+			"uuid":      node.Uuid,
+			"revisions": len(revisions),
+			"module":    "node.trash",
+		}).Info("purge node")
+	}
+
+	if t.Vault == nil {
+		return nil
+	}
+
+	// the files are removed after the commit, a failure leaves unused files but no row pointing
+	// to a missing binary
+	for _, revision := range revisions {
+		n := *node
+		n.Revision = revision
+
+		if t.Vault.Has(n.UniqueId()) {
+			t.Vault.Remove(n.UniqueId())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"github.com/rande/gonode/core/config"
+)
+
+// Transition moves a node from one of the From statuses to the To status, the Roles are
+// required to apply the transition. An empty Roles list allows anybody.
+type Transition struct {
+	Name  string   `json:"name"`
+	From  []int    `json:"from"`
+	To    int      `json:"to"`
+	Roles []string `json:"roles"`
+}
+
+func (t *Transition) Support(status int) bool {
+	for _, from := range t.From {
+		if from == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Workflow lists the allowed status transitions for a node type, a new node must be created
+// with the Initial status.
+type Workflow struct {
+	Initial     int           `json:"initial"`
+	Transitions []*Transition `json:"transitions"`
+}
+
+func NewWorkflowFromConfig(conf *config.Workflow) *Workflow {
+	w := &Workflow{
+		Initial:     conf.Initial,
+		Transitions: make([]*Transition, 0),
+	}
+
+	for _, t := range conf.Transitions {
+		w.Transitions = append(w.Transitions, &Transition{
+			Name:  t.Name,
+			From:  t.From,
+			To:    t.To,
+			Roles: t.Roles,
+		})
+	}
+
+	return w
+}
+
+// Get returns the transition named name, or nil
+func (w *Workflow) Get(name string) *Transition {
+	for _, t := range w.Transitions {
+		if t.Name == name {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// Find returns the first transition from one status to the other one, or nil
+func (w *Workflow) Find(from, to int) *Transition {
+	for _, t := range w.Transitions {
+		if t.To == to && t.Support(from) {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// WorkflowNodeHandler declares the default workflow of a node type, the workflow can be
+// overwritten by the configuration.
+type WorkflowNodeHandler interface {
+	GetWorkflow() *Workflow
+}
+
+func NewWorkflows(handlers Handlers) *Workflows {
+	return &Workflows{
+		Handlers:  handlers,
+		workflows: make(map[string]*Workflow),
+	}
+}
+
+// Workflows returns the workflow used by a node type, the node types without workflow
+// accept any status.
+type Workflows struct {
+	Handlers  Handlers
+	workflows map[string]*Workflow
+}
+
+func (w *Workflows) Add(nodeType string, workflow *Workflow) {
+	w.workflows[nodeType] = workflow
+}
+
+func (w *Workflows) Get(node *Node) *Workflow {
+	if workflow, ok := w.workflows[node.Type]; ok {
+		return workflow
+	}
+
+	if w.Handlers == nil || !w.Handlers.HasType(node.Type) {
+		return nil
+	}
+
+	if h, ok := w.Handlers.Get(node).(WorkflowNodeHandler); ok {
+		return h.GetWorkflow()
+	}
+
+	return nil
+}
+
+// Check returns the transition used to move the node from the saved version to the current
+// one, saved is nil for a new node. An error is returned if the status change is not allowed.
+func (w *Workflows) Check(saved *Node, node *Node) (*Transition, error) {
+	workflow := w.Get(node)
+
+	if workflow == nil {
+		return nil, nil
+	}
+
+	if saved == nil {
+		if node.Status != workflow.Initial {
+			return nil, ErrInvalidTransition
+		}
+
+		return nil, nil
+	}
+
+	if saved.Status == node.Status {
+		return nil, nil
+	}
+
+	if t := workflow.Find(saved.Status, node.Status); t != nil {
+		return t, nil
+	}
+
+	return nil, ErrInvalidTransition
+}
@@ -0,0 +1,75 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"testing"
+
+	"github.com/rande/gonode/core/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestWorkflow() *Workflow {
+	return NewWorkflowFromConfig(&config.Workflow{
+		Initial: StatusDraft,
+		Transitions: []*config.WorkflowTransition{
+			{Name: "submit", From: []int{StatusDraft}, To: StatusCompleted},
+			{Name: "validate", From: []int{StatusCompleted}, To: StatusValidated, Roles: []string{"node:publisher"}},
+			{Name: "reject", From: []int{StatusCompleted, StatusValidated}, To: StatusDraft},
+		},
+	})
+}
+
+func Test_Workflow_Get_Find(t *testing.T) {
+	w := getTestWorkflow()
+
+	assert.Equal(t, "validate", w.Get("validate").Name)
+	assert.Nil(t, w.Get("publish"))
+
+	assert.Equal(t, "reject", w.Find(StatusValidated, StatusDraft).Name)
+	assert.Nil(t, w.Find(StatusDraft, StatusValidated))
+}
+
+func Test_Workflows_Check(t *testing.T) {
+	c := HandlerCollection{
+		"node.user": &UserHandler{},
+	}
+
+	workflows := NewWorkflows(c)
+	workflows.Add("node.user", getTestWorkflow())
+
+	saved := c.NewNode("node.user")
+	saved.Status = StatusDraft
+
+	node := c.NewNode("node.user")
+
+	// new node
+	_, err := workflows.Check(nil, node)
+	assert.Equal(t, ErrInvalidTransition, err)
+
+	node.Status = StatusDraft
+	_, err = workflows.Check(nil, node)
+	assert.NoError(t, err)
+
+	// existing node
+	node.Status = StatusValidated
+	_, err = workflows.Check(saved, node)
+	assert.Equal(t, ErrInvalidTransition, err)
+
+	node.Status = StatusCompleted
+	transition, err := workflows.Check(saved, node)
+	assert.NoError(t, err)
+	assert.Equal(t, "submit", transition.Name)
+
+	// no workflow
+	other := NewNode()
+	other.Type = "node.other"
+	other.Status = StatusValidated
+
+	transition, err = workflows.Check(nil, other)
+	assert.NoError(t, err)
+	assert.Nil(t, transition)
+}
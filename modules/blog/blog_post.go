@@ -42,3 +42,7 @@ func (h *PostHandler) GetMetadata() *base.HandlerMetadata {
 
 	return meta
 }
+
+func (h *PostHandler) GetReferenceFields() []string {
+	return []string{"MainImage"}
+}
@@ -37,7 +37,7 @@ func Dashboard_GET_ToDo(app *goapp.App) ViewHandlerInterface {
 }
 
 func Dashboard_GET_Node_List(app *goapp.App) ViewHandlerInterface {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 	logger := app.Get("logger").(*logrus.Logger)
 
 	queryBuilder := app.Get("gonode.search.pgsql").(*search.SearchPGSQL)
@@ -67,7 +67,7 @@ type NodeForm struct {
 }
 
 func Dashboard_GET_Node_Edit(app *goapp.App) ViewHandlerInterface {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 	logger := app.Get("logger").(*logrus.Logger)
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) *ViewResponse {
@@ -105,7 +105,7 @@ func Dashboard_GET_Node_Edit(app *goapp.App) ViewHandlerInterface {
 }
 
 func Dashboard_GET_Node_Update(app *goapp.App) ViewHandlerInterface {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 	// logger := app.Get("logger").(*logrus.Logger)
 
 	return func(c web.C, res http.ResponseWriter, req *http.Request) *ViewResponse {
@@ -21,7 +21,7 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 		cv := app.Get("gonode.view_handler_collection").(base.ViewHandlerCollection)
 		cv.Add("feed.index", &FeedViewHandler{
 			Search:  app.Get("gonode.search.pgsql").(*search.SearchPGSQL),
-			Manager: app.Get("gonode.manager").(*base.PgNodeManager),
+			Manager: app.Get("gonode.manager").(*base.SqlNodeManager),
 		})
 
 		return nil
@@ -18,7 +18,7 @@ import (
 )
 
 type GuardManager struct {
-	m *base.PgNodeManager
+	m *base.SqlNodeManager
 }
 
 func (g *GuardManager) GetUser(username string) (guard.GuardUser, error) {
@@ -37,7 +37,7 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 	l.Prepare(func(app *goapp.App) error {
 		mux := app.Get("goji.mux").(*web.Mux)
 		conf := app.Get("gonode.configuration").(*config.Config)
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 		logger := app.Get("logger").(*log.Logger)
 
 		ignore := []*regexp.Regexp{}
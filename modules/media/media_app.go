@@ -8,7 +8,6 @@ package media
 import (
 	"net/http"
 
-	pq "github.com/lib/pq"
 	"github.com/rande/goapp"
 	"github.com/rande/gonode/core/config"
 	"github.com/rande/gonode/core/vault"
@@ -52,26 +51,16 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 			AllowedWidths: conf.Media.Image.AllowedWidths,
 		})
 
-		// need to find a way to trigger the handler registration
+		// the notifications are stored as jobs, so the downloads do not block the notification loop
 		sub := app.Get("gonode.postgres.subscriber").(*base.Subscriber)
+		jobs := app.Get("gonode.jobs").(*base.JobQueue)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
-		sub.ListenMessage("media_youtube_update", func(app *goapp.App) base.SubscriberHander {
-			manager := app.Get("gonode.manager").(*base.PgNodeManager)
-			listener := app.Get("gonode.listener.youtube").(*YoutubeListener)
+		sub.ConsumeExclusive("media.youtube", "media_youtube_update", jobs.Enqueuer("media.youtube"))
+		jobs.Handle("media.youtube", base.ListenerJob(app.Get("gonode.listener.youtube").(*YoutubeListener), manager))
 
-			return func(notification *pq.Notification) (int, error) {
-				return listener.Handle(notification, manager)
-			}
-		}(app))
-
-		sub.ListenMessage("media_file_download", func(app *goapp.App) base.SubscriberHander {
-			manager := app.Get("gonode.manager").(*base.PgNodeManager)
-			listener := app.Get("gonode.listener.file_downloader").(*ImageDownloadListener)
-
-			return func(notification *pq.Notification) (int, error) {
-				return listener.Handle(notification, manager)
-			}
-		}(app))
+		sub.ConsumeExclusive("media.file_downloader", "media_file_download", jobs.Enqueuer("media.file_download"))
+		jobs.Handle("media.file_download", base.ListenerJob(app.Get("gonode.listener.file_downloader").(*ImageDownloadListener), manager))
 
 		return nil
 	})
@@ -32,7 +32,7 @@ func Test_ImageHandler_PreInsert(t *testing.T) {
 	node := base.NewNode()
 
 	handler := &ImageHandler{}
-	manager := &base.PgNodeManager{}
+	manager := &base.SqlNodeManager{}
 
 	node.Data, node.Meta = handler.GetStruct()
 
@@ -57,7 +57,7 @@ func Test_ImageHandler_PreUpdate(t *testing.T) {
 	node := base.NewNode()
 
 	handler := &ImageHandler{}
-	manager := &base.PgNodeManager{}
+	manager := &base.SqlNodeManager{}
 
 	node.Data, node.Meta = handler.GetStruct()
 
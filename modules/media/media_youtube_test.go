@@ -35,7 +35,7 @@ func Test_YoutubeHandler_PreInsert(t *testing.T) {
 	node := base.NewNode()
 
 	handler := &YoutubeHandler{}
-	manager := &base.PgNodeManager{}
+	manager := &base.SqlNodeManager{}
 
 	node.Data, node.Meta = handler.GetStruct()
 
@@ -60,7 +60,7 @@ func Test_YoutubeHandler_PreUpdate(t *testing.T) {
 	node := base.NewNode()
 
 	handler := &YoutubeHandler{}
-	manager := &base.PgNodeManager{}
+	manager := &base.SqlNodeManager{}
 
 	node.Data, node.Meta = handler.GetStruct()
 
@@ -12,8 +12,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
-	sq "github.com/Masterminds/squirrel"
 	"github.com/rande/goapp"
 	"github.com/rande/gonode/core/config"
 	"github.com/rande/gonode/core/embed"
@@ -26,7 +26,7 @@ import (
 )
 
 func RenderPrism(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+	manager := app.Get("gonode.manager.cache").(*base.NodeCache)
 	loader := app.Get("gonode.template").(*template.TemplateLoader)
 	handlers := app.Get("gonode.view_handler_collection").(base.ViewHandlerCollection)
 	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
@@ -90,26 +90,41 @@ func RenderPrism(app *goapp.App) func(c web.C, res http.ResponseWriter, req *htt
 				}).Debug("Search valid node")
 			}
 
-			query := manager.
-				SelectBuilder(base.NewSelectOptions()).
-				Where(sq.Eq{"path": lookupPaths})
-
-			nodes := manager.FindBy(query, 0, 2)
-
-			switch nodes.Len() {
-			case 0:
-				node = nil
-			case 1:
-				node = nodes.Front().Value.(*base.Node)
-			case 2:
-				for e := nodes.Front(); e != nil; e = e.Next() {
-					n := e.Value.(*base.Node)
-					if n.Path == req.URL.Path {
-						node = n
-						break
-					}
+			// the exact path has the priority over the path without the format
+			for _, lookupPath := range lookupPaths {
+				if node = manager.FindByPath(lookupPath); node != nil {
+					break
 				}
 			}
+
+			// a path used by a node before a move is redirected to the node's current path
+			for i := 0; node == nil && i < len(lookupPaths); i++ {
+				redirect := manager.FindRedirect(lookupPaths[i])
+
+				if redirect == nil {
+					continue
+				}
+
+				location := redirect.Path
+				if i > 0 {
+					location = fmt.Sprintf("%s.%s", location, format)
+				}
+
+				if req.URL.RawQuery != "" {
+					location = fmt.Sprintf("%s?%s", location, req.URL.RawQuery)
+				}
+
+				if logger != nil {
+					logger.WithFields(log.Fields{
+						"module":    "prism.view",
+						"node_uuid": redirect.Uuid.String(),
+						"location":  location,
+					}).Debug("Redirect a previous path")
+				}
+
+				http.Redirect(res, req, location, http.StatusMovedPermanently)
+				return
+			}
 		}
 
 		res.Header().Set("Content-Type", "text/html; charset=UTF-8")
@@ -130,6 +145,18 @@ func RenderPrism(app *goapp.App) func(c web.C, res http.ResponseWriter, req *htt
 
 		response.Add("request", req)
 
+		// a node out of its publication window is only visible to the editors
+		if node != nil && !base.IsEditor(token.GetRoles()) && !base.IsPublished(node, time.Now()) {
+			if logger != nil {
+				logger.WithFields(log.Fields{
+					"module":    "prism.view",
+					"node_uuid": node.Uuid.String(),
+				}).Debug("Node is not published")
+			}
+
+			node = nil
+		}
+
 		if node != nil {
 			if granted, err := authorizer.IsGranted(token, nil, node); err != nil {
 				if logger != nil {
@@ -16,7 +16,9 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 
 	l.Config(func(app *goapp.App) error {
 		app.Set("gonode.search.pgsql", func(app *goapp.App) interface{} {
-			return &SearchPGSQL{}
+			return &SearchPGSQL{
+				Dialect: app.Get("gonode.dialect").(base.Dialect),
+			}
 		})
 
 		app.Set("gonode.search.parser.http", func(app *goapp.App) interface{} {
@@ -35,7 +37,7 @@ func Configure(l *goapp.Lifecycle, conf *config.Config) {
 		cv := app.Get("gonode.view_handler_collection").(base.ViewHandlerCollection)
 		cv.Add("search.index", &IndexViewHandler{
 			Search:    app.Get("gonode.search.pgsql").(*SearchPGSQL),
-			Manager:   app.Get("gonode.manager").(*base.PgNodeManager),
+			Manager:   app.Get("gonode.manager").(*base.SqlNodeManager),
 			MaxResult: 128,
 		})
 
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/rande/gonode/core/helper"
@@ -31,20 +32,19 @@ func GetJsonQuery(left string, sep string) string {
 	return c
 }
 
-func GetJsonSearchQuery(query sq.SelectBuilder, params []*Param, field string) sq.SelectBuilder {
+func GetJsonSearchQuery(dialect base.Dialect, query sq.SelectBuilder, params []*Param, field string) sq.SelectBuilder {
 	//-- SELECT uuid, "data" #> '{tags,1}' as tags FROM nodes WHERE  "data" @> '{"tags": ["sport"]}'
 	//-- SELECT uuid, "data" #> '{tags}' AS tags FROM nodes WHERE  "data" -> 'tags' ?| array['sport'];
 	for _, param := range params {
 		value := param.Value.([]string)
+		path := strings.Split(param.SubField, ".")
 
 		if len(value) > 1 {
-			name := GetJsonQuery(field+"."+param.SubField, "->")
-			query = query.Where(squirrel.NewExprSlice(fmt.Sprintf("%s ??| ARRAY["+sq.Placeholders(len(value))+"]", name), value))
+			query = query.Where(squirrel.NewExprSlice(dialect.JsonContainsAny(field, path, len(value)), value))
 		}
 
 		if len(value) == 1 {
-			name := GetJsonQuery(field+"."+param.SubField, "->>")
-			query = query.Where(sq.Expr(fmt.Sprintf("%s = ?", name), value[0]))
+			query = query.Where(sq.Expr(fmt.Sprintf("%s = ?", dialect.JsonField(field, path, true)), value[0]))
 		}
 	}
 
@@ -68,7 +68,9 @@ func (s *SearchPager) PageQuery(page uint64) url.Values {
 	return params
 }
 
+// SearchPGSQL builds the search queries, the database specific parts are built by the dialect
 type SearchPGSQL struct {
+	Dialect base.Dialect
 }
 
 func AddEqClause(column string, query sq.SelectBuilder, params []*Param) sq.SelectBuilder {
@@ -100,7 +102,9 @@ func (s *SearchPGSQL) BuildQuery(searchForm *SearchForm, query sq.SelectBuilder)
 	for _, order := range searchForm.OrderBy {
 		helper.PanicIf(len(order.SubField) == 0, "OrderBy field name is empty")
 
-		query = query.OrderBy(GetJsonQuery(order.SubField, "->") + " " + order.Operation)
+		path := strings.Split(order.SubField, ".")
+
+		query = query.OrderBy(s.Dialect.JsonField(path[0], path[1:], false) + " " + order.Operation)
 	}
 
 	query = AddEqClause("uuid", query, searchForm.Uuid)
@@ -120,8 +124,28 @@ func (s *SearchPGSQL) BuildQuery(searchForm *SearchForm, query sq.SelectBuilder)
 	query = AddClause("deleted", query, searchForm.Deleted)
 	query = AddClause("current", query, searchForm.Current)
 
-	query = GetJsonSearchQuery(query, searchForm.Data, "data")
-	query = GetJsonSearchQuery(query, searchForm.Meta, "meta")
+	query = GetJsonSearchQuery(s.Dialect, query, searchForm.Data, "data")
+	query = GetJsonSearchQuery(s.Dialect, query, searchForm.Meta, "meta")
+
+	return query
+}
+
+// BuildAccessQuery restricts the query to the nodes the roles can access, the nodes out of
+// their publication window are only available to the editors.
+func (s *SearchPGSQL) BuildAccessQuery(query sq.SelectBuilder, options *base.AccessOptions) sq.SelectBuilder {
+	if options == nil {
+		return query
+	}
+
+	roles, _ := options.Roles.ToStringSlice()
+
+	if len(roles) > 0 {
+		query = query.Where(squirrel.NewExprSlice(s.Dialect.ArrayOverlap("\"access\"", len(roles)), roles))
+	}
+
+	if !base.IsEditor(roles) {
+		query = base.WherePublished(query, time.Now())
+	}
 
 	return query
 }
@@ -10,8 +10,6 @@ import (
 	"net/url"
 	"strconv"
 
-	sq "github.com/Masterminds/squirrel"
-	"github.com/rande/gonode/core/squirrel"
 	"github.com/rande/gonode/modules/base"
 )
 
@@ -158,11 +156,7 @@ func GetPager(form *SearchForm, manager base.NodeManager, engine *SearchPGSQL, o
 	query := engine.BuildQuery(form, manager.SelectBuilder(base.NewSelectOptions()))
 
 	// apply security access
-	if options != nil && len(options.Roles) > 0 {
-		value, _ := options.Roles.ToStringSlice()
-
-		query = query.Where(squirrel.NewExprSlice("\"access\" && ARRAY["+sq.Placeholders(len(options.Roles))+"]", value))
-	}
+	query = engine.BuildAccessQuery(query, options)
 
 	list := manager.FindBy(query, (form.Page-1)*form.PerPage, form.PerPage+1)
 
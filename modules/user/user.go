@@ -67,6 +67,18 @@ func (h *UserHandler) GetStruct() (base.NodeData, base.NodeMeta) {
 	}
 }
 
+// GetFieldAccess hides the password's hash and settings, the email is only available to the
+// authenticated users and the roles can only be altered by an administrator
+func (h *UserHandler) GetFieldAccess() base.FieldAccess {
+	return base.FieldAccess{
+		"data.password":      {Private: true},
+		"meta.password_cost": {Private: true},
+		"meta.password_algo": {Private: true},
+		"data.email":         {Read: []string{"node:api:master", "ROLE_API", "ROLE_ADMIN"}},
+		"data.roles":         {Write: []string{"node:api:master", "node:user:roles"}},
+	}
+}
+
 func (h *UserHandler) PreInsert(node *base.Node, m base.NodeManager) error {
 	updatePassword(node)
 
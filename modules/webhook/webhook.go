@@ -0,0 +1,103 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+
+	"github.com/rande/gonode/modules/base"
+)
+
+// the headers sent with each delivery
+const (
+	HEADER_SIGNATURE = "X-Gonode-Signature"
+	HEADER_EVENT     = "X-Gonode-Event"
+	HEADER_DELIVERY  = "X-Gonode-Delivery"
+)
+
+// Webhook is a subscription to the node events, the events are posted to the url. The empty
+// Types and Actions lists match all the node types and all the actions.
+type Webhook struct {
+	Url     string   `json:"url"`
+	Secret  string   `json:"secret"`
+	Types   []string `json:"types"`
+	Actions []string `json:"actions"`
+}
+
+type WebhookMeta struct {
+}
+
+// Matches returns true if the event must be delivered to the webhook
+func (w *Webhook) Matches(event *base.ModelEvent) bool {
+	return contains(w.Types, event.Type) && contains(w.Actions, event.Action)
+}
+
+func contains(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sign returns the value of the signature header: the hex encoded HMAC-SHA256 of the body
+// computed with the webhook's secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type WebhookHandler struct {
+}
+
+func (h *WebhookHandler) GetStruct() (base.NodeData, base.NodeMeta) {
+	return &Webhook{
+		Types:   make([]string, 0),
+		Actions: make([]string, 0),
+	}, &WebhookMeta{}
+}
+
+func (h *WebhookHandler) GetMetadata() *base.HandlerMetadata {
+	meta := base.NewHandlerMetadata()
+
+	meta.Description = "Posts the node events to an HTTP endpoint"
+	meta.Name = "Webhook"
+
+	return meta
+}
+
+// GetFieldAccess restricts the secret to the administrators
+func (h *WebhookHandler) GetFieldAccess() base.FieldAccess {
+	return base.FieldAccess{
+		"data.secret": {
+			Read:  []string{"node:api:master", "node:api:webhook"},
+			Write: []string{"node:api:master", "node:api:webhook"},
+		},
+	}
+}
+
+func (h *WebhookHandler) Validate(node *base.Node, m base.NodeManager, errors base.Errors) {
+	data := node.Data.(*Webhook)
+
+	if u, err := url.Parse(data.Url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errors.AddError("data.url", "The url must be an absolute http or https url")
+	}
+
+	if data.Secret == "" {
+		errors.AddError("data.secret", "The secret cannot be empty")
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/core/config"
+	"github.com/rande/gonode/core/helper"
+	"github.com/rande/gonode/core/migration"
+	"github.com/rande/gonode/modules/base"
+	log "github.com/sirupsen/logrus"
+	"github.com/zenazn/goji/web"
+)
+
+func Configure(l *goapp.Lifecycle, conf *config.Config) {
+
+	l.Register(func(app *goapp.App) error {
+		app.Set("gonode.webhook.dispatcher", func(app *goapp.App) interface{} {
+			backoff, err := time.ParseDuration(conf.Webhooks.Backoff)
+			helper.PanicOnError(err)
+
+			timeout, err := time.ParseDuration(conf.Webhooks.Timeout)
+			helper.PanicOnError(err)
+
+			return &Dispatcher{
+				Manager:     app.Get("gonode.manager").(*base.SqlNodeManager),
+				Jobs:        app.Get("gonode.jobs").(*base.JobQueue),
+				HttpClient:  &http.Client{Timeout: timeout},
+				Logger:      app.Get("logger").(*log.Logger),
+				MaxAttempts: conf.Webhooks.MaxAttempts,
+				Backoff:     backoff,
+			}
+		})
+
+		return nil
+	})
+
+	l.Prepare(func(app *goapp.App) error {
+		migrator := app.Get("gonode.migrator").(*migration.Migrator)
+		for _, m := range append(GetMigrations(), GetSqliteMigrations()...) {
+			helper.PanicOnError(migrator.Add(m))
+		}
+
+		c := app.Get("gonode.handler_collection").(base.HandlerCollection)
+		c.Add("core.webhook", &WebhookHandler{})
+
+		dispatcher := app.Get("gonode.webhook.dispatcher").(*Dispatcher)
+
+		sub := app.Get("gonode.postgres.subscriber").(*base.Subscriber)
+		sub.ConsumeEventsExclusive("webhook.dispatcher", conf.Databases["master"].Prefix+"_manager_action", dispatcher.HandleEvent)
+
+		// the deliveries are posted by the job workers, the retries follow the webhooks' settings
+		// and the other options can be configured with the jobs.queues section
+		jobs := app.Get("gonode.jobs").(*base.JobQueue)
+
+		options, ok := jobs.Options[DELIVERY_QUEUE]
+		if !ok {
+			options = base.DefaultJobOptions()
+		}
+
+		options.MaxAttempts = dispatcher.MaxAttempts
+		options.Backoff = dispatcher.Backoff
+		jobs.Options[DELIVERY_QUEUE] = options
+
+		jobs.Handle(DELIVERY_QUEUE, dispatcher.HandleJob)
+
+		mux := app.Get("goji.mux").(*web.Mux)
+
+		mux.Get(conf.Api.Prefix+"/:version/webhooks/:uuid/deliveries", Api_GET_Webhook_Deliveries(app))
+		mux.Get(conf.Api.Prefix+"/:version/webhooks/deliveries/:id", Api_GET_Webhook_Delivery(app))
+		mux.Post(conf.Api.Prefix+"/:version/webhooks/deliveries/:id/replay", Api_POST_Webhook_Delivery_Replay(app))
+
+		return nil
+	})
+}
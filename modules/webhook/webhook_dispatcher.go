@@ -0,0 +1,440 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"github.com/rande/gonode/modules/base"
+	log "github.com/sirupsen/logrus"
+)
+
+// the status of a delivery
+const (
+	DELIVERY_PENDING = "pending"
+	DELIVERY_SUCCESS = "success"
+	DELIVERY_FAILED  = "failed"
+)
+
+// the job queue posting the deliveries, the payload is the delivery's id
+const DELIVERY_QUEUE = "webhook.delivery"
+
+// the number of bytes of the receiver's response stored with an attempt
+const responseLimit = 1024
+
+// Delivery is an event posted to a webhook, the delivery is retried until the receiver
+// answers with a 2xx status code or until the maximum number of attempts is reached.
+type Delivery struct {
+	Id            int64          `json:"id"`
+	Webhook       base.Reference `json:"webhook"`
+	Action        string         `json:"action"`
+	Subject       string         `json:"subject"`
+	Payload       string         `json:"payload"`
+	Status        string         `json:"status"`
+	Attempts      int            `json:"attempts"`
+	NextAttemptAt *time.Time     `json:"next_attempt_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	History       []*Attempt     `json:"history,omitempty"`
+}
+
+// Attempt is the log of one request sent to the webhook's url, StatusCode is 0 if the
+// request failed before receiving a response.
+type Attempt struct {
+	Id         int64     `json:"id"`
+	DeliveryId int64     `json:"delivery_id"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error"`
+	Response   string    `json:"response"`
+	Duration   int64     `json:"duration"` // in milliseconds
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (a *Attempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// Dispatcher posts the node events to the matching core.webhook nodes. The deliveries are
+// posted by the workers of the DELIVERY_QUEUE job queue, the failed deliveries are retried
+// with an exponential backoff: Backoff, then 2 * Backoff, 4 * Backoff, ...
+type Dispatcher struct {
+	Manager     *base.SqlNodeManager
+	Jobs        *base.JobQueue
+	HttpClient  base.HttpClient
+	Logger      *log.Logger
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+func (d *Dispatcher) deliveries() string {
+	return d.Manager.Prefix + "_webhooks_deliveries"
+}
+
+func (d *Dispatcher) attempts() string {
+	return d.Manager.Prefix + "_webhooks_attempts"
+}
+
+// HandleEvent is the consumer's handler of the <prefix>_manager_action channel
+func (d *Dispatcher) HandleEvent(e *base.Event) error {
+	_, err := d.Dispatch(e.Id, base.CreateModelEvent(&pq.Notification{Channel: e.Channel, Extra: e.Payload}), e.Payload)
+
+	return err
+}
+
+// Dispatch creates a delivery of the payload for each webhook matching the event, the
+// deliveries are enqueued so the notification loop is not blocked by the receivers. Each
+// delivery is stored with its job in one transaction, a failing webhook does not stop the
+// others and the errors are returned together. A webhook has a single delivery per event's
+// id, so the webhooks already served are skipped when the event is handled again. The id 0
+// disables this check.
+func (d *Dispatcher) Dispatch(eventId int64, event *base.ModelEvent, payload string) ([]*Delivery, error) {
+	query := d.Manager.SelectBuilder(base.NewSelectOptions()).
+		Where(sq.Eq{"type": "core.webhook", "enabled": true, "deleted": false})
+
+	deliveries := make([]*Delivery, 0)
+	errs := make([]string, 0)
+
+	for e := d.Manager.FindBy(query, 0, 1024).Front(); e != nil; e = e.Next() {
+		node := e.Value.(*base.Node)
+
+		if !node.Data.(*Webhook).Matches(event) {
+			continue
+		}
+
+		delivery, err := d.create(node.Uuid, eventId, event.Action, event.Subject, payload)
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", node.Uuid.CleanString(), err.Error()))
+
+			continue
+		}
+
+		if delivery != nil {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	if len(deliveries) > 0 {
+		d.Jobs.Notify(DELIVERY_QUEUE)
+	}
+
+	if len(errs) > 0 {
+		return deliveries, fmt.Errorf("unable to create the deliveries of the event %d: %s", eventId, strings.Join(errs, ", "))
+	}
+
+	return deliveries, nil
+}
+
+// create stores the delivery and its job, nil is returned if the webhook already has a delivery
+// of the event. The workers are not notified.
+func (d *Dispatcher) create(webhook base.Reference, eventId int64, action, subject, payload string) (*Delivery, error) {
+	now := time.Now()
+
+	delivery := &Delivery{
+		Webhook:       webhook,
+		Action:        action,
+		Subject:       subject,
+		Payload:       payload,
+		Status:        DELIVERY_PENDING,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	event := sql.NullInt64{Int64: eventId, Valid: eventId > 0}
+
+	err := d.Manager.Transaction(func(tx base.NodeManager) error {
+		err := sq.Insert(d.deliveries()).
+			Columns("webhook", "event_id", "action", "subject", "payload", "status", "attempts", "next_attempt_at", "created_at", "updated_at").
+			Values(webhook.CleanString(), event, action, subject, payload, delivery.Status, 0, now, now, now).
+			Suffix("ON CONFLICT (\"webhook\", \"event_id\") DO NOTHING RETURNING \"id\"").
+			PlaceholderFormat(sq.Dollar).
+			RunWith(tx.(*base.SqlNodeManager).Runner()).
+			QueryRow().
+			Scan(&delivery.Id)
+
+		// the webhook already has a delivery of the event
+		if err == sql.ErrNoRows {
+			delivery = nil
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = d.Jobs.EnqueueTx(tx, DELIVERY_QUEUE, strconv.FormatInt(delivery.Id, 10))
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// HandleJob is the handler of the DELIVERY_QUEUE jobs, an error is returned while the delivery
+// is pending so the job queue runs the next attempt after the backoff.
+func (d *Dispatcher) HandleJob(job *base.Job) error {
+	id, err := strconv.ParseInt(job.Payload, 10, 64)
+
+	if err != nil {
+		return err
+	}
+
+	delivery, err := d.FindDelivery(id)
+
+	if err == base.ErrNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// the delivery is already done, ie: the claim of a previous worker has expired
+	if delivery.Status != DELIVERY_PENDING {
+		return nil
+	}
+
+	if err := d.Deliver(delivery); err != nil {
+		return err
+	}
+
+	if delivery.Status == DELIVERY_PENDING {
+		return fmt.Errorf("the delivery %d has failed, attempt %d", delivery.Id, delivery.Attempts)
+	}
+
+	return nil
+}
+
+// Deliver posts the delivery's payload to the webhook and logs the attempt, an error is
+// returned only if the delivery cannot be stored. The delivery fails without retry if the
+// webhook has been removed or disabled.
+func (d *Dispatcher) Deliver(delivery *Delivery) error {
+	attempt := &Attempt{
+		DeliveryId: delivery.Id,
+		CreatedAt:  time.Now(),
+	}
+
+	node := d.Manager.Find(delivery.Webhook)
+	available := node != nil && node.Type == "core.webhook" && node.Enabled && !node.Deleted
+
+	if available {
+		d.post(node.Data.(*Webhook), delivery, attempt)
+	} else {
+		attempt.Error = "the webhook is not available"
+	}
+
+	delivery.Attempts++
+	delivery.UpdatedAt = time.Now()
+	delivery.NextAttemptAt = nil
+
+	switch {
+	case attempt.Succeeded():
+		delivery.Status = DELIVERY_SUCCESS
+	case !available || delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = DELIVERY_FAILED
+	default:
+		next := delivery.UpdatedAt.Add(d.Backoff << uint(delivery.Attempts-1))
+
+		delivery.Status = DELIVERY_PENDING
+		delivery.NextAttemptAt = &next
+	}
+
+	if d.Logger != nil {
+		d.Logger.WithFields(log.Fields{
+			"module":      "webhook.dispatcher",
+			"delivery":    delivery.Id,
+			"webhook":     delivery.Webhook.CleanString(),
+			"status":      delivery.Status,
+			"status_code": attempt.StatusCode,
+			"error":       attempt.Error,
+		}).Debug("deliver event")
+	}
+
+	err := sq.Insert(d.attempts()).
+		Columns("delivery_id", "status_code", "error", "response", "duration", "created_at").
+		Values(attempt.DeliveryId, attempt.StatusCode, attempt.Error, attempt.Response, attempt.Duration, attempt.CreatedAt).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(d.Manager.Db).
+		QueryRow().
+		Scan(&attempt.Id)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = sq.Update(d.deliveries()).
+		Set("status", delivery.Status).
+		Set("attempts", delivery.Attempts).
+		Set("next_attempt_at", delivery.NextAttemptAt).
+		Set("updated_at", delivery.UpdatedAt).
+		Where(sq.Eq{"id": delivery.Id}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(d.Manager.Db).
+		Exec()
+
+	return err
+}
+
+func (d *Dispatcher) post(webhook *Webhook, delivery *Delivery, attempt *Attempt) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequest("POST", webhook.Url, bytes.NewReader(body))
+
+	if err != nil {
+		attempt.Error = err.Error()
+
+		return
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gonode-webhook")
+	req.Header.Set(HEADER_SIGNATURE, Sign(webhook.Secret, body))
+	req.Header.Set(HEADER_EVENT, delivery.Action)
+	req.Header.Set(HEADER_DELIVERY, strconv.FormatInt(delivery.Id, 10))
+
+	start := time.Now()
+	res, err := d.HttpClient.Do(req)
+	attempt.Duration = time.Since(start).Milliseconds()
+
+	if err != nil {
+		attempt.Error = err.Error()
+
+		return
+	}
+
+	defer res.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(res.Body, responseLimit))
+
+	attempt.StatusCode = res.StatusCode
+	attempt.Response = string(response)
+}
+
+// Replay sends the delivery's payload again, a new delivery is created and enqueued so the
+// history of the replayed delivery is kept.
+func (d *Dispatcher) Replay(id int64) (*Delivery, error) {
+	delivery, err := d.FindDelivery(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	replay, err := d.create(delivery.Webhook, 0, delivery.Action, delivery.Subject, delivery.Payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.Jobs.Notify(DELIVERY_QUEUE)
+
+	return replay, nil
+}
+
+// FindDeliveries returns the webhook's deliveries, the most recent first
+func (d *Dispatcher) FindDeliveries(webhook base.Reference, offset, limit uint64) ([]*Delivery, error) {
+	return d.find(d.selectDeliveries().
+		Where(sq.Eq{"webhook": webhook.CleanString()}).
+		OrderBy("id DESC").
+		Offset(offset).
+		Limit(limit))
+}
+
+// FindDelivery returns the delivery with its attempts, base.ErrNotFound is returned if the
+// delivery does not exist
+func (d *Dispatcher) FindDelivery(id int64) (*Delivery, error) {
+	deliveries, err := d.find(d.selectDeliveries().Where(sq.Eq{"id": id}))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(deliveries) == 0 {
+		return nil, base.ErrNotFound
+	}
+
+	delivery := deliveries[0]
+
+	rows, err := d.Manager.Db.Query(fmt.Sprintf(`SELECT id, delivery_id, status_code, error, response, duration, created_at FROM %s WHERE delivery_id = $1 ORDER BY id ASC`, d.attempts()), id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	delivery.History = make([]*Attempt, 0)
+
+	for rows.Next() {
+		a := &Attempt{}
+
+		if err := rows.Scan(&a.Id, &a.DeliveryId, &a.StatusCode, &a.Error, &a.Response, &a.Duration, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		delivery.History = append(delivery.History, a)
+	}
+
+	return delivery, rows.Err()
+}
+
+func (d *Dispatcher) selectDeliveries() sq.SelectBuilder {
+	return sq.Select("id", "webhook", "action", "subject", "payload", "status", "attempts", "next_attempt_at", "created_at", "updated_at").
+		From(d.deliveries())
+}
+
+func (d *Dispatcher) find(query sq.SelectBuilder) ([]*Delivery, error) {
+	rows, err := query.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(d.Manager.Db).
+		Query()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deliveries := make([]*Delivery, 0)
+
+	for rows.Next() {
+		var webhook string
+		var next sql.NullTime
+
+		delivery := &Delivery{}
+
+		if err := rows.Scan(&delivery.Id, &webhook, &delivery.Action, &delivery.Subject, &delivery.Payload, &delivery.Status, &delivery.Attempts, &next, &delivery.CreatedAt, &delivery.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		if delivery.Webhook, err = base.GetReferenceFromString(webhook); err != nil {
+			return nil, err
+		}
+
+		if next.Valid {
+			delivery.NextAttemptAt = &next.Time
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
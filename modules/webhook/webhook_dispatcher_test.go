@@ -0,0 +1,334 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rande/gonode/core/config"
+	"github.com/rande/gonode/core/migration"
+	"github.com/rande/gonode/modules/base"
+	"github.com/stretchr/testify/assert"
+	_ "modernc.org/sqlite"
+)
+
+func getSqliteDispatcher(t *testing.T) (*Dispatcher, func()) {
+	db, err := sql.Open("sqlite", ":memory:")
+	assert.NoError(t, err)
+
+	// each connection opens a new memory database
+	db.SetMaxOpenConns(1)
+
+	migrator := migration.NewMigrator(db, "test", nil)
+	migrator.Driver = config.DATABASE_SQLITE
+
+	migrations := append(base.GetMigrations(), base.GetSqliteMigrations()...)
+	migrations = append(migrations, GetMigrations()...)
+	migrations = append(migrations, GetSqliteMigrations()...)
+
+	for _, m := range migrations {
+		assert.NoError(t, migrator.Add(m))
+	}
+
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	manager := &base.SqlNodeManager{
+		Handlers: base.HandlerCollection{
+			"core.webhook": &WebhookHandler{},
+		},
+		Db:      db,
+		Prefix:  "test",
+		Dialect: &base.SqliteDialect{},
+	}
+
+	d := &Dispatcher{
+		Manager:     manager,
+		Jobs:        base.NewJobQueue(manager, nil),
+		HttpClient:  &http.Client{Timeout: time.Second},
+		MaxAttempts: 3,
+		Backoff:     time.Minute,
+	}
+
+	d.Jobs.Options[DELIVERY_QUEUE] = &base.JobOptions{Workers: 1, MaxAttempts: 3, Backoff: time.Minute, Timeout: time.Minute}
+	d.Jobs.Handle(DELIVERY_QUEUE, d.HandleJob)
+
+	return d, func() {
+		assert.NoError(t, migrator.Reset())
+
+		db.Close()
+	}
+}
+
+// receiver records the requests and answers with the next status code
+type receiver struct {
+	sync.Mutex
+	codes    []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+
+	code := http.StatusOK
+	if len(r.codes) > 0 {
+		code, r.codes = r.codes[0], r.codes[1:]
+	}
+
+	res.WriteHeader(code)
+	res.Write([]byte("received"))
+}
+
+func newWebhook(t *testing.T, d *Dispatcher, url string, types []string) *base.Node {
+	node := d.Manager.Handlers.NewNode("core.webhook")
+	node.Name = "Search"
+	node.Data.(*Webhook).Url = url
+	node.Data.(*Webhook).Secret = "secret"
+	node.Data.(*Webhook).Types = types
+
+	_, err := d.Manager.Save(node, false)
+	assert.NoError(t, err)
+
+	return node
+}
+
+func event(id int64, e *base.ModelEvent) *base.Event {
+	payload, _ := json.Marshal(e)
+
+	return &base.Event{Id: id, Channel: "test_manager_action", Payload: string(payload)}
+}
+
+// due makes the pending delivery jobs due, so the next attempt is run without waiting for the backoff
+func due(t *testing.T, d *Dispatcher) {
+	_, err := d.Manager.Db.Exec(`UPDATE test_nodes_jobs SET run_at = $1 WHERE status = $2`, time.Now(), base.ProcessStatusReady)
+	assert.NoError(t, err)
+}
+
+func Test_Dispatcher_Deliver(t *testing.T) {
+	d, done := getSqliteDispatcher(t)
+	defer done()
+
+	r := &receiver{codes: []int{http.StatusInternalServerError}}
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	webhook := newWebhook(t, d, ts.URL+"/hook", []string{"blog.post"})
+
+	// the event does not match the webhook's types
+	err := d.HandleEvent(event(1, &base.ModelEvent{Type: "core.user", Action: "Create", Subject: "user"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(r.requests))
+
+	e := event(2, &base.ModelEvent{Type: "blog.post", Action: "Create", Subject: "post"})
+	err = d.HandleEvent(e)
+	assert.NoError(t, err)
+
+	// the delivery is posted by the job queue, not by the notification loop
+	assert.Equal(t, 0, len(r.requests))
+
+	found, err := d.Jobs.Run(DELIVERY_QUEUE)
+	assert.True(t, found)
+	assert.NoError(t, err)
+
+	// the first attempt fails
+	assert.Equal(t, 1, len(r.requests))
+	assert.Equal(t, e.Payload, string(r.bodies[0]))
+	assert.Equal(t, Sign("secret", r.bodies[0]), r.requests[0].Header.Get(HEADER_SIGNATURE))
+	assert.Equal(t, "Create", r.requests[0].Header.Get(HEADER_EVENT))
+	assert.Equal(t, "application/json", r.requests[0].Header.Get("Content-Type"))
+
+	deliveries, err := d.FindDeliveries(webhook.Uuid, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(deliveries))
+
+	delivery := deliveries[0]
+	assert.Equal(t, r.requests[0].Header.Get(HEADER_DELIVERY), "1")
+	assert.Equal(t, DELIVERY_PENDING, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, "post", delivery.Subject)
+	assert.NotNil(t, delivery.NextAttemptAt)
+
+	// the retry is not due
+	found, err = d.Jobs.Run(DELIVERY_QUEUE)
+	assert.False(t, found)
+	assert.NoError(t, err)
+
+	due(t, d)
+
+	found, err = d.Jobs.Run(DELIVERY_QUEUE)
+	assert.True(t, found)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(r.requests))
+
+	stats, err := d.Jobs.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats[DELIVERY_QUEUE].Done)
+
+	delivery, err = d.FindDelivery(delivery.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, DELIVERY_SUCCESS, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Nil(t, delivery.NextAttemptAt)
+
+	assert.Equal(t, 2, len(delivery.History))
+	assert.Equal(t, http.StatusInternalServerError, delivery.History[0].StatusCode)
+	assert.Equal(t, http.StatusOK, delivery.History[1].StatusCode)
+	assert.Equal(t, "received", delivery.History[1].Response)
+
+	_, err = d.FindDelivery(100)
+	assert.Equal(t, base.ErrNotFound, err)
+}
+
+func Test_Dispatcher_Backoff(t *testing.T) {
+	d, done := getSqliteDispatcher(t)
+	defer done()
+
+	r := &receiver{codes: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}}
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	newWebhook(t, d, ts.URL, nil)
+
+	deliveries, err := d.Dispatch(0, &base.ModelEvent{Type: "blog.post", Action: "Update"}, `{"action":"Update"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(deliveries))
+
+	delivery := deliveries[0]
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		due(t, d)
+
+		found, err := d.Jobs.Run(DELIVERY_QUEUE)
+		assert.True(t, found)
+		assert.NoError(t, err)
+
+		delivery, _ = d.FindDelivery(delivery.Id)
+		assert.Equal(t, attempt, delivery.Attempts)
+
+		if attempt < 3 {
+			assert.Equal(t, DELIVERY_PENDING, delivery.Status)
+			assert.Equal(t, delivery.UpdatedAt.Add(time.Minute<<uint(attempt-1)).Unix(), delivery.NextAttemptAt.Unix())
+		}
+	}
+
+	// the maximum number of attempts is reached, the job is done
+	assert.Equal(t, DELIVERY_FAILED, delivery.Status)
+	assert.Nil(t, delivery.NextAttemptAt)
+
+	stats, err := d.Jobs.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats[DELIVERY_QUEUE].Done)
+	assert.Equal(t, 0, stats[DELIVERY_QUEUE].Ready)
+}
+
+func Test_Dispatcher_Replay(t *testing.T) {
+	d, done := getSqliteDispatcher(t)
+	defer done()
+
+	r := &receiver{}
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	webhook := newWebhook(t, d, ts.URL, nil)
+
+	deliveries, err := d.Dispatch(0, &base.ModelEvent{Type: "blog.post", Action: "Update"}, `{"action":"Update"}`)
+	assert.NoError(t, err)
+	d.Jobs.Run(DELIVERY_QUEUE)
+
+	replay, err := d.Replay(deliveries[0].Id)
+	assert.NoError(t, err)
+	assert.NotEqual(t, deliveries[0].Id, replay.Id)
+	assert.Equal(t, DELIVERY_PENDING, replay.Status)
+
+	d.Jobs.Run(DELIVERY_QUEUE)
+
+	replay, _ = d.FindDelivery(replay.Id)
+	assert.Equal(t, DELIVERY_SUCCESS, replay.Status)
+	assert.Equal(t, 1, len(replay.History))
+	assert.Equal(t, 2, len(r.requests))
+	assert.Equal(t, r.bodies[0], r.bodies[1])
+
+	// the webhook is removed, the delivery fails without retry
+	_, err = d.Manager.RemoveOne(webhook)
+	assert.NoError(t, err)
+
+	replay, err = d.Replay(deliveries[0].Id)
+	assert.NoError(t, err)
+	d.Jobs.Run(DELIVERY_QUEUE)
+
+	replay, _ = d.FindDelivery(replay.Id)
+	assert.Equal(t, DELIVERY_FAILED, replay.Status)
+	assert.Equal(t, "the webhook is not available", replay.History[0].Error)
+	assert.Equal(t, 2, len(r.requests))
+}
+
+func Test_Dispatcher_Event_Handled_Twice(t *testing.T) {
+	d, done := getSqliteDispatcher(t)
+	defer done()
+
+	r := &receiver{}
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	first := newWebhook(t, d, ts.URL, nil)
+	second := newWebhook(t, d, ts.URL, nil)
+
+	// the delivery of the first webhook cannot be stored
+	_, err := d.Manager.Db.Exec(fmt.Sprintf(`CREATE TRIGGER test_fail BEFORE INSERT ON test_webhooks_deliveries WHEN NEW.webhook = '%s' BEGIN SELECT RAISE(ABORT, 'disk full'); END`, first.Uuid.CleanString()))
+	assert.NoError(t, err)
+
+	e := event(5, &base.ModelEvent{Type: "blog.post", Action: "Update"})
+
+	err = d.HandleEvent(e)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), first.Uuid.CleanString())
+
+	// the second webhook is served, the failed delivery does not leave a job
+	deliveries, _ := d.FindDeliveries(second.Uuid, 0, 10)
+	assert.Equal(t, 1, len(deliveries))
+
+	stats, err := d.Jobs.Stats()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stats[DELIVERY_QUEUE].Ready)
+
+	_, err = d.Manager.Db.Exec(`DROP TRIGGER test_fail`)
+	assert.NoError(t, err)
+
+	// the event is handled again, only the first webhook gets a new delivery
+	err = d.HandleEvent(e)
+	assert.NoError(t, err)
+
+	deliveries, _ = d.FindDeliveries(first.Uuid, 0, 10)
+	assert.Equal(t, 1, len(deliveries))
+
+	deliveries, _ = d.FindDeliveries(second.Uuid, 0, 10)
+	assert.Equal(t, 1, len(deliveries))
+
+	stats, _ = d.Jobs.Stats()
+	assert.Equal(t, 2, stats[DELIVERY_QUEUE].Ready)
+
+	for found := true; found; {
+		found, err = d.Jobs.Run(DELIVERY_QUEUE)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, len(r.requests))
+}
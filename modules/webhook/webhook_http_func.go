@@ -0,0 +1,172 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/core/security"
+	"github.com/rande/gonode/modules/api"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/modules/search"
+	"github.com/zenazn/goji/web"
+)
+
+// findWebhook returns the webhook node if the token is granted
+func findWebhook(apiHandler *api.Api, uuid string, options *base.AccessOptions) (*base.Node, error) {
+	node, err := apiHandler.FindOne(uuid, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if node.Type != "core.webhook" {
+		return nil, base.ErrNotFound
+	}
+
+	return node, nil
+}
+
+// findDelivery returns the delivery if the token is granted on its webhook
+func findDelivery(apiHandler *api.Api, dispatcher *Dispatcher, id string, options *base.AccessOptions) (*Delivery, error) {
+	deliveryId, err := strconv.ParseInt(id, 10, 64)
+
+	if err != nil {
+		return nil, base.ErrNotFound
+	}
+
+	delivery, err := dispatcher.FindDelivery(deliveryId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := findWebhook(apiHandler, delivery.Webhook.CleanString(), options); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func Api_GET_Webhook_Deliveries(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*api.Api)
+	dispatcher := app.Get("gonode.webhook.dispatcher").(*Dispatcher)
+	searchParser := app.Get("gonode.search.parser.http").(*search.HttpSearchParser)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		attrs := security.Attributes{"node:api:master", "node:api:webhook"}
+
+		if !api.Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		// only the pagination is used from the search form
+		searchForm := searchParser.HandleSearch(res, req)
+
+		if searchForm == nil {
+			return
+		}
+
+		node, err := findWebhook(apiHandler, c.URLParams["uuid"], base.NewAccessOptionsFromToken(token))
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		deliveries, err := dispatcher.FindDeliveries(node.Uuid, (searchForm.Page-1)*searchForm.PerPage, searchForm.PerPage+1)
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		pager := &api.ApiPager{
+			Elements: make([]interface{}, 0),
+			Page:     searchForm.Page,
+			PerPage:  searchForm.PerPage,
+		}
+
+		if searchForm.Page > 1 {
+			pager.Previous = searchForm.Page - 1
+		}
+
+		for k, delivery := range deliveries {
+			if uint64(k) == searchForm.PerPage {
+				pager.Next = searchForm.Page + 1
+
+				break
+			}
+
+			pager.Elements = append(pager.Elements, delivery)
+		}
+
+		base.Serialize(res, pager)
+	}
+}
+
+func Api_GET_Webhook_Delivery(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*api.Api)
+	dispatcher := app.Get("gonode.webhook.dispatcher").(*Dispatcher)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		attrs := security.Attributes{"node:api:master", "node:api:webhook"}
+
+		if !api.Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		if delivery, err := findDelivery(apiHandler, dispatcher, c.URLParams["id"], base.NewAccessOptionsFromToken(token)); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			base.Serialize(res, delivery)
+		}
+	}
+}
+
+func Api_POST_Webhook_Delivery_Replay(app *goapp.App) func(c web.C, res http.ResponseWriter, req *http.Request) {
+	apiHandler := app.Get("gonode.api").(*api.Api)
+	dispatcher := app.Get("gonode.webhook.dispatcher").(*Dispatcher)
+	authorizer := app.Get("security.authorizer").(security.AuthorizationChecker)
+
+	return func(c web.C, res http.ResponseWriter, req *http.Request) {
+		token := security.GetTokenFromContext(c)
+		attrs := security.Attributes{"node:api:master", "node:api:webhook"}
+
+		if !api.Check(c, res, req, attrs, authorizer) {
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+
+		delivery, err := findDelivery(apiHandler, dispatcher, c.URLParams["id"], base.NewAccessOptionsFromToken(token))
+
+		if err != nil {
+			base.HandleError(req, res, err)
+
+			return
+		}
+
+		if replay, err := dispatcher.Replay(delivery.Id); err != nil {
+			base.HandleError(req, res, err)
+		} else {
+			res.WriteHeader(http.StatusCreated)
+			base.Serialize(res, replay)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"github.com/rande/gonode/core/config"
+	"github.com/rande/gonode/core/migration"
+)
+
+func GetMigrations() []*migration.Migration {
+	return []*migration.Migration{
+		{
+			Module:  "webhook",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 1,
+			Name:    "create webhooks_deliveries and webhooks_attempts tables",
+			Up: `CREATE TABLE "{prefix}_webhooks_deliveries" (
+				"id" BIGSERIAL NOT NULL,
+				"webhook" UUid NOT NULL,
+				"action" CHARACTER VARYING( 64 ) NOT NULL,
+				"subject" CHARACTER VARYING( 64 ) NOT NULL,
+				"payload" TEXT NOT NULL,
+				"status" CHARACTER VARYING( 16 ) NOT NULL,
+				"attempts" INTEGER DEFAULT '0' NOT NULL,
+				"next_attempt_at" TIMESTAMP WITHOUT TIME ZONE,
+				"created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				"updated_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				PRIMARY KEY ( "id" )
+			);
+
+			CREATE INDEX "{prefix}_webhooks_deliveries_webhook_idx" ON "{prefix}_webhooks_deliveries" USING btree( "webhook", "id" );
+			CREATE INDEX "{prefix}_webhooks_deliveries_pending_idx" ON "{prefix}_webhooks_deliveries" USING btree( "status", "next_attempt_at" );
+
+			CREATE TABLE "{prefix}_webhooks_attempts" (
+				"id" BIGSERIAL NOT NULL,
+				"delivery_id" BIGINT NOT NULL,
+				"status_code" INTEGER DEFAULT '0' NOT NULL,
+				"error" TEXT DEFAULT '' NOT NULL,
+				"response" TEXT DEFAULT '' NOT NULL,
+				"duration" INTEGER DEFAULT '0' NOT NULL,
+				"created_at" TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+				PRIMARY KEY ( "id" )
+			);
+
+			CREATE INDEX "{prefix}_webhooks_attempts_delivery_idx" ON "{prefix}_webhooks_attempts" USING btree( "delivery_id" );`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_webhooks_attempts";
+			DROP TABLE IF EXISTS "{prefix}_webhooks_deliveries";`,
+		},
+		{
+			Module:  "webhook",
+			Driver:  config.DATABASE_POSTGRES,
+			Version: 2,
+			Name:    "add the event's id to the webhooks_deliveries table",
+			Up: `ALTER TABLE "{prefix}_webhooks_deliveries" ADD COLUMN "event_id" BIGINT NULL;
+
+			CREATE UNIQUE INDEX "{prefix}_webhooks_deliveries_event_idx" ON "{prefix}_webhooks_deliveries" USING btree( "webhook", "event_id" );`,
+			Down: `DROP INDEX IF EXISTS "{prefix}_webhooks_deliveries_event_idx";
+			ALTER TABLE IF EXISTS "{prefix}_webhooks_deliveries" DROP COLUMN IF EXISTS "event_id";`,
+		},
+	}
+}
+
+func GetSqliteMigrations() []*migration.Migration {
+	return []*migration.Migration{
+		{
+			Module:  "webhook",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 1,
+			Name:    "create webhooks_deliveries and webhooks_attempts tables",
+			Up: `CREATE TABLE "{prefix}_webhooks_deliveries" (
+				"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+				"webhook" CHARACTER VARYING( 36 ) NOT NULL,
+				"event_id" INTEGER NULL,
+				"action" CHARACTER VARYING( 64 ) NOT NULL,
+				"subject" CHARACTER VARYING( 64 ) NOT NULL,
+				"payload" TEXT NOT NULL,
+				"status" CHARACTER VARYING( 16 ) NOT NULL,
+				"attempts" INTEGER DEFAULT '0' NOT NULL,
+				"next_attempt_at" TIMESTAMP,
+				"created_at" TIMESTAMP NOT NULL,
+				"updated_at" TIMESTAMP NOT NULL
+			);
+
+			CREATE INDEX "{prefix}_webhooks_deliveries_webhook_idx" ON "{prefix}_webhooks_deliveries" ( "webhook", "id" );
+			CREATE INDEX "{prefix}_webhooks_deliveries_pending_idx" ON "{prefix}_webhooks_deliveries" ( "status", "next_attempt_at" );
+
+			CREATE TABLE "{prefix}_webhooks_attempts" (
+				"id" INTEGER PRIMARY KEY AUTOINCREMENT,
+				"delivery_id" INTEGER NOT NULL,
+				"status_code" INTEGER DEFAULT '0' NOT NULL,
+				"error" TEXT DEFAULT '' NOT NULL,
+				"response" TEXT DEFAULT '' NOT NULL,
+				"duration" INTEGER DEFAULT '0' NOT NULL,
+				"created_at" TIMESTAMP NOT NULL
+			);
+
+			CREATE INDEX "{prefix}_webhooks_attempts_delivery_idx" ON "{prefix}_webhooks_attempts" ( "delivery_id" );`,
+			Down: `DROP TABLE IF EXISTS "{prefix}_webhooks_attempts";
+			DROP TABLE IF EXISTS "{prefix}_webhooks_deliveries";`,
+		},
+		{
+			Module:  "webhook",
+			Driver:  config.DATABASE_SQLITE,
+			Version: 2,
+			Name:    "add the event's id to the webhooks_deliveries table",
+			Up:      `CREATE UNIQUE INDEX "{prefix}_webhooks_deliveries_event_idx" ON "{prefix}_webhooks_deliveries" ( "webhook", "event_id" );`,
+			Down:    `DROP INDEX IF EXISTS "{prefix}_webhooks_deliveries_event_idx";`,
+		},
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"testing"
+
+	"github.com/rande/gonode/modules/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Webhook_Matches(t *testing.T) {
+	w := &Webhook{}
+
+	assert.True(t, w.Matches(&base.ModelEvent{Type: "blog.post", Action: "Create"}))
+	assert.True(t, w.Matches(&base.ModelEvent{Action: "Lock"}))
+
+	w.Types = []string{"blog.post"}
+	w.Actions = []string{"Create", "Update"}
+
+	assert.True(t, w.Matches(&base.ModelEvent{Type: "blog.post", Action: "Update"}))
+	assert.False(t, w.Matches(&base.ModelEvent{Type: "blog.post", Action: "SoftDelete"}))
+	assert.False(t, w.Matches(&base.ModelEvent{Type: "core.user", Action: "Create"}))
+}
+
+func Test_Sign(t *testing.T) {
+	// echo -n '{"action":"Create"}' | openssl dgst -sha256 -hmac "secret"
+	assert.Equal(t, "sha256=8af50a8fb3fec69507cd94855dbc8bafea267ec65f2bb27aeeb20e9fcd1c30c6", Sign("secret", []byte(`{"action":"Create"}`)))
+}
+
+func Test_WebhookHandler_Validate(t *testing.T) {
+	c := base.HandlerCollection{"core.webhook": &WebhookHandler{}}
+
+	node := c.NewNode("core.webhook")
+
+	errors := base.NewErrors()
+	c.Get(node).(base.ValidateNodeHandler).Validate(node, nil, errors)
+
+	assert.Equal(t, 2, len(errors))
+	assert.True(t, errors.HasError("data.url"))
+	assert.True(t, errors.HasError("data.secret"))
+
+	node.Data.(*Webhook).Url = "ftp://localhost/hook"
+	node.Data.(*Webhook).Secret = "secret"
+
+	errors = base.NewErrors()
+	c.Get(node).(base.ValidateNodeHandler).Validate(node, nil, errors)
+	assert.True(t, errors.HasError("data.url"))
+
+	node.Data.(*Webhook).Url = "https://localhost/hook"
+
+	errors = base.NewErrors()
+	c.Get(node).(base.ValidateNodeHandler).Validate(node, nil, errors)
+	assert.Equal(t, 0, len(errors))
+}
@@ -66,7 +66,7 @@ func GetFakeUserNode(pos int) *base.Node {
 	return node
 }
 
-func LoadFixtures(m *base.PgNodeManager, max int) error {
+func LoadFixtures(m *base.SqlNodeManager, max int) error {
 
 	var err error
 
@@ -21,7 +21,7 @@ import (
 func Test_Access_FindOne_NoResult(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
 
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		// create dummy user
 		u := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.user")
@@ -58,7 +58,7 @@ func Test_Access_FindOne_NoResult(t *testing.T) {
 
 func Test_Access_FindOne_Result(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 		node := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("blog.post")
 		data := node.Data.(*blog.Post)
 		data.Title = "Blog Post 1"
@@ -76,7 +76,7 @@ func Test_Access_FindOne_Result(t *testing.T) {
 
 func Test_Access_RemoveOne_NoResult(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		// create dummy user
 		u := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.user")
@@ -111,7 +111,7 @@ func Test_Access_RemoveOne_NoResult(t *testing.T) {
 
 func Test_Access_RemoveOne_Result(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 		node := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("blog.post")
 		data := node.Data.(*blog.Post)
 		data.Title = "Blog Post 1"
@@ -129,7 +129,7 @@ func Test_Access_RemoveOne_Result(t *testing.T) {
 
 func Test_Access_Find_NoResult(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		// create dummy user
 		u := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.user")
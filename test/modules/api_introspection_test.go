@@ -6,10 +6,14 @@
 package modules
 
 import (
+	"encoding/json"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	. "github.com/rande/goapp"
+	"github.com/rande/gonode/modules/api"
+	"github.com/rande/gonode/modules/base"
 	"github.com/rande/gonode/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,6 +29,44 @@ func Test_API_GET_Handlers_Node(t *testing.T) {
 	})
 }
 
+func Test_API_GET_Handlers_Node_Schema(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *App) {
+		auth := test.GetDefaultAuthHeader(ts)
+
+		res, _ := test.RunRequest("GET", ts.URL+"/api/v1.0/handlers/node/blog.post/schema", nil, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, "application/schema+json", res.Header.Get("Content-Type"))
+
+		schema := map[string]interface{}{}
+		json.Unmarshal(res.GetBody(), &schema)
+
+		data := schema["properties"].(map[string]interface{})["data"].(map[string]interface{})
+		assert.Contains(t, data["properties"], "main_image")
+
+		res, _ = test.RunRequest("GET", ts.URL+"/api/v1.0/handlers/node/foo.bar/schema", nil, auth)
+
+		assert.Equal(t, 404, res.StatusCode)
+	})
+}
+
+func Test_API_POST_Invalid_Schema(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *App) {
+		auth := test.GetDefaultAuthHeader(ts)
+
+		body := strings.NewReader(`{"type": "blog.post", "name": "Post", "data": {"title": 12, "main_image": "foo"}, "access": ["node:api:master"]}`)
+		res, _ := test.RunRequest("POST", ts.URL+"/api/v1.0/nodes", body, auth)
+
+		assert.Equal(t, 412, res.StatusCode)
+
+		errors := base.Errors{}
+		json.Unmarshal(res.GetBody(), &errors)
+
+		assert.True(t, errors.HasError("data.title"))
+		assert.True(t, errors.HasError("data.main_image"))
+	})
+}
+
 func Test_API_GET_Handlers_View(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *App) {
 		auth := test.GetDefaultAuthHeader(ts)
@@ -44,5 +86,17 @@ func Test_API_GET_Services(t *testing.T) {
 
 		assert.Equal(t, 200, res.StatusCode)
 		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+		services := []*api.Service{}
+		json.Unmarshal(res.GetBody(), &services)
+
+		for _, s := range services {
+			if s.Name == "gonode.manager.cache" {
+				assert.Contains(t, s.Stats, "hits")
+				assert.Contains(t, s.Stats, "misses")
+			} else if s.Name == "gonode.manager" {
+				assert.Nil(t, s.Stats)
+			}
+		}
 	})
 }
@@ -0,0 +1,73 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/api"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type auditPager struct {
+	Elements []*api.AuditEntry `json:"elements"`
+	Next     uint64            `json:"next"`
+}
+
+func Test_API_GET_Audit(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		auth := test.GetDefaultAuthHeader(ts)
+
+		editor := manager.NewNode("default")
+		manager.Save(editor, false)
+
+		node := manager.NewNode("default")
+		node.Name = "Draft"
+		node.Access = []string{"node:api:master"}
+		manager.Save(node, false)
+
+		node.Name = "Final"
+		node.UpdatedBy = editor.Uuid
+		manager.Save(node, true)
+
+		manager.RemoveOne(node)
+
+		res, _ := test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/audit?updated_by=%s", ts.URL, editor.Uuid), nil, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+
+		pager := &auditPager{}
+		json.Unmarshal(res.GetBody(), pager)
+
+		assert.Equal(t, 2, len(pager.Elements))
+		assert.Equal(t, base.AUDIT_DELETE, pager.Elements[0].Action)
+		assert.Equal(t, []string{"deleted"}, pager.Elements[0].Fields)
+		assert.Equal(t, base.AUDIT_UPDATE, pager.Elements[1].Action)
+		assert.Equal(t, []string{"name"}, pager.Elements[1].Fields)
+		assert.Equal(t, node.Uuid, pager.Elements[1].Uuid)
+
+		res, _ = test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/audit?action=create&type=default&per_page=1", ts.URL), nil, auth)
+
+		pager = &auditPager{}
+		json.Unmarshal(res.GetBody(), pager)
+
+		assert.Equal(t, 1, len(pager.Elements))
+		assert.Equal(t, uint64(2), pager.Next)
+		assert.Equal(t, base.AUDIT_CREATE, pager.Elements[0].Action)
+		assert.Equal(t, 0, len(pager.Elements[0].Fields))
+
+		res, _ = test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/audit?from=yesterday", ts.URL), nil, auth)
+
+		assert.Equal(t, 400, res.StatusCode)
+	})
+}
@@ -0,0 +1,60 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_API_POST_Node_Copy(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		nodes := createTree(app)
+		auth := test.GetDefaultAuthHeader(ts)
+
+		// copy the subtree next to the original node
+		res, _ := test.RunRequest("POST", fmt.Sprintf("%s/api/v1.0/nodes/%s/copy?recursive", ts.URL, nodes["a"].Uuid), nil, auth)
+
+		assert.Equal(t, 201, res.StatusCode)
+
+		clone := test.GetNode(app, res)
+
+		assert.Equal(t, nodes["a"].Uuid, clone.Source)
+		assert.Equal(t, "a-1", clone.Slug)
+		assert.Equal(t, "/root/a-1", clone.Path)
+		assert.Equal(t, nodes["root"].Uuid, clone.ParentUuid)
+
+		query := manager.SelectBuilder(base.NewSelectOptions()).Where("parent_uuid = ?", clone.Uuid.CleanString())
+		leaf := manager.FindOneBy(query)
+
+		assert.Equal(t, nodes["leaf"].Uuid, leaf.Source)
+		assert.Equal(t, "/root/a-1/leaf", leaf.Path)
+
+		// copy the node only, under another parent
+		res, _ = test.RunRequest("POST", fmt.Sprintf("%s/api/v1.0/nodes/%s/copy?parent=%s", ts.URL, nodes["a"].Uuid, nodes["b"].Uuid), nil, auth)
+
+		assert.Equal(t, 201, res.StatusCode)
+
+		clone = test.GetNode(app, res)
+
+		assert.Equal(t, "/root/b/a", clone.Path)
+		assert.Nil(t, manager.FindOneBy(manager.SelectBuilder(base.NewSelectOptions()).Where("parent_uuid = ?", clone.Uuid.CleanString())))
+
+		// the tree user cannot read the target parent
+		auth = test.GetAuthHeaderFromCredentials("tree", "tree", ts)
+
+		res, _ = test.RunRequest("POST", fmt.Sprintf("%s/api/v1.0/nodes/%s/copy?parent=%s", ts.URL, nodes["a"].Uuid, nodes["hidden"].Uuid), nil, auth)
+
+		assert.Equal(t, 403, res.StatusCode)
+	})
+}
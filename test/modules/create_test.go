@@ -6,11 +6,13 @@
 package modules
 
 import (
+	"encoding/json"
 	"fmt"
 	"image"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/rande/goapp"
@@ -126,3 +128,27 @@ func Test_Media_Resize_With_Orientation(t *testing.T) {
 		})
 	}
 }
+
+func Test_Create_Node_Slug(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		auth := test.GetDefaultAuthHeader(ts)
+
+		for _, slug := range []string{"creme-brulee", "creme-brulee-1"} {
+			body := strings.NewReader(`{"type": "core.raw", "name": "Crème Brûlée", "access": ["node:api:master"]}`)
+			res, _ := test.RunRequest("POST", ts.URL+"/api/v1.0/nodes", body, auth)
+
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+			assert.Equal(t, slug, test.GetNode(app, res).Slug)
+		}
+
+		body := strings.NewReader(`{"type": "core.raw", "name": "Crème", "slug": "creme-brulee", "access": ["node:api:master"]}`)
+		res, _ := test.RunRequest("POST", ts.URL+"/api/v1.0/nodes", body, auth)
+
+		assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+
+		errors := base.NewErrors()
+		json.Unmarshal(res.GetBody(), &errors)
+
+		assert.Equal(t, []string{"The slug is already used by a sibling"}, errors.GetError("slug"))
+	})
+}
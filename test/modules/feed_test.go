@@ -28,7 +28,7 @@ type RssFeedXml struct {
 }
 
 func Setup_Feed_Data(app *goapp.App) *base.Node {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 	home := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.root")
 	home.Name = "Blog"
@@ -0,0 +1,86 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/modules/user"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_API_User_Field_Access(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		createUser := func(username string, roles []string) *base.Node {
+			u := manager.NewNode("core.user")
+			u.Name = username
+			u.Access = []string{"node:user:edit", "node:api:master"}
+
+			data := u.Data.(*user.User)
+			data.Email = username + "@example.org"
+			data.Enabled = true
+			data.NewPassword = username
+			data.Username = username
+			data.Roles = roles
+
+			u.Meta.(*user.UserMeta).PasswordCost = 1
+
+			manager.Save(u, false)
+
+			return u
+		}
+
+		createUser("editor", []string{"ROLE_API", "node:api:read", "node:api:update", "node:user:edit"})
+		target := createUser("target", []string{"ROLE_API"})
+		password := manager.Find(target.Uuid).Data.(*user.User).Password
+
+		editor := test.GetAuthHeaderFromCredentials("editor", "editor", ts)
+		url := fmt.Sprintf("%s/api/v1.0/nodes/%s", ts.URL, target.Uuid)
+
+		// the password and its settings are never serialized
+		res, _ := test.RunRequest("GET", url, nil, editor)
+		assert.Equal(t, 200, res.StatusCode)
+
+		document := make(map[string]interface{})
+		json.Unmarshal(res.GetBody(), &document)
+
+		data := document["data"].(map[string]interface{})
+		assert.Equal(t, "target@example.org", data["email"])
+		assert.NotContains(t, data, "password")
+		assert.Empty(t, document["meta"])
+
+		// the roles cannot be altered without the node:user:roles role, the password is kept
+		body := fmt.Sprintf(`{"uuid": "%s", "type": "core.user", "name": "target", "revision": %d, "access": ["node:user:edit", "node:api:master"], "data": {"firstname": "Target", "username": "target", "email": "target@example.org", "enabled": true, "roles": ["ROLE_API", "node:api:master"], "password": "{plain}hack"}}`, target.Uuid, target.Revision)
+
+		res, _ = test.RunRequest("PUT", url, strings.NewReader(body), editor)
+		assert.Equal(t, 201, res.StatusCode)
+
+		saved := manager.Find(target.Uuid)
+		assert.Equal(t, "Target", saved.Data.(*user.User).FirstName)
+		assert.Equal(t, []string{"ROLE_API"}, saved.Data.(*user.User).Roles)
+		assert.Equal(t, password, saved.Data.(*user.User).Password)
+		assert.Equal(t, 1, saved.Meta.(*user.UserMeta).PasswordCost)
+
+		// a master user can alter the roles
+		body = strings.Replace(body, fmt.Sprintf(`"revision": %d`, target.Revision), fmt.Sprintf(`"revision": %d`, saved.Revision), 1)
+
+		res, _ = test.RunRequest("PUT", url, strings.NewReader(body), test.GetDefaultAuthHeader(ts))
+		assert.Equal(t, 201, res.StatusCode)
+
+		saved = manager.Find(target.Uuid)
+		assert.Equal(t, []string{"ROLE_API", "node:api:master"}, saved.Data.(*user.User).Roles)
+		assert.Equal(t, password, saved.Data.(*user.User).Password)
+	})
+}
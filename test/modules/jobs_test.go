@@ -0,0 +1,75 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/api"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_API_Jobs(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		jobs := app.Get("gonode.jobs").(*base.JobQueue)
+		jobs.Enqueue("test.ready", "ready")
+
+		// the first failure dead-letters the job
+		jobs.Options["test.dead"] = base.DefaultJobOptions()
+		jobs.Options["test.dead"].MaxAttempts = 1
+
+		dead, _ := jobs.Enqueue("test.dead", "dead")
+		job, _ := jobs.Claim("test.dead")
+		jobs.Fail(job, errors.New("unavailable"))
+
+		auth := test.GetDefaultAuthHeader(ts)
+
+		res, _ := test.RunRequest("GET", ts.URL+"/api/v1.0/jobs?status=dead", nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		pager := &api.ApiPager{}
+		json.Unmarshal(res.GetBody(), pager)
+		assert.Equal(t, 1, len(pager.Elements))
+		assert.Equal(t, "unavailable", pager.Elements[0].(map[string]interface{})["error"])
+
+		res, _ = test.RunRequest("GET", ts.URL+"/api/v1.0/jobs?status=unknown", nil, auth)
+		assert.Equal(t, 400, res.StatusCode)
+
+		res, _ = test.RunRequest("GET", ts.URL+"/api/v1.0/jobs/stats", nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		stats := map[string]*base.JobStats{}
+		json.Unmarshal(res.GetBody(), &stats)
+		assert.Equal(t, 1, stats["test.ready"].Ready)
+		assert.Equal(t, 1, stats["test.dead"].Dead)
+
+		url := fmt.Sprintf("%s/api/v1.0/jobs/%d", ts.URL, dead.Id)
+
+		res, _ = test.RunRequest("GET", url, nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		res, _ = test.RunRequest("PUT", url+"/retry", nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		job = &base.Job{}
+		json.Unmarshal(res.GetBody(), job)
+		assert.Equal(t, base.ProcessStatusReady, job.Status)
+
+		// the job is not dead-lettered anymore
+		res, _ = test.RunRequest("PUT", url+"/retry", nil, auth)
+		assert.Equal(t, 404, res.StatusCode)
+
+		res, _ = test.RunRequest("GET", ts.URL+"/api/v1.0/jobs/1000", nil, auth)
+		assert.Equal(t, 404, res.StatusCode)
+	})
+}
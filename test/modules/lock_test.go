@@ -0,0 +1,138 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/api"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/modules/user"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func createEditor(app *goapp.App, username string, roles ...string) {
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+	u := manager.NewNode("core.user")
+	u.Name = username
+
+	data := u.Data.(*user.User)
+	data.Email = username + "@example.org"
+	data.Enabled = true
+	data.NewPassword = username
+	data.Username = username
+	data.Roles = append([]string{"ROLE_API", "node:api:lock", "node:api:update", "node:lock:edit"}, roles...)
+
+	u.Meta.(*user.UserMeta).PasswordCost = 1
+
+	manager.Save(u, false)
+}
+
+func Test_API_Node_Lock(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		node := manager.NewNode("core.raw")
+		node.Name = "Humans.txt"
+		node.Slug = "humans.txt"
+		node.Access = []string{"node:lock:edit"}
+		manager.Save(node, false)
+
+		createEditor(app, "alice")
+		createEditor(app, "bob", "node:api:move")
+
+		alice := test.GetAuthHeaderFromCredentials("alice", "alice", ts)
+		bob := test.GetAuthHeaderFromCredentials("bob", "bob", ts)
+		master := test.GetDefaultAuthHeader(ts)
+
+		url := fmt.Sprintf("%s/api/v1.0/nodes/%s/lock", ts.URL, node.Uuid)
+
+		update := func(revision int) string {
+			return fmt.Sprintf(`{"uuid": "%s", "type": "core.raw", "name": "Humans.txt", "slug": "humans.txt", "revision": %d, "access": ["node:lock:edit"]}`, node.Uuid, revision)
+		}
+
+		res, _ := test.RunRequest("GET", url, nil, alice)
+		assert.Equal(t, 404, res.StatusCode)
+
+		res, _ = test.RunRequest("PUT", url+"?ttl=-1m", nil, alice)
+		assert.Equal(t, 400, res.StatusCode)
+
+		res, _ = test.RunRequest("PUT", url+"?ttl=10m", nil, alice)
+		assert.Equal(t, 200, res.StatusCode)
+
+		lock := &base.Lock{}
+		json.Unmarshal(res.GetBody(), lock)
+		assert.Equal(t, "alice", lock.Owner)
+
+		// another editor can see the lock, but cannot take it or save the node
+		res, _ = test.RunRequest("GET", url, nil, bob)
+		assert.Equal(t, 200, res.StatusCode)
+
+		lock = &base.Lock{}
+		json.Unmarshal(res.GetBody(), lock)
+		assert.Equal(t, "alice", lock.Owner)
+
+		res, _ = test.RunRequest("PUT", url, nil, bob)
+		assert.Equal(t, 423, res.StatusCode)
+
+		res, _ = test.RunRequest("PUT", ts.URL+"/api/v1.0/nodes/"+node.Uuid.CleanString(), strings.NewReader(update(1)), bob)
+		assert.Equal(t, 423, res.StatusCode)
+
+		res, _ = test.RunRequest("DELETE", url, nil, bob)
+		assert.Equal(t, 423, res.StatusCode)
+
+		// the binary, the parent and the position cannot be changed either
+		res, _ = test.RunRequest("PUT", ts.URL+"/api/v1.0/nodes/"+node.Uuid.CleanString()+"?raw", strings.NewReader("Bob"), bob)
+		assert.Equal(t, 423, res.StatusCode)
+
+		parent := manager.NewNode("core.raw")
+		parent.Name = "Docs"
+		parent.Access = []string{"node:lock:edit"}
+		manager.Save(parent, false)
+
+		res, _ = test.RunRequest("PUT", fmt.Sprintf("%s/api/v1.0/nodes/move/%s/%s", ts.URL, node.Uuid, parent.Uuid), nil, bob)
+		assert.Equal(t, 423, res.StatusCode)
+
+		manager.Move(node.Uuid, parent.Uuid)
+
+		order := fmt.Sprintf("%s/api/v1.0/nodes/%s/children/order", ts.URL, parent.Uuid)
+		children := fmt.Sprintf(`["%s"]`, node.Uuid)
+
+		res, _ = test.RunRequest("PUT", order+"?revision", strings.NewReader(children), bob)
+		assert.Equal(t, 423, res.StatusCode)
+
+		res, _ = test.RunRequest("PUT", order, strings.NewReader(children), bob)
+		assert.Equal(t, 200, res.StatusCode)
+
+		// the owner's save releases the lock
+		res, _ = test.RunRequest("PUT", ts.URL+"/api/v1.0/nodes/"+node.Uuid.CleanString(), strings.NewReader(update(1)), alice)
+		assert.Equal(t, 201, res.StatusCode)
+
+		res, _ = test.RunRequest("GET", url, nil, alice)
+		assert.Equal(t, 404, res.StatusCode)
+
+		// a master user can release the lock of another user
+		res, _ = test.RunRequest("PUT", url, nil, bob)
+		assert.Equal(t, 200, res.StatusCode)
+
+		res, _ = test.RunRequest("DELETE", url, nil, master)
+		assert.Equal(t, 200, res.StatusCode)
+
+		op := &api.ApiOperation{}
+		json.Unmarshal(res.GetBody(), op)
+		assert.Equal(t, "Node unlocked: true", op.Message)
+
+		res, _ = test.RunRequest("GET", url, nil, bob)
+		assert.Equal(t, 404, res.StatusCode)
+	})
+}
@@ -30,7 +30,7 @@ func Test_Create_Username(t *testing.T) {
 
 		// WITH
 		// create a valid user into the database ...
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		u := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.user")
 		data := u.Data.(*user.User)
@@ -18,7 +18,7 @@ import (
 
 func Test_Valid_UpdatedAt(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		node := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("blog.post")
 		data := node.Data.(*blog.Post)
@@ -36,7 +36,7 @@ func Test_Valid_UpdatedAt(t *testing.T) {
 
 func Test_New_Revision(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		node := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("blog.post")
 		data := node.Data.(*blog.Post)
@@ -34,7 +34,7 @@ func Test_Pagination(t *testing.T) {
 
 		// WITH
 		// create a valid user into the database ...
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		for i := 0; i < 11; i++ {
 			u := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.user")
@@ -21,7 +21,7 @@ func Test_Create_Parents_With_Manager(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
 		// WITH
 		handlers := app.Get("gonode.handler_collection").(base.HandlerCollection)
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		node1 := handlers.NewNode("default")
 		node1.Slug = "the"
@@ -76,7 +76,7 @@ func Test_Create_Parents_With_Api(t *testing.T) {
 		auth := test.GetDefaultAuthHeader(ts)
 
 		handlers := app.Get("gonode.handler_collection").(base.HandlerCollection)
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		node1 := handlers.NewNode("default")
 		node1.Access = []string{"node:api:master"}
@@ -6,6 +6,8 @@
 package modules
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -14,6 +16,7 @@ import (
 	"github.com/rande/goapp"
 	"github.com/rande/gonode/modules/base"
 	"github.com/rande/gonode/modules/blog"
+	"github.com/rande/gonode/modules/raw"
 	"github.com/rande/gonode/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -21,7 +24,7 @@ import (
 func Test_Prism_Blog_Archive(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
 		// GIVEN
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		node := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("blog.post")
 		data := node.Data.(*blog.Post)
@@ -48,7 +51,7 @@ func Test_Prism_Bad_Request(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
 		// WITH
 		// create a valid user into the database ...
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		node := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("blog.post")
 		data := node.Data.(*blog.Post)
@@ -65,7 +68,7 @@ func Test_Prism_Bad_Request(t *testing.T) {
 
 func Test_Prism_Format(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		home := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.root")
 		home.Name = "Homepage"
@@ -101,7 +104,7 @@ func Test_Prism_Format(t *testing.T) {
 
 func Test_Prism_Forbidden(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		home := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.root")
 		home.Name = "Homepage"
@@ -122,3 +125,138 @@ func Test_Prism_Forbidden(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, res.StatusCode)
 	})
 }
+
+func Test_Prism_Cache(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		cache := app.Get("gonode.manager.cache").(*base.NodeCache)
+
+		node := manager.NewNode("core.raw")
+		node.Name = "Humans.txt"
+		node.Slug = "humans.txt"
+		node.Path = "/humans.txt"
+		node.Data.(*raw.Raw).Content = []byte("Thomas")
+		node.Access = []string{"node:prism:render", "IS_AUTHENTICATED_ANONYMOUSLY"}
+
+		manager.Save(node, false)
+
+		for i := 0; i < 2; i++ {
+			res, _ := test.RunRequest("GET", ts.URL+"/humans.txt")
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "Thomas", string(res.GetBody()))
+		}
+
+		stats := cache.Stats().(*base.NodeCacheStats)
+		assert.Equal(t, uint64(1), stats.Hits)
+		assert.Equal(t, 1, stats.Size)
+
+		// the update invalidates the cached node
+		node.Data.(*raw.Raw).Content = []byte("Thomas Rabaix")
+		manager.Save(node, false)
+
+		res, _ := test.RunRequest("GET", ts.URL+"/humans.txt")
+		assert.Equal(t, "Thomas Rabaix", string(res.GetBody()))
+	})
+}
+
+func Test_Prism_Redirect(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		home := manager.NewNode("core.root")
+		home.Name = "Homepage"
+		home.Access = []string{"node:prism:render", "IS_AUTHENTICATED_ANONYMOUSLY"}
+
+		manager.Save(home, false)
+
+		nodes := make(map[string]*base.Node)
+		for _, slug := range []string{"docs", "humans.txt"} {
+			node := manager.NewNode("core.raw")
+			node.Name = slug
+			node.Slug = slug
+			node.Data.(*raw.Raw).Content = []byte("Thomas")
+			node.Access = []string{"node:prism:render", "IS_AUTHENTICATED_ANONYMOUSLY"}
+
+			manager.Save(node, false)
+			manager.Move(node.Uuid, home.Uuid)
+
+			nodes[slug] = node
+		}
+
+		manager.Move(nodes["humans.txt"].Uuid, nodes["docs"].Uuid)
+
+		client := &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+
+		res, _ := client.Get(ts.URL + "/humans.txt?page=1")
+
+		assert.Equal(t, http.StatusMovedPermanently, res.StatusCode)
+		assert.Equal(t, "/docs/humans.txt?page=1", res.Header.Get("Location"))
+
+		r, _ := test.RunRequest("GET", ts.URL+"/humans.txt")
+
+		assert.Equal(t, http.StatusOK, r.StatusCode)
+		assert.Equal(t, "Thomas", string(r.GetBody()))
+
+		res, _ = client.Get(ts.URL + "/missing")
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
+
+func Test_Prism_Redirect_Slug(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		home := manager.NewNode("core.root")
+		home.Name = "Homepage"
+		home.Access = []string{"node:prism:render", "IS_AUTHENTICATED_ANONYMOUSLY"}
+
+		manager.Save(home, false)
+
+		node := manager.NewNode("core.raw")
+		node.Name = "docs"
+		node.Slug = "docs"
+		node.Data.(*raw.Raw).Content = []byte("Thomas")
+		node.Access = []string{"node:prism:render", "IS_AUTHENTICATED_ANONYMOUSLY", "node:api:master"}
+
+		manager.Save(node, false)
+		manager.Move(node.Uuid, home.Uuid)
+
+		auth := test.GetDefaultAuthHeader(ts)
+		url := fmt.Sprintf("%s/api/v1.0/nodes/%s", ts.URL, node.Uuid)
+
+		// the slug is changed with the API
+		res, _ := test.RunRequest("GET", url, nil, auth)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		document := make(map[string]interface{})
+		json.Unmarshal(res.GetBody(), &document)
+		document["slug"] = "guides"
+
+		body, _ := json.Marshal(document)
+
+		res, _ = test.RunRequest("PUT", url, bytes.NewReader(body), auth)
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "/guides", manager.Find(node.Uuid).Path)
+
+		client := &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+
+		r, _ := client.Get(ts.URL + "/docs")
+
+		assert.Equal(t, http.StatusMovedPermanently, r.StatusCode)
+		assert.Equal(t, "/guides", r.Header.Get("Location"))
+
+		res, _ = test.RunRequest("GET", ts.URL+"/guides")
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "Thomas", string(res.GetBody()))
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Publication_Prism(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		publisher := app.Get("gonode.publisher").(*base.Publisher)
+
+		publishAt := time.Now().Add(time.Hour)
+
+		raw := manager.NewNode("core.raw")
+		raw.Name = "Humans.txt"
+		raw.Enabled = false
+		raw.PublishAt = &publishAt
+		raw.Access = []string{"node:prism:render", "IS_AUTHENTICATED_ANONYMOUSLY"}
+
+		manager.Save(raw, false)
+
+		res, _ := test.RunRequest("GET", fmt.Sprintf("%s/prism/%s", ts.URL, raw.Uuid))
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		count, err := publisher.Run(time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		count, err = publisher.Run(publishAt.Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		raw = manager.Find(raw.Uuid)
+		assert.True(t, raw.Enabled)
+		assert.Nil(t, raw.PublishAt)
+		assert.Equal(t, base.StatusNew, raw.Status)
+		assert.Equal(t, 2, raw.Revision)
+
+		res, _ = test.RunRequest("GET", fmt.Sprintf("%s/prism/%s", ts.URL, raw.Uuid))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+}
+
+func Test_Publication_Unpublish(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		publisher := app.Get("gonode.publisher").(*base.Publisher)
+
+		unpublishAt := time.Now().Add(-time.Minute)
+
+		raw := manager.NewNode("core.raw")
+		raw.Name = "Humans.txt"
+		raw.UnpublishAt = &unpublishAt
+
+		manager.Save(raw, false)
+
+		count, err := publisher.Run(time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		raw = manager.Find(raw.Uuid)
+		assert.False(t, raw.Enabled)
+		assert.Nil(t, raw.UnpublishAt)
+	})
+}
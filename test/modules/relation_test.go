@@ -0,0 +1,147 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/modules/blog"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Relations_References(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		auth := test.GetDefaultAuthHeader(ts)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		image := manager.NewNode("media.image")
+		image.Name = "Image"
+		manager.Save(image, false)
+
+		post := manager.NewNode("blog.post")
+		post.Name = "Post"
+		post.Data.(*blog.Post).MainImage = image.Uuid
+		manager.Save(post, false)
+
+		res, _ := test.RunRequest("GET", ts.URL+"/api/v1.0/nodes/"+post.Uuid.CleanString()+"/references", nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		p := test.GetPager(app, res)
+		assert.Equal(t, 1, len(p.Elements))
+		assert.Equal(t, image.Uuid, p.Elements[0].(*base.Node).Uuid)
+
+		res, _ = test.RunRequest("GET", ts.URL+"/api/v1.0/nodes/"+image.Uuid.CleanString()+"/referenced-by?field=MainImage", nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		p = test.GetPager(app, res)
+		assert.Equal(t, 1, len(p.Elements))
+		assert.Equal(t, post.Uuid, p.Elements[0].(*base.Node).Uuid)
+
+		// the relation is removed when the reference is updated
+		post.Data.(*blog.Post).MainImage = base.GetEmptyReference()
+		manager.Save(post, false)
+
+		res, _ = test.RunRequest("GET", ts.URL+"/api/v1.0/nodes/"+image.Uuid.CleanString()+"/referenced-by", nil, auth)
+		assert.Equal(t, 0, len(test.GetPager(app, res).Elements))
+	})
+}
+
+func Test_Relations_Integrity(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		auth := test.GetDefaultAuthHeader(ts)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		manager.Integrity = true
+		defer func() {
+			manager.Integrity = false
+		}()
+
+		image := manager.NewNode("media.image")
+		image.Name = "Image"
+		manager.Save(image, false)
+
+		post := manager.NewNode("blog.post")
+		post.Name = "Post"
+		post.Data.(*blog.Post).MainImage = image.Uuid
+		manager.Save(post, false)
+
+		res, _ := test.RunRequest("DELETE", ts.URL+"/api/v1.0/nodes/"+image.Uuid.CleanString(), nil, auth)
+		assert.Equal(t, 409, res.StatusCode)
+		assert.False(t, manager.Find(image.Uuid).Deleted)
+
+		// a deleted node does not hold its references
+		manager.RemoveOne(post)
+
+		res, _ = test.RunRequest("DELETE", ts.URL+"/api/v1.0/nodes/"+image.Uuid.CleanString(), nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+	})
+}
+
+func Test_Relations_Integrity_Bulk_Remove(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		manager.Integrity = true
+		defer func() {
+			manager.Integrity = false
+		}()
+
+		image := manager.NewNode("media.image")
+		image.Name = "Image"
+		manager.Save(image, false)
+
+		post := manager.NewNode("blog.post")
+		post.Name = "Post"
+		post.Data.(*blog.Post).MainImage = image.Uuid
+		manager.Save(post, false)
+
+		// the post outside the removed set references the image: nothing is removed
+		query := manager.SelectBuilder(base.NewSelectOptions()).Where("type = ?", "media.image")
+		assert.Equal(t, base.ErrReferenced, manager.Remove(query))
+		assert.False(t, manager.Find(image.Uuid).Deleted)
+
+		// the references inside the removed set are ignored
+		query = manager.SelectBuilder(base.NewSelectOptions()).Where("type IN (?, ?)", "media.image", "blog.post")
+		assert.NoError(t, manager.Remove(query))
+		assert.True(t, manager.Find(image.Uuid).Deleted)
+		assert.True(t, manager.Find(post.Uuid).Deleted)
+	})
+}
+
+func Test_Relations_Rebuild(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		auth := test.GetDefaultAuthHeader(ts)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		image := manager.NewNode("media.image")
+		image.Name = "Image"
+		manager.Save(image, false)
+
+		post := manager.NewNode("blog.post")
+		post.Name = "Post"
+		post.Data.(*blog.Post).MainImage = image.Uuid
+		manager.Save(post, false)
+
+		// the nodes created before the relations table existed
+		_, err := manager.Db.Exec(`DELETE FROM "` + manager.Prefix + `_nodes_relations"`)
+		assert.NoError(t, err)
+
+		count, err := manager.RebuildRelations()
+		assert.NoError(t, err)
+		assert.True(t, count >= 2)
+
+		res, _ := test.RunRequest("GET", ts.URL+"/api/v1.0/nodes/"+image.Uuid.CleanString()+"/referenced-by", nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		p := test.GetPager(app, res)
+		assert.Equal(t, 1, len(p.Elements))
+		assert.Equal(t, post.Uuid, p.Elements[0].(*base.Node).Uuid)
+	})
+}
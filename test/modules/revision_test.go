@@ -0,0 +1,109 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/api"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/modules/blog"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRevisions(app *goapp.App) *base.Node {
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+	node := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("blog.post")
+	node.Name = "Title 1"
+	node.Data.(*blog.Post).Title = "Title 1"
+
+	manager.Save(node, true)
+
+	node.Name = "Title 2"
+	node.Data.(*blog.Post).Title = "Title 2"
+
+	manager.Save(node, true)
+
+	return node
+}
+
+func Test_Revision_Diff(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		auth := test.GetDefaultAuthHeader(ts)
+		node := createRevisions(app)
+
+		res, _ := test.RunRequest("GET", ts.URL+"/api/v1.0/nodes/"+node.Uuid.CleanString()+"/revisions/1/diff/2", nil, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+
+		diff := &api.NodeDiff{}
+		json.Unmarshal(res.GetBody(), diff)
+
+		assert.Equal(t, 1, diff.From)
+		assert.Equal(t, 2, diff.To)
+		assert.Contains(t, diff.Summary, `data.title changed from "Title 1" to "Title 2"`)
+		assert.Contains(t, diff.Summary, `name changed from "Title 1" to "Title 2"`)
+
+		res, _ = test.RunRequest("GET", ts.URL+"/api/v1.0/nodes/"+node.Uuid.CleanString()+"/revisions/1/diff/42", nil, auth)
+
+		assert.Equal(t, 404, res.StatusCode)
+	})
+}
+
+func Test_Revision_Restore(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		auth := test.GetDefaultAuthHeader(ts)
+		node := createRevisions(app)
+
+		res, _ := test.RunRequest("PUT", ts.URL+"/api/v1.0/nodes/"+node.Uuid.CleanString()+"/revisions/1/restore", nil, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+
+		restored := test.GetNode(app, res)
+
+		assert.Equal(t, 3, restored.Revision)
+		assert.Equal(t, "Title 1", restored.Name)
+		assert.Equal(t, "Title 1", restored.Data.(*blog.Post).Title)
+		assert.Equal(t, float64(1), restored.Modules["restored_from"])
+	})
+}
+
+func Test_Revision_Restore_Keeps_Tree_Position(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		auth := test.GetDefaultAuthHeader(ts)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		node := createRevisions(app)
+
+		parent := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("blog.post")
+		parent.Name = "Blog"
+		manager.Save(parent, false)
+
+		// the slug is changed after the first revision, then the node is moved
+		node.Slug = "renamed"
+		manager.Save(node, true)
+		manager.Move(node.Uuid, parent.Uuid)
+
+		current := manager.Find(node.Uuid)
+		assert.Equal(t, "/blog/renamed", current.Path)
+
+		res, _ := test.RunRequest("PUT", ts.URL+"/api/v1.0/nodes/"+node.Uuid.CleanString()+"/revisions/1/restore", nil, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+
+		restored := manager.Find(node.Uuid)
+
+		assert.Equal(t, "Title 1", restored.Name)
+		assert.Equal(t, "renamed", restored.Slug)
+		assert.Equal(t, "/blog/renamed", restored.Path)
+		assert.Equal(t, parent.Uuid, restored.ParentUuid)
+		assert.Equal(t, current.Parents, restored.Parents)
+	})
+}
@@ -26,7 +26,7 @@ func Test_Search_Revision_Basic(t *testing.T) {
 	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
 
 		u := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.user")
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		data := u.Data.(*user.User)
 		data.Email = "test@example.org"
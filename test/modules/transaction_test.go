@@ -0,0 +1,141 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/modules/blog"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Transaction_Commit(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		collection := app.Get("gonode.handler_collection").(base.HandlerCollection)
+
+		parent := collection.NewNode("blog.post")
+		parent.Name = "Parent"
+		parent.Data.(*blog.Post).Title = "Parent"
+
+		child := collection.NewNode("blog.post")
+		child.Name = "Child"
+		child.Data.(*blog.Post).Title = "Child"
+
+		err := manager.Transaction(func(tx base.NodeManager) error {
+			if _, err := tx.Save(parent, false); err != nil {
+				return err
+			}
+
+			if _, err := tx.Save(child, false); err != nil {
+				return err
+			}
+
+			_, err := tx.Move(child.Uuid, parent.Uuid)
+
+			return err
+		})
+
+		assert.NoError(t, err)
+
+		saved := manager.Find(child.Uuid)
+
+		assert.NotNil(t, saved)
+		assert.Equal(t, parent.Uuid, saved.ParentUuid)
+	})
+}
+
+func Test_Transaction_Rollback(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		collection := app.Get("gonode.handler_collection").(base.HandlerCollection)
+
+		node := collection.NewNode("blog.post")
+		node.Name = "Rollback"
+		node.Data.(*blog.Post).Title = "Rollback"
+
+		errStop := errors.New("stop the import")
+
+		err := manager.Transaction(func(tx base.NodeManager) error {
+			if _, err := tx.Save(node, false); err != nil {
+				return err
+			}
+
+			return errStop
+		})
+
+		assert.Equal(t, errStop, err)
+		assert.Nil(t, manager.Find(node.Uuid))
+	})
+}
+
+func Test_Transaction_Events_Commit_Order(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		outbox := app.Get("gonode.outbox").(*base.Outbox)
+
+		cursor, err := outbox.Last()
+		assert.NoError(t, err)
+
+		// the consumer reads the events after its cursor, like the subscriber does
+		received := make([]string, 0)
+		consume := func() {
+			events, err := outbox.Events([]string{"test_order"}, cursor, 10)
+			assert.NoError(t, err)
+
+			for _, e := range events {
+				received = append(received, e.Payload)
+				cursor = e.Id
+			}
+		}
+
+		notified := make(chan bool)
+		first := make(chan error)
+		second := make(chan bool)
+
+		// the first transaction notifies first, and commits once the second one is committed
+		go func() {
+			first <- manager.Transaction(func(tx base.NodeManager) error {
+				tx.Notify("test_order", "first")
+				close(notified)
+
+				select {
+				case <-second:
+				case <-time.After(500 * time.Millisecond): // the database serializes the transactions
+				}
+
+				return nil
+			})
+		}()
+
+		<-notified
+
+		go func() {
+			err := manager.Transaction(func(tx base.NodeManager) error {
+				tx.Notify("test_order", "second")
+
+				return nil
+			})
+
+			assert.NoError(t, err)
+			close(second)
+		}()
+
+		<-second
+		consume()
+
+		assert.NoError(t, <-first)
+		consume()
+
+		assert.ElementsMatch(t, []string{"first", "second"}, received)
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Transfer_Export_Import(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		exporter := app.Get("gonode.exporter").(*base.Exporter)
+		importer := app.Get("gonode.importer").(*base.Importer)
+
+		parent := manager.NewNode("core.index")
+		parent.Name = "Blog"
+		manager.Save(parent, false)
+
+		child := manager.NewNode("core.raw")
+		child.Name = "Humans.txt"
+		child.ParentUuid = parent.Uuid
+		manager.Save(child, false)
+
+		child = manager.Find(child.Uuid)
+		child.Name = "Robots.txt"
+		manager.Save(child, true)
+
+		child = manager.Find(child.Uuid)
+
+		buffer := bytes.NewBuffer([]byte(""))
+
+		count, err := exporter.Export(buffer, &base.ExportOptions{
+			Types:     []string{"core.index", "core.raw"},
+			Revisions: true,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, 2, strings.Count(buffer.String(), "\n"))
+
+		export := buffer.String()
+
+		// the nodes exist, nothing is imported
+		result, err := importer.Import(strings.NewReader(export), &base.ImportOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, &base.ImportResult{Skipped: 2}, result)
+
+		for _, table := range []string{"nodes", "nodes_audit"} {
+			_, err = manager.Db.Exec(fmt.Sprintf(`DELETE FROM "%s_%s"`, manager.Prefix, table))
+			assert.NoError(t, err)
+		}
+
+		result, err = importer.Import(strings.NewReader(export), &base.ImportOptions{DryRun: true})
+		assert.NoError(t, err)
+		assert.Equal(t, &base.ImportResult{Created: 2}, result)
+		assert.Nil(t, manager.Find(child.Uuid))
+
+		result, err = importer.Import(strings.NewReader(export), &base.ImportOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, &base.ImportResult{Created: 2}, result)
+
+		imported := manager.Find(child.Uuid)
+
+		assert.NotNil(t, imported)
+		assert.Equal(t, "Robots.txt", imported.Name)
+		assert.Equal(t, 2, imported.Revision)
+		assert.Equal(t, parent.Uuid, imported.ParentUuid)
+		assert.Equal(t, child.Parents, imported.Parents)
+		assert.Equal(t, child.Path, imported.Path)
+
+		options := base.NewSelectOptions()
+		options.TableSuffix = "nodes_audit"
+
+		revisions := manager.FindBy(manager.SelectBuilder(options).Where(sq.Eq{"uuid": child.Uuid.CleanString()}), 0, 10)
+		assert.Equal(t, 2, revisions.Len())
+
+		result, err = importer.Import(strings.NewReader(export), &base.ImportOptions{Upsert: true})
+		assert.NoError(t, err)
+		assert.Equal(t, &base.ImportResult{Updated: 2}, result)
+	})
+}
+
+func Test_Transfer_Export_Pages(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		exporter := app.Get("gonode.exporter").(*base.Exporter)
+
+		for i := 0; i < 130; i++ {
+			node := manager.NewNode("core.raw")
+			node.Name = fmt.Sprintf("Page %d", i)
+			manager.Save(node, false)
+		}
+
+		buffer := bytes.NewBuffer([]byte(""))
+
+		count, err := exporter.Export(buffer, &base.ExportOptions{Types: []string{"core.raw"}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 130, count)
+		assert.Equal(t, 130, strings.Count(buffer.String(), "\n"))
+		assert.Equal(t, 1, strings.Count(buffer.String(), `"name":"Page 129"`))
+	})
+}
+
+func Test_Transfer_Import_Invalid(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		importer := app.Get("gonode.importer").(*base.Importer)
+
+		_, err := importer.Import(strings.NewReader(`{"node": "foo"`), &base.ImportOptions{})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,140 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/modules/blog"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTrashTree(app *goapp.App) (*base.Node, *base.Node) {
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+	collection := app.Get("gonode.handler_collection").(base.HandlerCollection)
+
+	parent := collection.NewNode("blog.post")
+	parent.Name = "Parent"
+	parent.Data.(*blog.Post).Title = "Parent"
+	manager.Save(parent, false)
+
+	child := collection.NewNode("blog.post")
+	child.Name = "Child"
+	child.Data.(*blog.Post).Title = "Child"
+	manager.Save(child, false)
+
+	manager.Move(child.Uuid, parent.Uuid)
+
+	manager.RemoveOne(manager.Find(child.Uuid))
+	manager.RemoveOne(manager.Find(parent.Uuid))
+
+	return parent, child
+}
+
+func Test_Trash_List_And_Undelete(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		auth := test.GetDefaultAuthHeader(ts)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		parent, child := createTrashTree(app)
+
+		res, _ := test.RunRequest("GET", ts.URL+"/api/v1.0/trash", nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		p := test.GetPager(app, res)
+		assert.Equal(t, 2, len(p.Elements))
+
+		res, _ = test.RunRequest("PUT", ts.URL+"/api/v1.0/trash/"+parent.Uuid.CleanString()+"/undelete?recursive", nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		assert.False(t, manager.Find(parent.Uuid).Deleted)
+		assert.False(t, manager.Find(child.Uuid).Deleted)
+
+		res, _ = test.RunRequest("PUT", ts.URL+"/api/v1.0/trash/"+parent.Uuid.CleanString()+"/undelete", nil, auth)
+		assert.Equal(t, 412, res.StatusCode)
+	})
+}
+
+func Test_Trash_Purge(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		trash := app.Get("gonode.trash").(*base.Trash)
+
+		parent, child := createTrashTree(app)
+
+		count, err := trash.Purge(time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		count, err = trash.Purge(0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		assert.Nil(t, manager.Find(parent.Uuid))
+		assert.Nil(t, manager.Find(child.Uuid))
+	})
+}
+
+func Test_Trash_Purge_Keeps_Parent_With_Children(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		trash := app.Get("gonode.trash").(*base.Trash)
+
+		parent, child := createTrashTree(app)
+
+		// the child is restored, the parent stays in the trash
+		trash.Undelete(manager.Find(child.Uuid), false)
+
+		count, err := trash.Purge(0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, count)
+		assert.NotNil(t, manager.Find(parent.Uuid))
+
+		// the parent is purged once its child is purged
+		manager.RemoveOne(manager.Find(child.Uuid))
+
+		count, err = trash.Purge(0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Nil(t, manager.Find(parent.Uuid))
+	})
+}
+
+func Test_Trash_Purge_Relations(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		trash := app.Get("gonode.trash").(*base.Trash)
+
+		image := manager.NewNode("media.image")
+		image.Name = "Image"
+		manager.Save(image, false)
+
+		post := manager.NewNode("blog.post")
+		post.Name = "Post"
+		post.Data.(*blog.Post).MainImage = image.Uuid
+		manager.Save(post, false)
+
+		manager.RemoveOne(manager.Find(image.Uuid))
+
+		count, err := trash.Purge(0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		// the post does not reference the purged image anymore
+		referencedBy := manager.ReferencedBy(manager.SelectBuilder(base.NewSelectOptions()), image.Uuid, "")
+		assert.Equal(t, 0, manager.FindBy(referencedBy, 0, 10).Len())
+	})
+}
@@ -0,0 +1,193 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/api"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/modules/user"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type treeResult struct {
+	Node     *base.Node    `json:"node"`
+	Children []*treeResult `json:"children"`
+}
+
+// createTree creates the nodes: /root, /root/b (weight 2), /root/a (weight 1), /root/a/leaf
+// and /root/hidden (not granted to the tree user)
+func createTree(app *goapp.App) map[string]*base.Node {
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+	nodes := make(map[string]*base.Node)
+
+	for _, slug := range []string{"root", "b", "a", "leaf", "hidden"} {
+		node := manager.NewNode("default")
+		node.Name = slug
+		node.Slug = slug
+		node.Access = []string{"node:tree:read"}
+
+		manager.Save(node, false)
+
+		nodes[slug] = node
+	}
+
+	nodes["a"].Weight = 1
+	nodes["b"].Weight = 2
+	nodes["hidden"].Access = []string{"no.role"}
+
+	for _, slug := range []string{"a", "b", "hidden"} {
+		manager.Save(nodes[slug], false)
+		manager.Move(nodes[slug].Uuid, nodes["root"].Uuid)
+	}
+
+	manager.Move(nodes["leaf"].Uuid, nodes["a"].Uuid)
+
+	for slug, node := range nodes {
+		nodes[slug] = manager.Find(node.Uuid)
+	}
+
+	// a user with the api role, but only granted to the node:tree:read nodes
+	u := manager.NewNode("core.user")
+	u.Name = "Tree User"
+
+	data := u.Data.(*user.User)
+	data.Email = "tree@example.org"
+	data.Enabled = true
+	data.NewPassword = "tree"
+	data.Username = "tree"
+	data.Roles = []string{"ROLE_API", "node:api:tree", "node:api:copy", "node:tree:read"}
+
+	u.Meta.(*user.UserMeta).PasswordCost = 1
+
+	manager.Save(u, false)
+
+	return nodes
+}
+
+func Test_API_GET_Node_Children(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		nodes := createTree(app)
+		auth := test.GetAuthHeaderFromCredentials("tree", "tree", ts)
+
+		res, _ := test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/nodes/%s/children", ts.URL, nodes["root"].Uuid), nil, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+
+		pager := test.GetPager(app, res)
+
+		assert.Equal(t, 2, len(pager.Elements))
+		assert.Equal(t, "a", pager.Elements[0].(*base.Node).Name)
+		assert.Equal(t, "b", pager.Elements[1].(*base.Node).Name)
+
+		res, _ = test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/nodes/%s/children", ts.URL, nodes["hidden"].Uuid), nil, auth)
+
+		assert.Equal(t, 403, res.StatusCode)
+	})
+}
+
+func Test_API_GET_Node_Ancestors_And_Breadcrumbs(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		nodes := createTree(app)
+		auth := test.GetAuthHeaderFromCredentials("tree", "tree", ts)
+
+		res, _ := test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/nodes/%s/ancestors", ts.URL, nodes["leaf"].Uuid), nil, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+
+		ancestors := []*base.Node{}
+		json.Unmarshal(res.GetBody(), &ancestors)
+
+		assert.Equal(t, 2, len(ancestors))
+		assert.Equal(t, nodes["root"].Uuid, ancestors[0].Uuid)
+		assert.Equal(t, nodes["a"].Uuid, ancestors[1].Uuid)
+
+		res, _ = test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/nodes/%s/breadcrumbs", ts.URL, nodes["leaf"].Uuid), nil, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+
+		breadcrumbs := []*api.Breadcrumb{}
+		json.Unmarshal(res.GetBody(), &breadcrumbs)
+
+		assert.Equal(t, 3, len(breadcrumbs))
+		assert.Equal(t, "/root", breadcrumbs[0].Path)
+		assert.Equal(t, "/root/a", breadcrumbs[1].Path)
+		assert.Equal(t, "/root/a/leaf", breadcrumbs[2].Path)
+		assert.Equal(t, nodes["leaf"].Uuid, breadcrumbs[2].Uuid)
+	})
+}
+
+func Test_API_GET_Node_Tree(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		nodes := createTree(app)
+		auth := test.GetAuthHeaderFromCredentials("tree", "tree", ts)
+
+		res, _ := test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/nodes/%s/tree", ts.URL, nodes["root"].Uuid), nil, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+
+		tree := &treeResult{}
+		json.Unmarshal(res.GetBody(), tree)
+
+		assert.Equal(t, "root", tree.Node.Name)
+		assert.Equal(t, 2, len(tree.Children))
+		assert.Equal(t, "a", tree.Children[0].Node.Name)
+		assert.Equal(t, 0, len(tree.Children[0].Children))
+
+		res, _ = test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/nodes/%s/tree?depth=2", ts.URL, nodes["root"].Uuid), nil, auth)
+
+		tree = &treeResult{}
+		json.Unmarshal(res.GetBody(), tree)
+
+		assert.Equal(t, 2, len(tree.Children))
+		assert.Equal(t, 1, len(tree.Children[0].Children))
+		assert.Equal(t, "leaf", tree.Children[0].Children[0].Node.Name)
+		assert.Equal(t, "b", tree.Children[1].Node.Name)
+	})
+}
+
+func Test_API_PUT_Node_Children_Order(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		nodes := createTree(app)
+		auth := test.GetDefaultAuthHeader(ts)
+
+		body := strings.NewReader(fmt.Sprintf(`["%s", "%s", "%s"]`, nodes["b"].Uuid, nodes["hidden"].Uuid, nodes["a"].Uuid))
+		res, _ := test.RunRequest("PUT", fmt.Sprintf("%s/api/v1.0/nodes/%s/children/order?revision", ts.URL, nodes["root"].Uuid), body, auth)
+
+		assert.Equal(t, 200, res.StatusCode)
+
+		a := manager.Find(nodes["a"].Uuid)
+		assert.Equal(t, 2, a.Weight)
+		assert.Equal(t, nodes["a"].Revision+1, a.Revision)
+
+		res, _ = test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/nodes/%s/children", ts.URL, nodes["root"].Uuid), nil, auth)
+
+		pager := test.GetPager(app, res)
+
+		assert.Equal(t, 3, len(pager.Elements))
+		assert.Equal(t, "b", pager.Elements[0].(*base.Node).Name)
+		assert.Equal(t, "hidden", pager.Elements[1].(*base.Node).Name)
+		assert.Equal(t, "a", pager.Elements[2].(*base.Node).Name)
+
+		// the leaf is not a child of the root
+		body = strings.NewReader(fmt.Sprintf(`["%s"]`, nodes["leaf"].Uuid))
+		res, _ = test.RunRequest("PUT", fmt.Sprintf("%s/api/v1.0/nodes/%s/children/order", ts.URL, nodes["root"].Uuid), body, auth)
+
+		assert.Equal(t, 412, res.StatusCode)
+
+		res, _ = test.RunRequest("PUT", fmt.Sprintf("%s/api/v1.0/nodes/%s/children/order", ts.URL, nodes["root"].Uuid), strings.NewReader("{}"), auth)
+
+		assert.Equal(t, 400, res.StatusCode)
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/api"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/modules/webhook"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_API_Webhook_Deliveries(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		signatures := make(chan bool, 10)
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			body, _ := io.ReadAll(req.Body)
+
+			signatures <- req.Header.Get(webhook.HEADER_SIGNATURE) == webhook.Sign("secret", body)
+		}))
+		defer receiver.Close()
+
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+		dispatcher := app.Get("gonode.webhook.dispatcher").(*webhook.Dispatcher)
+
+		node := manager.NewNode("core.webhook")
+		node.Name = "Search cluster"
+		node.Access = []string{"node:api:master"}
+		node.Data.(*webhook.Webhook).Url = receiver.URL
+		node.Data.(*webhook.Webhook).Secret = "secret"
+		node.Data.(*webhook.Webhook).Types = []string{"blog.post"}
+
+		_, err := manager.Save(node, false)
+		assert.NoError(t, err)
+
+		deliveries, err := dispatcher.Dispatch(0, &base.ModelEvent{Type: "blog.post", Action: "Create"}, `{"type":"blog.post","action":"Create"}`)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(deliveries))
+		assert.True(t, <-signatures)
+
+		auth := test.GetDefaultAuthHeader(ts)
+
+		// the secret is only readable by the administrators
+		res, _ := test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/nodes/%s", ts.URL, node.Uuid), nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, "secret", test.GetNode(app, res).Data.(*webhook.Webhook).Secret)
+
+		res, _ = test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/webhooks/%s/deliveries", ts.URL, node.Uuid), nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+
+		pager := &api.ApiPager{}
+		json.Unmarshal(res.GetBody(), pager)
+		assert.Equal(t, 1, len(pager.Elements))
+
+		url := fmt.Sprintf("%s/api/v1.0/webhooks/deliveries/%d", ts.URL, deliveries[0].Id)
+
+		// the delivery is updated by the job worker once the receiver has answered
+		delivery := &webhook.Delivery{}
+		for i := 0; i < 50 && delivery.Status != webhook.DELIVERY_SUCCESS; i++ {
+			time.Sleep(20 * time.Millisecond)
+
+			res, _ = test.RunRequest("GET", url, nil, auth)
+			assert.Equal(t, 200, res.StatusCode)
+
+			json.Unmarshal(res.GetBody(), delivery)
+		}
+
+		assert.Equal(t, webhook.DELIVERY_SUCCESS, delivery.Status)
+		assert.Equal(t, 1, len(delivery.History))
+		assert.Equal(t, 200, delivery.History[0].StatusCode)
+
+		res, _ = test.RunRequest("POST", url+"/replay", nil, auth)
+		assert.Equal(t, 201, res.StatusCode)
+		assert.True(t, <-signatures)
+
+		replay := &webhook.Delivery{}
+		json.Unmarshal(res.GetBody(), replay)
+		assert.NotEqual(t, delivery.Id, replay.Id)
+		assert.Equal(t, delivery.Payload, replay.Payload)
+
+		res, _ = test.RunRequest("GET", fmt.Sprintf("%s/api/v1.0/webhooks/deliveries/%d", ts.URL, 1000), nil, auth)
+		assert.Equal(t, 404, res.StatusCode)
+	})
+}
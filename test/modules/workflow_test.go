@@ -0,0 +1,98 @@
+// Copyright © 2014-2023 Thomas Rabaix <thomas.rabaix@gmail.com>.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package modules
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rande/goapp"
+	"github.com/rande/gonode/modules/base"
+	"github.com/rande/gonode/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Workflow_Transitions(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		auth := test.GetDefaultAuthHeader(ts)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		app.Get("gonode.workflows").(*base.Workflows).Add("core.raw", &base.Workflow{
+			Initial: base.StatusDraft,
+			Transitions: []*base.Transition{
+				{Name: "submit", From: []int{base.StatusDraft}, To: base.StatusCompleted},
+				{Name: "validate", From: []int{base.StatusCompleted}, To: base.StatusValidated, Roles: []string{"node:publisher"}},
+			},
+		})
+
+		raw := manager.NewNode("core.raw")
+		raw.Name = "Humans.txt"
+		raw.Status = base.StatusDraft
+		manager.Save(raw, false)
+
+		// illegal transition with the update endpoint
+		body := strings.NewReader(fmt.Sprintf(`{"uuid": "%s", "type": "core.raw", "name": "Humans.txt", "slug": "humans.txt", "revision": 1, "status": 3, "access": ["node:api:master"]}`, raw.Uuid))
+		res, _ := test.RunRequest("PUT", ts.URL+"/api/v1.0/nodes/"+raw.Uuid.CleanString(), body, auth)
+		assert.Equal(t, 412, res.StatusCode)
+
+		res, _ = test.RunRequest("POST", ts.URL+"/api/v1.0/nodes/"+raw.Uuid.CleanString()+"/transitions/validate", nil, auth)
+		assert.Equal(t, 412, res.StatusCode)
+
+		res, _ = test.RunRequest("POST", ts.URL+"/api/v1.0/nodes/"+raw.Uuid.CleanString()+"/transitions/submit", nil, auth)
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, base.StatusCompleted, manager.Find(raw.Uuid).Status)
+
+		// the user does not have the node:publisher role
+		res, _ = test.RunRequest("POST", ts.URL+"/api/v1.0/nodes/"+raw.Uuid.CleanString()+"/transitions/validate", nil, auth)
+		assert.Equal(t, 403, res.StatusCode)
+
+		res, _ = test.RunRequest("POST", ts.URL+"/api/v1.0/nodes/"+raw.Uuid.CleanString()+"/transitions/publish", nil, auth)
+		assert.Equal(t, 404, res.StatusCode)
+	})
+}
+
+func Test_Workflow_Transition_Locked(t *testing.T) {
+	test.RunHttpTest(t, func(t *testing.T, ts *httptest.Server, app *goapp.App) {
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
+
+		app.Get("gonode.workflows").(*base.Workflows).Add("core.raw", &base.Workflow{
+			Initial: base.StatusDraft,
+			Transitions: []*base.Transition{
+				{Name: "submit", From: []int{base.StatusDraft}, To: base.StatusCompleted},
+			},
+		})
+
+		raw := manager.NewNode("core.raw")
+		raw.Name = "Humans.txt"
+		raw.Status = base.StatusDraft
+		raw.Access = []string{"node:lock:edit"}
+		manager.Save(raw, false)
+
+		createEditor(app, "alice", "node:api:transition")
+		createEditor(app, "bob", "node:api:transition")
+
+		alice := test.GetAuthHeaderFromCredentials("alice", "alice", ts)
+		bob := test.GetAuthHeaderFromCredentials("bob", "bob", ts)
+
+		res, _ := test.RunRequest("PUT", ts.URL+"/api/v1.0/nodes/"+raw.Uuid.CleanString()+"/lock", nil, alice)
+		assert.Equal(t, 200, res.StatusCode)
+
+		// the node is locked by another editor
+		res, _ = test.RunRequest("POST", ts.URL+"/api/v1.0/nodes/"+raw.Uuid.CleanString()+"/transitions/submit", nil, bob)
+		assert.Equal(t, 423, res.StatusCode)
+		assert.Equal(t, base.StatusDraft, manager.Find(raw.Uuid).Status)
+
+		// the owner's transition releases the lock
+		res, _ = test.RunRequest("POST", ts.URL+"/api/v1.0/nodes/"+raw.Uuid.CleanString()+"/transitions/submit", nil, alice)
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, base.StatusCompleted, manager.Find(raw.Uuid).Status)
+
+		res, _ = test.RunRequest("GET", ts.URL+"/api/v1.0/nodes/"+raw.Uuid.CleanString()+"/lock", nil, alice)
+		assert.Equal(t, 404, res.StatusCode)
+	})
+}
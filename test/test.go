@@ -26,6 +26,7 @@ import (
 	"github.com/rande/gonode/core/form"
 	"github.com/rande/gonode/core/helper"
 	"github.com/rande/gonode/core/logger"
+	"github.com/rande/gonode/core/migration"
 	"github.com/rande/gonode/core/router"
 	"github.com/rande/gonode/core/security"
 	"github.com/rande/gonode/modules/api"
@@ -43,6 +44,7 @@ import (
 	"github.com/rande/gonode/modules/setup"
 	"github.com/rande/gonode/modules/template"
 	"github.com/rande/gonode/modules/user"
+	"github.com/rande/gonode/modules/webhook"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/zenazn/goji/web"
@@ -87,6 +89,12 @@ func GetLifecycle(file string) *goapp.Lifecycle {
 
 	config.LoadConfigurationFromFile(file, conf)
 
+	// run the tests without a PostgreSQL server: GONODE_TEST_DATABASE=sqlite go test ./test/...
+	if os.Getenv("GONODE_TEST_DATABASE") == config.DATABASE_SQLITE {
+		conf.Databases["master"].Type = config.DATABASE_SQLITE
+		conf.Databases["master"].DSN = fmt.Sprintf("file:%s/gonode_test.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate", os.TempDir())
+	}
+
 	l.Config(func(app *goapp.App) error {
 		app.Set("gonode.configuration", func(app *goapp.App) interface{} {
 			return conf
@@ -133,10 +141,12 @@ func GetLifecycle(file string) *goapp.Lifecycle {
 	localisation.Configure(l, conf)
 	logger.Configure(l, conf)
 	commands.Configure(l, conf)
+	migration.Configure(l, conf)
 	security.ConfigureCors(l, conf)
 	node_guard.Configure(l, conf)
 	security.ConfigureSecurity(l, conf)
 	api.Configure(l, conf)
+	webhook.Configure(l, conf)
 	setup.Configure(l, conf)
 	prism.Configure(l, conf)
 	router.Configure(l, conf)
@@ -289,7 +299,7 @@ func RunHttpTest(t *testing.T, f func(t *testing.T, ts *httptest.Server, app *go
 		helper.PanicOnError(err)
 
 		// create a valid user
-		manager := app.Get("gonode.manager").(*base.PgNodeManager)
+		manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 
 		u := app.Get("gonode.handler_collection").(base.HandlerCollection).NewNode("core.user")
 		u.Name = "User ZZ"
@@ -315,7 +325,7 @@ func RunHttpTest(t *testing.T, f func(t *testing.T, ts *httptest.Server, app *go
 }
 
 func InitSearchFixture(app *goapp.App) []*base.Node {
-	manager := app.Get("gonode.manager").(*base.PgNodeManager)
+	manager := app.Get("gonode.manager").(*base.SqlNodeManager)
 	collection := app.Get("gonode.handler_collection").(base.Handlers)
 	nodes := make([]*base.Node, 0)
 